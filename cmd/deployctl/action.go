@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+)
+
+type (
+	InputError struct {
+		Name  string
+		Value string
+	}
+)
+
+func (err *InputError) Error() string {
+	return "input " + err.Name + " must be true or false, got " + strconv.Quote(err.Value)
+}
+
+func action(ctx *deployctl.Context, options *options, args []string) error {
+	if config := input("CONFIG"); config != "" {
+		options.config = config
+	}
+
+	if environment := input("ENVIRONMENT"); environment != "" {
+		options.environment = environment
+	}
+
+	if stage := input("STAGE"); stage != "" {
+		options.stage = stage
+	}
+
+	options.variableFlags = append(options.variableFlags, inputLines("VARIABLES")...)
+	options.variableFiles = append(options.variableFiles, inputLines("VAR_FILES")...)
+
+	for name, target := range map[string]*bool{
+		"DRY_RUN": &options.dryRun,
+		"STRICT":  &options.strict,
+		"LOCKED":  &options.locked,
+		"CONFIRM": &options.confirmed,
+	} {
+		value := input(name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return &InputError{Name: strings.ToLower(name), Value: value}
+		}
+
+		*target = *target || parsed
+	}
+
+	options.ci = string(ciGitHub)
+	options.outputs = true
+
+	return run(ctx, options, append(args, strings.Fields(input("SCRIPTS"))...))
+}
+
+func input(name string) string {
+	return strings.TrimSpace(os.Getenv("INPUT_" + name))
+}
+
+func inputLines(name string) []string {
+	lines := []string(nil)
+
+	for _, line := range strings.Split(input(name), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+)
+
+const (
+	defaultArtifactsAddress = ":8734"
+
+	artifactsHeaderTimeout = 10 * time.Second
+	artifactsReadTimeout   = 30 * time.Second
+	artifactsIdleTimeout   = 2 * time.Minute
+)
+
+var (
+	ErrArtifactsUsage     = errors.New("usage: artifacts serve [-listen address] [-cert file -key file]")
+	ErrArtifactsPlaintext = errors.New("artifacts are only served without TLS on a loopback address, set -cert and -key")
+)
+
+func artifacts(ctx *deployctl.Context, options *options, args []string) error {
+	if len(args) == 0 || args[0] != "serve" {
+		return ErrArtifactsUsage
+	}
+
+	flags := flag.NewFlagSet("artifacts serve", flag.ContinueOnError)
+	address := flags.String("listen", defaultArtifactsAddress, "address to serve cached artifacts on")
+	cert := flags.String("cert", "", "TLS certificate file")
+	key := flags.String("key", "", "TLS private key file")
+
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() != 0 || (*cert == "") != (*key == "") {
+		return ErrArtifactsUsage
+	}
+
+	if *cert == "" && !loopback(*address) {
+		return ErrArtifactsPlaintext
+	}
+
+	ctx.SetCache(options.cachedir())
+
+	handler, err := ctx.ArtifactHandler(os.Getenv(deployctl.ArtifactsToken))
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(deployctl.ArtifactsPath, handler)
+
+	server := &http.Server{
+		Addr:              *address,
+		Handler:           mux,
+		ReadHeaderTimeout: artifactsHeaderTimeout,
+		ReadTimeout:       artifactsReadTimeout,
+		IdleTimeout:       artifactsIdleTimeout,
+	}
+
+	log.Printf("serving artifacts from %s on %s", options.cachedir(), *address)
+
+	if *cert != "" {
+		return server.ListenAndServeTLS(*cert, *key)
+	}
+
+	return server.ListenAndServe()
+}
+
+func loopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
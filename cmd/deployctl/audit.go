@@ -0,0 +1,12 @@
+package main
+
+import (
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+)
+
+func audit(ctx *deployctl.Context, options *options, args []string) error {
+	options.audit = true
+	options.dryRun = true
+
+	return run(ctx, options, args)
+}
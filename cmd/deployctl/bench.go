@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+var ErrBenchUsage = errors.New("usage: bench [-n iterations] <script> [script...]")
+
+func bench(ctx *deployctl.Context, options *options, args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	iterations := flags.Int("n", 10, "number of iterations per script")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 || *iterations < 1 {
+		return ErrBenchUsage
+	}
+
+	_, deploys, err := options.load()
+	if err != nil {
+		return err
+	}
+
+	input := bufio.NewReader(os.Stdin)
+
+	ctx.SetConfirmed(options.confirmed)
+	ctx.OnConfirm(func(deploy *models.Deploy, labels []string) error {
+		return confirm(input, deploy, labels)
+	})
+
+	for _, deploy := range deploys {
+		resolver, err := variables.Resolve(deploy, options.variableFiles, options.variableFlags)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range flags.Args() {
+			if _, ok := deploy.Scripts.Scripts[name]; !ok {
+				continue
+			}
+
+			benchmark, err := ctx.Bench(deploy, resolver, name, *iterations)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf(
+				"%s\tn=%d\tfailed=%d\tmin=%s\tp50=%s\tp90=%s\tp99=%s\tmax=%s\tmean=%s\t%.1f/s\tread=%s/s\twritten=%s/s\n",
+				benchmark.Name,
+				len(benchmark.Durations),
+				benchmark.Failures,
+				benchmark.Percentile(0),
+				benchmark.Percentile(50),
+				benchmark.Percentile(90),
+				benchmark.Percentile(99),
+				benchmark.Percentile(100),
+				benchmark.Mean(),
+				benchmark.Throughput(),
+				models.Size(benchmark.ReadRate()),
+				models.Size(benchmark.WriteRate()),
+			)
+		}
+	}
+
+	return nil
+}
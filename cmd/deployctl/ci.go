@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	ciFormat string
+
+	ci struct {
+		format  ciFormat
+		section string
+		outputs bool
+	}
+)
+
+const (
+	ciAuto   ciFormat = "auto"
+	ciNone   ciFormat = "none"
+	ciGitHub ciFormat = "github"
+	ciGitLab ciFormat = "gitlab"
+)
+
+var ErrCIUnknown = errors.New("ci must be \"auto\", \"none\", \"github\" or \"gitlab\"")
+
+func newCI(format string, outputs bool) (*ci, error) {
+	detected, err := detectCI(format)
+	if err != nil {
+		return nil, err
+	}
+
+	detected.outputs = outputs
+	return detected, nil
+}
+
+func detectCI(format string) (*ci, error) {
+	switch ciFormat(format) {
+	case ciAuto:
+		switch {
+		case os.Getenv("GITHUB_ACTIONS") == "true":
+			return &ci{format: ciGitHub}, nil
+		case os.Getenv("GITLAB_CI") == "true":
+			return &ci{format: ciGitLab}, nil
+		}
+
+		return &ci{format: ciNone}, nil
+	case ciNone, ciGitHub, ciGitLab:
+		return &ci{format: ciFormat(format)}, nil
+	}
+
+	return nil, ErrCIUnknown
+}
+
+func (ci *ci) begin(index int, deploy *models.Deploy) {
+	title := documentName(index, deploy)
+
+	switch ci.format {
+	case ciGitHub:
+		fmt.Println("::group::" + title)
+	case ciGitLab:
+		ci.section = "deploy_" + strconv.Itoa(index+1)
+		fmt.Printf("\x1b[0Ksection_start:%d:%s\r\x1b[0K%s\n", time.Now().Unix(), ci.section, title)
+	}
+}
+
+func (ci *ci) end(report *deployctl.Report) {
+	switch ci.format {
+	case ciGitHub:
+		fmt.Println("::endgroup::")
+
+		for _, result := range report.Failed() {
+			fmt.Printf("::error title=%s::%s\n", githubProperty(result.Type+" "+result.Label()), githubData(result.Err.Error()))
+		}
+	case ciGitLab:
+		fmt.Printf("\x1b[0Ksection_end:%d:%s\r\x1b[0K\n", time.Now().Unix(), ci.section)
+	}
+}
+
+func (ci *ci) summary(reports []*deployctl.Report) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if ci.format != ciGitHub || path == "" {
+		return nil
+	}
+
+	builder := new(strings.Builder)
+	builder.WriteString("## Deploy\n\n| Script | Type | Outcome | Duration | Error |\n| --- | --- | --- | --- | --- |\n")
+
+	for _, report := range reports {
+		for _, result := range report.Results {
+			message := ""
+			if result.Err != nil {
+				message = markdownCell(result.Err.Error())
+			}
+
+			fmt.Fprintf(builder, "| %s | %s | %s | %s | %s |\n", markdownCell(result.Label()), result.Type, result.Outcome(), result.Duration.Round(time.Millisecond), message)
+		}
+	}
+
+	return appendFile(path, builder.String())
+}
+
+func (ci *ci) output(reports []*deployctl.Report) error {
+	path := os.Getenv("GITHUB_OUTPUT")
+	if !ci.outputs || path == "" {
+		return nil
+	}
+
+	builder := new(strings.Builder)
+	failed := []string(nil)
+
+	for _, outcome := range []deployctl.Outcome{deployctl.OutcomeChanged, deployctl.OutcomeUnchanged, deployctl.OutcomeFailed, deployctl.OutcomeSkipped, deployctl.OutcomeUnreachable, deployctl.OutcomePlanned, deployctl.OutcomeRolledBack} {
+		count := 0
+
+		for _, report := range reports {
+			count += report.Count(outcome)
+		}
+
+		fmt.Fprintf(builder, "%s=%d\n", outcome, count)
+	}
+
+	for _, report := range reports {
+		for _, result := range report.Results {
+			switch result.Outcome() {
+			case deployctl.OutcomeFailed, deployctl.OutcomeUnreachable:
+				failed = append(failed, result.Label())
+			}
+		}
+	}
+
+	fmt.Fprintf(builder, "failed-scripts=%s\n", strings.Join(failed, ","))
+
+	return appendFile(path, builder.String())
+}
+
+func appendFile(path, text string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.WriteString(text)
+
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+func documentName(index int, deploy *models.Deploy) string {
+	if deploy.Target.Stage != "" {
+		return deploy.Target.Stage
+	}
+
+	return "document " + strconv.Itoa(index+1)
+}
+
+func githubData(text string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(text)
+}
+
+func githubProperty(text string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(text)
+}
+
+func markdownCell(text string) string {
+	return strings.NewReplacer("|", "\\|", "\r", "", "\n", "<br>").Replace(text)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/delta"
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+)
+
+var (
+	ErrDeltaSignatureUsage = errors.New("usage: delta-signature <file>")
+	ErrDeltaPatchUsage     = errors.New("usage: delta-patch [-sha256 sum] <base> <to>")
+)
+
+func deltaSignature(ctx *deployctl.Context, options *options, args []string) error {
+	if len(args) != 1 {
+		return ErrDeltaSignatureUsage
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	signature, err := delta.NewSignature(file, delta.DefaultBlockSize)
+	if err != nil {
+		return err
+	}
+
+	return delta.WriteSignature(os.Stdout, signature)
+}
+
+func deltaPatch(ctx *deployctl.Context, options *options, args []string) error {
+	flags := flag.NewFlagSet("delta-patch", flag.ContinueOnError)
+	sum := flags.String("sha256", "", "expected SHA-256 of the patched file")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() != 2 {
+		return ErrDeltaPatchUsage
+	}
+
+	base, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer base.Close()
+
+	to := flags.Arg(1)
+
+	file, err := os.OpenFile(to, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	writer := bufio.NewWriter(io.MultiWriter(file, hash))
+
+	err = delta.Apply(os.Stdin, base, writer)
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	if err == nil {
+		err = file.Close()
+	}
+
+	if actual := hex.EncodeToString(hash.Sum(nil)); err == nil && *sum != "" && !strings.EqualFold(actual, *sum) {
+		err = &deployctl.ChecksumError{Source: to, Expected: *sum, Actual: actual}
+	}
+
+	if err != nil {
+		os.Remove(to)
+	}
+
+	return err
+}
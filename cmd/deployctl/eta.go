@@ -0,0 +1,65 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	progress struct {
+		durations map[string]time.Duration
+		runs      int
+		plan      time.Duration
+		stage     time.Duration
+		name      string
+	}
+)
+
+func newProgress(options *options, deploys []*models.Deploy, names []string) (*progress, error) {
+	runs, err := deployctl.ReadHistory(options.historyfile())
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &progress{
+		durations: deployctl.Durations(runs, deployctl.EstimateRuns),
+		runs:      min(len(runs), deployctl.EstimateRuns),
+	}
+
+	for _, deploy := range deploys {
+		progress.plan += deployctl.Estimate(deploy, progress.durations, names)
+	}
+
+	return progress, nil
+}
+
+func (progress *progress) begin(index int, deploy *models.Deploy, names []string) {
+	progress.name = documentName(index, deploy)
+
+	progress.stage = deployctl.Estimate(deploy, progress.durations, names)
+}
+
+func (progress *progress) start(name, host string) {
+	if progress.runs == 0 {
+		return
+	}
+
+	log.Printf(
+		"stage %s ~%s remaining, plan ~%s remaining based on last %d runs",
+		progress.name,
+		progress.stage.Round(time.Second),
+		progress.plan.Round(time.Second),
+		progress.runs,
+	)
+
+	estimate, ok := progress.durations[models.Label(name, host)]
+	if !ok {
+		estimate = progress.durations[name]
+	}
+
+	progress.stage = max(progress.stage-estimate, 0)
+	progress.plan = max(progress.plan-estimate, 0)
+}
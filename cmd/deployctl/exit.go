@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+	"github.com/gohryt/dotdeploy/internal/lint"
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	configError struct {
+		err error
+	}
+)
+
+const (
+	exitExecution   = 1
+	exitValidation  = 2
+	exitWindow      = 3
+	exitInterrupted = 130
+)
+
+func (err *configError) Error() string {
+	return err.err.Error()
+}
+
+func (err *configError) Unwrap() error {
+	return err.err
+}
+
+func exit(err error) {
+	if structured != nil {
+		structured.error(err)
+	} else {
+		log.Println(err)
+	}
+
+	failed := failedScripts(err)
+	if len(failed) > 0 {
+		log.Printf("failed: %s", strings.Join(failed, ", "))
+	}
+
+	os.Exit(exitCode(err))
+}
+
+func exitCode(err error) int {
+	var config *configError
+	var unknown *deployctl.UnknownScriptError
+	var remote *deployctl.UnknownRemoteError
+	var window *deployctl.WindowError
+	var overrun *deployctl.OverrunError
+	var input *InputError
+	var lintErr *LintError
+	var rule *lint.UnknownRuleError
+	var ruleErr *lint.RuleError
+	var condition *deployctl.ConditionError
+
+	switch {
+	case errors.As(err, &config), errors.As(err, &unknown), errors.As(err, &remote), errors.As(err, &input), errors.As(err, &lintErr), errors.As(err, &rule), errors.As(err, &ruleErr), errors.As(err, &condition):
+		return exitValidation
+	case errors.As(err, &window), errors.As(err, &overrun):
+		return exitWindow
+	case deployctl.Interrupted(err):
+		return exitInterrupted
+	}
+
+	return exitExecution
+}
+
+func failedScripts(err error) []string {
+	failed := []string(nil)
+
+	var walk func(err error)
+	walk = func(err error) {
+		var script *deployctl.ScriptError
+
+		switch wrapped := err.(type) {
+		case *deployctl.PrepareError:
+			walk(wrapped.Err)
+		case interface{ Unwrap() []error }:
+			for _, inner := range wrapped.Unwrap() {
+				walk(inner)
+			}
+		default:
+			if errors.As(err, &script) {
+				failed = append(failed, models.Label(script.Name, script.Host))
+			}
+		}
+	}
+
+	walk(err)
+	return failed
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+func gc(ctx *deployctl.Context, options *options, args []string) error {
+	_, deploys, err := options.load()
+	if err != nil {
+		return err
+	}
+
+	ctx.SetDryRun(options.dryRun)
+	ctx.SetCache(options.cachedir())
+
+	retention := models.Retain(deploys)
+	retention.Runs = options.keep(retention)
+
+	collection, err := ctx.CollectGarbage(retention, options.historyfile(), time.Now())
+
+	verb := "removed"
+	if options.dryRun {
+		verb = "would remove"
+	}
+
+	for _, garbage := range collection.Garbage {
+		fmt.Println(verb, garbage)
+	}
+
+	if collection.Runs > 0 {
+		fmt.Printf("%s %d old runs from history\n", verb, collection.Runs)
+	}
+
+	fmt.Printf("%s %d entries, %s\n", verb, len(collection.Garbage), models.Size(collection.Freed()))
+
+	return err
+}
+
+func (options *options) keep(retention models.Retention) int {
+	if retention.Runs > 0 {
+		return retention.Runs
+	}
+
+	return options.historyKeep
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+var ErrHistoryUsage = errors.New("usage: history [show <run> | diff <run> <run>], where <run> is an ID or last")
+
+func history(ctx *deployctl.Context, options *options, args []string) error {
+	runs, err := deployctl.ReadHistory(options.historyfile())
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		for _, run := range runs {
+			fmt.Printf("%d\t%s\t%s\t%s\t%.12s\n", run.ID, run.Start.Format("2006-01-02 15:04:05"), run.Duration, runStatus(run), run.Config)
+		}
+
+		return nil
+	}
+
+	switch {
+	case args[0] == "show" && len(args) == 2:
+		run, err := findRun(runs, args[1])
+		if err != nil {
+			return err
+		}
+
+		showRun(run)
+		return nil
+	case args[0] == "diff" && len(args) == 3:
+		fromRun, err := findRun(runs, args[1])
+		if err != nil {
+			return err
+		}
+
+		toRun, err := findRun(runs, args[2])
+		if err != nil {
+			return err
+		}
+
+		for _, line := range deployctl.DiffRuns(fromRun, toRun) {
+			fmt.Println(line)
+		}
+
+		return nil
+	}
+
+	return ErrHistoryUsage
+}
+
+func findRun(runs []*models.Run, reference string) (*models.Run, error) {
+	if reference == "last" {
+		if len(runs) == 0 {
+			return nil, deployctl.ErrRunNotFound
+		}
+
+		return runs[len(runs)-1], nil
+	}
+
+	id, err := strconv.Atoi(reference)
+	if err != nil {
+		return nil, ErrHistoryUsage
+	}
+
+	return deployctl.FindRun(runs, id)
+}
+
+func showRun(run *models.Run) {
+	fmt.Printf("run %d\nstarted %s\nduration %s\nstatus %s\nconfig %s\n", run.ID, run.Start.Format("2006-01-02 15:04:05"), run.Duration, runStatus(run), run.Config)
+
+	if run.Error != "" {
+		fmt.Printf("error %s\n", run.Error)
+	}
+
+	fmt.Println()
+
+	for _, result := range run.Results {
+		fmt.Printf("%s\t%s\t%s\t%s\t%s\n", result.Label(), result.Type, result.Outcome, result.Duration, result.Error)
+	}
+}
+
+func runStatus(run *models.Run) string {
+	if run.Aborted {
+		return "aborted"
+	}
+
+	if run.Failed {
+		return "failed"
+	}
+
+	return "ok"
+}
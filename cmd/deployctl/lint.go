@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+	"github.com/gohryt/dotdeploy/internal/lint"
+)
+
+type (
+	LintError struct {
+		Errors int
+	}
+)
+
+func (err *LintError) Error() string {
+	return "lint found " + strconv.Itoa(err.Errors) + " errors"
+}
+
+func lintConfig(ctx *deployctl.Context, options *options, args []string) error {
+	_, deploys, err := options.load()
+	if err != nil {
+		return err
+	}
+
+	errors := 0
+
+	for i, deploy := range deploys {
+		findings, err := lint.Check(deploy)
+		if err != nil {
+			return err
+		}
+
+		if len(deploys) > 1 && len(findings) > 0 {
+			fmt.Printf("# document %d environment=%q stage=%q\n", i+1, deploy.Target.Environment, deploy.Target.Stage)
+		}
+
+		for _, finding := range findings {
+			script := finding.Script
+			if script == "" {
+				script = "-"
+			}
+
+			fmt.Printf("%s\t%s\t%s\t%s\n", finding.Severity, finding.Rule, script, finding.Message)
+		}
+
+		errors += lint.Errors(findings)
+	}
+
+	if errors > 0 {
+		return &LintError{Errors: errors}
+	}
+
+	return nil
+}
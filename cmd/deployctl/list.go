@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+)
+
+func list(ctx *deployctl.Context, options *options, args []string) error {
+	_, deploys, err := options.load()
+	if err != nil {
+		return err
+	}
+
+	for i, deploy := range deploys {
+		if len(deploys) > 1 {
+			fmt.Printf("# document %d environment=%q stage=%q\n", i+1, deploy.Target.Environment, deploy.Target.Stage)
+		}
+
+		for position, name := range deploy.Order() {
+			script := deploy.Scripts.Scripts[name]
+			fmt.Printf("%d\t%s\t%s\t%s\n", position+1, name, script.Type(), strings.Join(script.Follow, ","))
+		}
+	}
+
+	return nil
+}
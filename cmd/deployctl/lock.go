@@ -0,0 +1,28 @@
+package main
+
+import (
+	"os"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+)
+
+func lock(ctx *deployctl.Context, options *options, args []string) error {
+	config, deploys, err := options.load()
+	if err != nil {
+		return err
+	}
+
+	lock, err := ctx.Lock(config, deploys)
+	if err != nil {
+		return err
+	}
+
+	data, err := sonic.ConfigDefault.MarshalIndent(lock, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(options.lockfile(), data, 0o644)
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+)
+
+type (
+	jsonLog struct {
+		mutex sync.Mutex
+		out   io.Writer
+	}
+
+	logLine struct {
+		Time    time.Time
+		Level   string
+		Message string
+	}
+
+	outputLine struct {
+		Time   time.Time
+		Event  string
+		Script string
+		Host   string `json:",omitempty"`
+		Line   string
+	}
+
+	resultLine struct {
+		Time       time.Time
+		Event      string
+		Script     string
+		Host       string `json:",omitempty"`
+		Type       string
+		Status     deployctl.Outcome
+		Start      time.Time
+		End        time.Time
+		DurationMS int64
+		Cached     bool     `json:",omitempty"`
+		Attempts   int      `json:",omitempty"`
+		Plan       []string `json:",omitempty"`
+		Diff       string   `json:",omitempty"`
+		Output     string   `json:",omitempty"`
+		Error      string   `json:",omitempty"`
+	}
+
+	summaryLine struct {
+		Time   time.Time
+		Event  string
+		Counts map[deployctl.Outcome]int
+	}
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+
+	warningPrefix = "warning: "
+)
+
+var ErrLogFormat = errors.New("log-format must be \"text\" or \"json\"")
+
+var structured *jsonLog
+
+func setLogFormat(format string) error {
+	switch format {
+	case logFormatText:
+		return nil
+	case logFormatJSON:
+		structured = &jsonLog{out: log.Writer()}
+
+		log.SetFlags(0)
+		log.SetOutput(structured)
+		stderr.SetFlags(0)
+		stderr.SetOutput(structured)
+
+		return nil
+	}
+
+	return ErrLogFormat
+}
+
+func (writer *jsonLog) Write(data []byte) (int, error) {
+	message := string(bytes.TrimSuffix(data, []byte{'\n'}))
+	level := "info"
+
+	if trimmed, ok := strings.CutPrefix(message, warningPrefix); ok {
+		message, level = trimmed, "warning"
+	}
+
+	err := writer.emit(&logLine{Time: time.Now(), Level: level, Message: message})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(data), nil
+}
+
+func (writer *jsonLog) emit(line any) error {
+	data, err := sonic.Marshal(line)
+	if err != nil {
+		return err
+	}
+
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	_, err = writer.out.Write(append(data, '\n'))
+	return err
+}
+
+func (writer *jsonLog) error(err error) {
+	writer.emit(&logLine{Time: time.Now(), Level: "error", Message: err.Error()})
+}
+
+func (writer *jsonLog) output(name, host, line string) {
+	writer.emit(&outputLine{Time: time.Now(), Event: "output", Script: name, Host: host, Line: line})
+}
+
+func (writer *jsonLog) result(result *deployctl.Result) {
+	line := &resultLine{
+		Time:       time.Now(),
+		Event:      "result",
+		Script:     result.Name,
+		Host:       result.Host,
+		Type:       result.Type,
+		Status:     result.Outcome(),
+		Start:      result.Start,
+		End:        result.Start.Add(result.Duration),
+		DurationMS: result.Duration.Milliseconds(),
+		Cached:     result.Cached,
+		Attempts:   result.Attempts,
+		Plan:       result.Plan,
+		Diff:       result.Diff,
+		Output:     result.Output,
+	}
+
+	if result.Err != nil {
+		line.Error = result.Err.Error()
+	}
+
+	writer.emit(line)
+}
+
+func (writer *jsonLog) summary(counts map[deployctl.Outcome]int) {
+	writer.emit(&summaryLine{Time: time.Now(), Event: "summary", Counts: counts})
+}
@@ -1,42 +1,247 @@
 package main
 
 import (
+	"bytes"
+	"errors"
+	"flag"
 	"log"
 	"os"
 	"os/signal"
-
-	"github.com/bytedance/sonic"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
 
 	"github.com/gohryt/dotdeploy/internal/deployctl"
 	"github.com/gohryt/dotdeploy/internal/models"
 )
 
+type (
+	stringsFlag []string
+
+	options struct {
+		config        string
+		strict        bool
+		locked        bool
+		confirmed     bool
+		dryRun        bool
+		audit         bool
+		step          bool
+		explain       string
+		logLevel      string
+		logFormat     string
+		record        string
+		replay        string
+		environment   string
+		stage         string
+		historyKeep   int
+		cacheDir      string
+		variableFiles stringsFlag
+		variableFlags stringsFlag
+		logSinks      stringsFlag
+		ci            string
+		outputs       bool
+		inContainer   string
+		container     bool
+	}
+
+	command func(ctx *deployctl.Context, options *options, args []string) error
+)
+
+const defaultConfig = ".deploy"
+
+var ErrContainerMode = errors.New("in-container must be auto, true or false")
+
+var commands = map[string]command{
+	"run":       run,
+	"audit":     audit,
+	"action":    action,
+	"lock":      lock,
+	"history":   history,
+	"test":      test,
+	"bench":     bench,
+	"list":      list,
+	"lint":      lintConfig,
+	"verify":    verify,
+	"gc":        gc,
+	"artifacts": artifacts,
+
+	"migrate-config":  migrateConfig,
+	"plan-diff":       planDiff,
+	"retry-failed":    retryFailed,
+	"delta-signature": deltaSignature,
+	"delta-patch":     deltaPatch,
+}
+
+func (values *stringsFlag) String() string {
+	return strings.Join(*values, ",")
+}
+
+func (values *stringsFlag) Set(value string) error {
+	*values = append(*values, value)
+	return nil
+}
+
 func main() {
-	signalC := make(chan os.Signal, 1)
-	signal.Notify(signalC, os.Interrupt)
+	options := new(options)
 
-	ctx, err := deployctl.NewContext()
+	flag.StringVar(&options.config, "config", defaultConfig, "path to the deploy config")
+	flag.BoolVar(&options.strict, "strict", false, "reject unknown fields, missing required fields and bare integer durations")
+	flag.BoolVar(&options.locked, "locked", false, "refuse to run unless the config and artifacts match the lockfile")
+	flag.BoolVar(&options.confirmed, "yes-i-mean-production", false, "run against protected environments without asking for confirmation")
+	flag.BoolVar(&options.dryRun, "dry-run", false, "validate every script and print what it would do without changing anything")
+	flag.BoolVar(&options.step, "step", false, "pause before each script and ask to continue, skip or abort")
+	flag.StringVar(&options.explain, "explain", "", "print the resolved inputs of a script without running anything")
+	flag.StringVar(&options.logLevel, "log-level", "info", "log verbosity, debug also logs resolved inputs of each script")
+	flag.StringVar(&options.logFormat, "log-format", logFormatText, "log format: text for people or json for one structured event per line")
+	flag.StringVar(&options.record, "record", "", "record external effects of the run into a fixture file")
+	flag.StringVar(&options.replay, "replay", "", "replay external effects from a fixture file instead of performing them")
+	flag.StringVar(&options.environment, "environment", "", "only use documents targeting this environment")
+	flag.StringVar(&options.stage, "stage", "", "only use documents targeting this stage")
+	flag.IntVar(&options.historyKeep, "history", 20, "number of run reports to keep in history, 0 keeps everything")
+	flag.StringVar(&options.cacheDir, "cache-dir", "", "directory for prefetched downloads and cached results, shared between configs")
+	flag.Var(&options.variableFiles, "var-file", "load variables from a JSON file, can be repeated")
+	flag.Var(&options.variableFlags, "var", "set a variable as key=value, can be repeated")
+	flag.Var(&options.logSinks, "log-sink", "also send logs to syslog or journald, can be repeated")
+	flag.StringVar(&options.ci, "ci", "auto", "CI output format: auto, none, github or gitlab")
+	flag.StringVar(&options.inContainer, "in-container", "auto", "container mode: auto detects it, true or false force it")
+	flag.Parse()
+
+	err := openSinks(options.logSinks)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer ctx.Close()
+	defer sinks.Close()
 
-	file, err := os.Open(".deploy")
+	err = setLogFormat(options.logFormat)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	deploy := new(models.Deploy)
+	err = options.detectContainer()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	err = sonic.ConfigDefault.NewDecoder(file).Decode(deploy)
+	signalC := make(chan os.Signal, 1)
+	signal.Notify(signalC, os.Interrupt, syscall.SIGTERM)
+
+	ctx, err := deployctl.NewContext()
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer ctx.Close()
 
-	log.Println(deploy)
+	ctx.SetContainer(options.container)
+
+	args := flag.Args()
+	selected := run
+
+	if len(args) > 0 {
+		if named, ok := commands[args[0]]; ok {
+			selected = named
+			args = args[1:]
+		}
+	}
+
+	errC := make(chan error, 1)
+
+	go func() {
+		errC <- selected(ctx, options, args)
+	}()
 
 	select {
-	case <-signalC:
+	case received := <-signalC:
+		log.Printf("%s: stopping, send it again to exit at once", received)
+		ctx.Cancel()
+
+		select {
+		case <-signalC:
+		case err = <-errC:
+		}
+
+		if err == nil {
+			err = deployctl.ErrAborted
+		}
+
+		ctx.Close()
+		exit(err)
 	case <-ctx.Done():
+	case err = <-errC:
+		if err != nil {
+			ctx.Close()
+			exit(err)
+		}
 	}
 }
+
+func (options *options) load() (config []byte, deploys []*models.Deploy, err error) {
+	config, err = os.ReadFile(options.config)
+	if err != nil {
+		return nil, nil, &configError{err: err}
+	}
+
+	deploys, err = models.Load(bytes.NewReader(config), options.strict)
+	if err != nil {
+		return nil, nil, &configError{err: err}
+	}
+
+	if options.container {
+		deployctl.ContainerDefaults(deploys)
+	}
+
+	err = models.Resolve(deploys, filepath.Dir(options.config))
+	if err != nil {
+		return nil, nil, &configError{err: err}
+	}
+
+	return config, models.Select(deploys, options.environment, options.stage), nil
+}
+
+func (options *options) detectContainer() error {
+	if options.inContainer == "auto" {
+		options.container = deployctl.DetectContainer()
+	} else {
+		container, err := strconv.ParseBool(options.inContainer)
+		if err != nil {
+			return ErrContainerMode
+		}
+
+		options.container = container
+	}
+
+	if !options.container || options.config != defaultConfig {
+		return nil
+	}
+
+	_, err := os.Stat(options.config)
+	if err == nil {
+		return nil
+	}
+
+	mounted := filepath.Join(deployctl.ContainerPlan, defaultConfig)
+
+	_, err = os.Stat(mounted)
+	if err != nil {
+		return nil
+	}
+
+	options.config = mounted
+	return os.Chdir(deployctl.ContainerPlan)
+}
+
+func (options *options) lockfile() string {
+	return options.config + ".lock"
+}
+
+func (options *options) cachedir() string {
+	if options.cacheDir != "" {
+		return options.cacheDir
+	}
+
+	return options.config + ".cache"
+}
+
+func (options *options) historyfile() string {
+	return options.config + ".history.jsonl"
+}
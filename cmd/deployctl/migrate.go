@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+func migrateConfig(ctx *deployctl.Context, options *options, args []string) error {
+	flags := flag.NewFlagSet("migrate-config", flag.ContinueOnError)
+	write := flags.Bool("w", false, "rewrite the config in place instead of printing it")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(options.config)
+	if err != nil {
+		return err
+	}
+
+	migrated, err := models.MigrateConfig(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	if !*write {
+		_, err = os.Stdout.Write(migrated)
+		return err
+	}
+
+	info, err := os.Stat(options.config)
+	if err != nil {
+		return err
+	}
+
+	temporary := options.config + ".tmp"
+
+	err = os.WriteFile(temporary, migrated, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(temporary, options.config)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+const (
+	colorReset  = "\x1b[0m"
+	colorBold   = "\x1b[1m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+)
+
+var ErrPlanDiffUsage = errors.New("usage: plan-diff <git-ref>")
+
+func planDiff(ctx *deployctl.Context, options *options, args []string) error {
+	if len(args) != 1 {
+		return ErrPlanDiffUsage
+	}
+
+	_, current, err := options.load()
+	if err != nil {
+		return err
+	}
+
+	directory, base := filepath.Split(options.config)
+	if directory == "" {
+		directory = "."
+	}
+
+	command := exec.CommandContext(ctx, "git", "-C", directory, "show", args[0]+":./"+base)
+	command.Stderr = os.Stderr
+
+	data, err := command.Output()
+	if err != nil {
+		return err
+	}
+
+	previous, err := models.Load(bytes.NewReader(data), false)
+	if err != nil {
+		return &configError{err: err}
+	}
+
+	err = models.Resolve(previous, filepath.Dir(options.config))
+	if err != nil {
+		return &configError{err: err}
+	}
+
+	previous = models.Select(previous, options.environment, options.stage)
+
+	changes, err := deployctl.DiffPlans(previous, current)
+	if err != nil {
+		return err
+	}
+
+	printPlanChanges(changes, colored())
+	return nil
+}
+
+func printPlanChanges(changes []*deployctl.PlanChange, color bool) {
+	paint := func(code, text string) string {
+		if !color {
+			return text
+		}
+
+		return code + text + colorReset
+	}
+
+	colors := map[deployctl.ChangeKind]string{
+		deployctl.ChangeAdded:   colorGreen,
+		deployctl.ChangeRemoved: colorRed,
+		deployctl.ChangeChanged: colorYellow,
+	}
+
+	document := ""
+
+	for i, change := range changes {
+		if i == 0 || change.Document != document {
+			document = change.Document
+			fmt.Println(paint(colorBold, "# "+document))
+		}
+
+		kind := string(change.Kind)
+
+		if change.Script == "" {
+			fmt.Println(paint(colors[change.Kind], kind+" settings"))
+		} else {
+			fmt.Println(paint(colors[change.Kind], kind+" "+change.Type+" "+change.Script))
+		}
+
+		for _, field := range change.Fields {
+			switch {
+			case field.From == "":
+				fmt.Printf("\t%s: %s\n", field.Path, paint(colorGreen, field.To))
+			case field.To == "":
+				fmt.Printf("\t%s: %s\n", field.Path, paint(colorRed, field.From))
+			default:
+				fmt.Printf("\t%s: %s -> %s\n", field.Path, paint(colorRed, field.From), paint(colorGreen, field.To))
+			}
+		}
+	}
+}
+
+func colored() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
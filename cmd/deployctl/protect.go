@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+func confirm(reader *bufio.Reader, deploy *models.Deploy, labels []string) error {
+	names := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		name, _, _ := strings.Cut(label, "@")
+		names[name] = true
+	}
+
+	environment := deploy.Target.Environment
+
+	fmt.Printf("environment %s is protected, the run will perform these destructive scripts:\n", environment)
+
+	for _, name := range deploy.Order() {
+		script := deploy.Scripts.Scripts[name]
+		if (len(names) > 0 && !names[name]) || !script.Destructive() {
+			continue
+		}
+
+		fmt.Printf("\t%s %s\n", script.Type(), name)
+	}
+
+	fmt.Printf("type the environment name to continue: ")
+
+	line, err := reader.ReadString('\n')
+	if err != nil && line == "" {
+		return deployctl.ErrAborted
+	}
+
+	if answer := strings.TrimSpace(line); answer == "" || answer != environment {
+		return deployctl.ErrAborted
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	succeeded struct {
+		labels  map[string]bool
+		scripts map[string]bool
+		hosts   []string
+	}
+)
+
+var ErrNothingToRetry = errors.New("the last run in history has no failed, unreachable or unfinished scripts")
+
+func retryFailed(ctx *deployctl.Context, options *options, args []string) error {
+	runs, err := deployctl.ReadHistory(options.historyfile())
+	if err != nil {
+		return err
+	}
+
+	if len(runs) == 0 {
+		return ErrNothingToRetry
+	}
+
+	_, deploys, err := options.load()
+	if err != nil {
+		return err
+	}
+
+	done := &succeeded{labels: make(map[string]bool), scripts: make(map[string]bool)}
+
+	for _, result := range runs[len(runs)-1].Results {
+		switch deployctl.Outcome(result.Outcome) {
+		case deployctl.OutcomeChanged, deployctl.OutcomeUnchanged:
+			done.labels[result.Label()] = true
+			done.scripts[result.Name] = true
+		}
+
+		if result.Host != "" && !slices.Contains(done.hosts, result.Host) {
+			done.hosts = append(done.hosts, result.Host)
+		}
+	}
+
+	labels := []string(nil)
+
+	for _, deploy := range deploys {
+		for _, label := range done.pending(deploy) {
+			if !slices.Contains(labels, label) {
+				labels = append(labels, label)
+			}
+		}
+	}
+
+	if len(labels) == 0 {
+		return ErrNothingToRetry
+	}
+
+	log.Printf("retrying %s", strings.Join(labels, ", "))
+	return run(ctx, options, labels)
+}
+
+func (done *succeeded) pending(deploy *models.Deploy) (labels []string) {
+	hosts, _ := deploy.Hosts()
+
+	for _, host := range done.hosts {
+		if slices.Contains(hosts, host) {
+			continue
+		}
+
+		for _, pattern := range deploy.Target.Hosts {
+			if ok, _ := path.Match(pattern, host); ok {
+				hosts = append(hosts, host)
+				break
+			}
+		}
+	}
+
+	if len(hosts) == 0 {
+		hosts = []string{""}
+	}
+
+	for _, name := range deploy.Order() {
+		script := deploy.Scripts.Scripts[name]
+		if name == deploy.Rollout.Check || script.RunOnce && done.scripts[name] {
+			continue
+		}
+
+		targets := hosts
+		if script.DelegateTo != "" {
+			targets = []string{script.DelegateTo}
+		}
+
+		for _, host := range targets {
+			label := models.Label(name, host)
+			if !done.labels[label] && !slices.Contains(labels, label) {
+				labels = append(labels, label)
+			}
+		}
+	}
+
+	return labels
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"time"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+func run(ctx *deployctl.Context, options *options, names []string) (err error) {
+	config, deploys, err := options.load()
+	if err != nil {
+		return err
+	}
+
+	if options.locked {
+		data, err := os.ReadFile(options.lockfile())
+		if err != nil {
+			return err
+		}
+
+		lock := new(models.Lock)
+
+		err = sonic.Unmarshal(data, lock)
+		if err != nil {
+			return err
+		}
+
+		err = ctx.VerifyLock(lock, config, deploys)
+		if err != nil {
+			return err
+		}
+	}
+
+	if options.explain != "" {
+		return explain(ctx, deploys, options)
+	}
+
+	ci, err := newCI(options.ci, options.outputs)
+	if err != nil {
+		return err
+	}
+
+	input := bufio.NewReader(os.Stdin)
+	ctx.OnApprove(approve(input))
+	ctx.OnWarning(warn)
+	ctx.OnOutput(stream)
+
+	ctx.SetDryRun(options.dryRun)
+	ctx.SetAudit(options.audit)
+	ctx.SetCache(options.cachedir())
+
+	ctx.SetConfirmed(options.confirmed)
+	ctx.OnConfirm(func(deploy *models.Deploy, labels []string) error {
+		return confirm(input, deploy, labels)
+	})
+
+	for _, deploy := range deploys {
+		err := ctx.Confirm(deploy, names)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case options.step:
+		ctx.OnStep(step(input))
+	case options.logLevel == "debug":
+		ctx.OnStep(debug)
+	}
+
+	switch {
+	case options.replay != "":
+		replayer, err := deployctl.LoadReplayer(options.replay)
+		if err != nil {
+			return err
+		}
+
+		ctx.UseEffects(replayer)
+	case options.record != "":
+		recorder := deployctl.NewRecorder(deployctl.SystemEffects{})
+		ctx.UseEffects(recorder)
+
+		defer func() {
+			err := recorder.Save(options.record)
+			if err != nil {
+				log.Println(err)
+			}
+		}()
+	}
+
+	progress, err := newProgress(options, deploys, names)
+	if err != nil {
+		return err
+	}
+
+	err = checkWindows(deploys, progress.durations, names)
+	if err != nil {
+		return err
+	}
+
+	ctx.OnStart(progress.start)
+
+	start := time.Now()
+	reports := []*deployctl.Report(nil)
+
+	defer func() {
+		failure := err
+		aborted := deployctl.Interrupted(failure)
+		summary(reports)
+
+		err := ci.summary(reports)
+		if err != nil {
+			log.Println(err)
+		}
+
+		err = ci.output(reports)
+		if err != nil {
+			log.Println(err)
+		}
+
+		if len(reports) > 0 && !options.dryRun {
+			record := deployctl.NewRun(config, start, reports)
+			record.Aborted = aborted
+			record.Failed = record.Failed || failure != nil
+
+			if failure != nil {
+				record.Error = failure.Error()
+			}
+
+			err := deployctl.AppendHistory(options.historyfile(), record, options.keep(models.Retain(deploys)))
+			if err != nil {
+				log.Println(err)
+			}
+		}
+	}()
+
+	for i, deploy := range deploys {
+		progress.begin(i, deploy, names)
+
+		resolver, err := variables.Resolve(deploy, options.variableFiles, options.variableFlags)
+		if err != nil {
+			return err
+		}
+
+		ci.begin(i, deploy)
+
+		report, err := ctx.Process(deploy, resolver, names...)
+		reports = append(reports, report)
+
+		printReport(report)
+		ci.end(report)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkWindows(deploys []*models.Deploy, durations map[string]time.Duration, names []string) error {
+	for _, deploy := range deploys {
+		if deploy.Window.IsZero() {
+			continue
+		}
+
+		err := deployctl.CheckOverrun(deploy, deployctl.Estimate(deploy, durations, names), time.Now())
+		if err == nil {
+			continue
+		}
+
+		if deploy.Overrun == models.OverrunAbort {
+			return err
+		}
+
+		warn(err)
+	}
+
+	return nil
+}
+
+func warn(err error) {
+	log.Printf("warning: %v", err)
+}
+
+func stream(name, host, line string) {
+	if structured != nil {
+		structured.output(name, host, line)
+		return
+	}
+
+	log.Printf("%s | %s", models.Label(name, host), line)
+}
+
+func printReport(report *deployctl.Report) {
+	if structured != nil {
+		for _, result := range report.Results {
+			structured.result(result)
+		}
+
+		return
+	}
+
+	for _, result := range report.Results {
+		switch result.Outcome() {
+		case deployctl.OutcomeSkipped:
+			logResult(result, "%s %s skipped", result.Type, result.Label())
+		case deployctl.OutcomePlanned:
+			for _, action := range result.Plan {
+				logResult(result, "%s %s would %s", result.Type, result.Label(), action)
+			}
+		case deployctl.OutcomeRolledBack:
+			logResult(result, "%s %s rolled back", result.Type, result.Label())
+		case deployctl.OutcomeUnreachable:
+			logResult(result, "%s %s not run: %v", result.Type, result.Label(), result.Err)
+		case deployctl.OutcomeFailed:
+			logResult(result, "%s %s failed after %s: %v", result.Type, result.Label(), result.Duration, result.Err)
+		default:
+			logResult(result, "%s %s %s in %s", result.Type, result.Label(), result.Outcome(), result.Duration)
+		}
+
+		if result.Cached {
+			log.Printf("%s %s reused a cached result", result.Type, result.Label())
+		}
+
+		if result.Attempts > 1 {
+			log.Printf("%s %s took %d attempts", result.Type, result.Label(), result.Attempts)
+		}
+
+		if !result.Usage.IsZero() {
+			log.Printf("%s %s usage: %s", result.Type, result.Label(), result.Usage)
+		}
+
+		if result.Diff != "" {
+			log.Printf("%s %s changes:\n%s", result.Type, result.Label(), result.Diff)
+		}
+
+		if result.Output != "" && !result.Streamed {
+			log.Printf("%s %s output:\n%s", result.Type, result.Label(), result.Output)
+		}
+	}
+}
+
+func summary(reports []*deployctl.Report) {
+	counts := make(map[deployctl.Outcome]int)
+
+	for _, report := range reports {
+		for _, outcome := range []deployctl.Outcome{deployctl.OutcomeChanged, deployctl.OutcomeUnchanged, deployctl.OutcomeFailed, deployctl.OutcomeSkipped, deployctl.OutcomeUnreachable, deployctl.OutcomePlanned, deployctl.OutcomeRolledBack} {
+			counts[outcome] += report.Count(outcome)
+		}
+	}
+
+	if structured != nil {
+		structured.summary(counts)
+		return
+	}
+
+	if counts[deployctl.OutcomePlanned] > 0 {
+		log.Printf("%d planned, %d failed validation", counts[deployctl.OutcomePlanned], counts[deployctl.OutcomeFailed])
+
+		if checked := counts[deployctl.OutcomeChanged] + counts[deployctl.OutcomeUnchanged]; checked > 0 {
+			log.Printf("%d read-only checks passed", checked)
+		}
+
+		return
+	}
+
+	log.Printf(
+		"%d changed, %d unchanged, %d failed, %d skipped, %d unreachable",
+		counts[deployctl.OutcomeChanged],
+		counts[deployctl.OutcomeUnchanged],
+		counts[deployctl.OutcomeFailed],
+		counts[deployctl.OutcomeSkipped],
+		counts[deployctl.OutcomeUnreachable],
+	)
+
+	if counts[deployctl.OutcomeRolledBack] > 0 {
+		log.Printf("%d rolled back", counts[deployctl.OutcomeRolledBack])
+	}
+}
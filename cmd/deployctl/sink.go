@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+	"github.com/gohryt/dotdeploy/internal/logsink"
+)
+
+var (
+	sinks  logsink.Sinks
+	stderr = log.New(os.Stderr, "", log.LstdFlags)
+)
+
+func openSinks(names []string) error {
+	for _, name := range names {
+		sink, err := logsink.Open(name)
+		if err != nil {
+			return err
+		}
+
+		sinks = append(sinks, sink)
+	}
+
+	if len(sinks) > 0 {
+		log.SetOutput(io.MultiWriter(os.Stderr, &logsink.Writer{Sinks: sinks, Priority: logsink.PriorityInfo}))
+	}
+
+	return nil
+}
+
+func logResult(result *deployctl.Result, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+
+	if len(sinks) == 0 {
+		log.Print(message)
+		return
+	}
+
+	stderr.Print(message)
+
+	priority := logsink.PriorityInfo
+	if result.Err != nil {
+		priority = logsink.PriorityError
+	}
+
+	fields := map[string]string{
+		"DEPLOY_SCRIPT":      result.Name,
+		"DEPLOY_TYPE":        result.Type,
+		"DEPLOY_OUTCOME":     string(result.Outcome()),
+		"DEPLOY_DURATION_MS": strconv.FormatInt(result.Duration.Milliseconds(), 10),
+	}
+
+	if result.Host != "" {
+		fields["DEPLOY_HOST"] = result.Host
+	}
+
+	if result.Err != nil {
+		fields["DEPLOY_ERROR"] = result.Err.Error()
+	}
+
+	sinks.Send(priority, message, fields)
+}
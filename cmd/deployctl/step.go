@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+var ErrExplainNotFound = errors.New("script to explain is not defined in the selected documents")
+
+func step(reader *bufio.Reader) deployctl.Stepper {
+	return func(name string, script *models.Script, resolver *variables.Resolver) (deployctl.Step, error) {
+		err := describe(os.Stdout, name, script, resolver)
+		if err != nil {
+			return deployctl.StepAbort, err
+		}
+
+		for {
+			fmt.Print("[c]ontinue, [s]kip, [a]bort? ")
+
+			line, err := reader.ReadString('\n')
+			if err != nil && line == "" {
+				return deployctl.StepAbort, nil
+			}
+
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "", "c", "continue":
+				return deployctl.StepContinue, nil
+			case "s", "skip":
+				return deployctl.StepSkip, nil
+			case "a", "abort":
+				return deployctl.StepAbort, nil
+			}
+		}
+	}
+}
+
+func approve(reader *bufio.Reader) deployctl.Approver {
+	return func(wave, waves int, hosts []string) (bool, error) {
+		log.Printf("wave %d of %d done: %s", wave, waves, strings.Join(hosts, ", "))
+
+		for {
+			fmt.Print("[c]ontinue, [a]bort? ")
+
+			line, err := reader.ReadString('\n')
+			if err != nil && line == "" {
+				return false, nil
+			}
+
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "c", "continue":
+				return true, nil
+			case "a", "abort":
+				return false, nil
+			}
+		}
+	}
+}
+
+func debug(name string, script *models.Script, resolver *variables.Resolver) (deployctl.Step, error) {
+	builder := new(strings.Builder)
+
+	err := describe(builder, name, script, resolver)
+	if err != nil {
+		return deployctl.StepAbort, err
+	}
+
+	log.Print(builder.String())
+	return deployctl.StepContinue, nil
+}
+
+func explain(ctx *deployctl.Context, deploys []*models.Deploy, options *options) error {
+	for _, deploy := range deploys {
+		script, ok := deploy.Scripts.Scripts[options.explain]
+		if !ok || script == nil {
+			continue
+		}
+
+		resolver, err := variables.Resolve(deploy, options.variableFiles, options.variableFlags)
+		if err != nil {
+			return err
+		}
+
+		err = ctx.LoadSecrets(context.Background(), deploy, resolver)
+		if err != nil {
+			return err
+		}
+
+		return describe(os.Stdout, options.explain, script, resolver)
+	}
+
+	return ErrExplainNotFound
+}
+
+func describe(writer io.Writer, name string, script *models.Script, resolver *variables.Resolver) error {
+	expanded, err := deployctl.ExpandScript(script, resolver)
+	if err == nil {
+		script = expanded
+	}
+
+	data, err := sonic.ConfigDefault.MarshalIndent(script, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	environment, err := resolver.Environment(script.Environment)
+	if err != nil {
+		return err
+	}
+
+	builder := new(strings.Builder)
+	fmt.Fprintf(builder, "%s %s\n%s\n", script.Type(), name, data)
+
+	for _, pair := range environment {
+		fmt.Fprintf(builder, "\t%s\n", pair)
+	}
+
+	_, err = io.WriteString(writer, resolver.Conceal(builder.String()))
+	return err
+}
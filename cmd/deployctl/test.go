@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+var (
+	ErrTestUsage  = errors.New("usage: test <file> [file...]")
+	ErrTestFailed = errors.New("plan tests failed")
+)
+
+func test(ctx *deployctl.Context, options *options, args []string) error {
+	if len(args) == 0 {
+		return ErrTestUsage
+	}
+
+	config, err := os.ReadFile(options.config)
+	if err != nil {
+		return err
+	}
+
+	failed := false
+
+	for _, path := range args {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		tests := new(models.PlanTests)
+
+		err = sonic.Unmarshal(data, tests)
+		if err != nil {
+			return err
+		}
+
+		err = models.Validate(tests, true)
+		if err != nil {
+			return err
+		}
+
+		for _, test := range tests.Tests {
+			failures, err := deployctl.TestPlan(config, filepath.Dir(options.config), test, options.strict)
+			if err != nil {
+				return err
+			}
+
+			if len(failures) == 0 {
+				fmt.Printf("PASS\t%s\t%s\n", path, test.Name)
+				continue
+			}
+
+			failed = true
+			fmt.Printf("FAIL\t%s\t%s\n", path, test.Name)
+
+			for _, failure := range failures {
+				fmt.Printf("\t%s\n", failure)
+			}
+		}
+	}
+
+	if failed {
+		return ErrTestFailed
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+)
+
+var ErrVerifyNothing = errors.New("no release given and no Manifest scripts in the selected documents")
+
+func verify(ctx *deployctl.Context, options *options, args []string) error {
+	releases := map[string]string{}
+	order := []string(nil)
+
+	add := func(root, manifest string) {
+		if _, ok := releases[root]; !ok {
+			order = append(order, root)
+		}
+
+		releases[root] = deployctl.ManifestPath(root, manifest)
+	}
+
+	if len(args) > 0 {
+		for _, root := range args {
+			add(root, "")
+		}
+	} else {
+		_, deploys, err := options.load()
+		if err != nil {
+			return err
+		}
+
+		for _, deploy := range deploys {
+			for _, name := range deploy.Order() {
+				if manifest := deploy.Scripts.Scripts[name].Manifest; manifest != nil {
+					add(manifest.Path, manifest.To)
+				}
+			}
+		}
+	}
+
+	if len(order) == 0 {
+		return ErrVerifyNothing
+	}
+
+	errs := []error(nil)
+
+	for _, root := range order {
+		manifest, err := deployctl.LoadManifest(releases[root])
+		if err != nil {
+			return err
+		}
+
+		changes, err := deployctl.VerifyManifest(ctx, manifest, root, releases[root])
+		if err != nil {
+			return err
+		}
+
+		if len(changes) == 0 {
+			fmt.Printf("ok\t%s\n", root)
+			continue
+		}
+
+		fmt.Printf("FAIL\t%s\n", root)
+
+		for _, change := range changes {
+			line := string(change.Kind) + " " + change.Path
+			if change.Reason != "" {
+				line += ": " + change.Reason
+			}
+
+			fmt.Println("\t" + line)
+		}
+
+		errs = append(errs, &deployctl.VerifyError{Path: root, Changes: len(changes)})
+	}
+
+	return errors.Join(errs...)
+}
@@ -4,13 +4,14 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"syscall"
 
 	"github.com/gohryt/dotdeploy/internal/deployd"
 )
 
 func main() {
 	signalC := make(chan os.Signal, 1)
-	signal.Notify(signalC, os.Interrupt)
+	signal.Notify(signalC, os.Interrupt, syscall.SIGTERM)
 
 	ctx, err := deployd.NewContext()
 	if err != nil {
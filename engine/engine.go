@@ -0,0 +1,158 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gohryt/dotdeploy/internal/deployctl"
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+type (
+	Deploy  = models.Deploy
+	Script  = models.Script
+	Remote  = models.Remote
+	Report  = deployctl.Report
+	Result  = deployctl.Result
+	Outcome = deployctl.Outcome
+
+	ScriptMove     = models.ScriptMove
+	ScriptCopy     = models.ScriptCopy
+	ScriptDownload = models.ScriptDownload
+	ScriptRun      = models.ScriptRun
+	ScriptFile     = models.ScriptFile
+	ScriptTemplate = models.ScriptTemplate
+	ScriptSymlink  = models.ScriptSymlink
+	ScriptDelete   = models.ScriptDelete
+	ScriptArchive  = models.ScriptArchive
+	ScriptExtract  = models.ScriptExtract
+	ScriptManifest = models.ScriptManifest
+	ScriptWait     = models.ScriptWait
+	ScriptCompat   = models.ScriptCompat
+	Dependency     = models.Dependency
+	ScriptDeploy   = models.ScriptDeploy
+
+	Options struct {
+		Environment   string
+		Stage         string
+		Scripts       []string
+		Variables     map[string]string
+		VariableFiles []string
+		DryRun        bool
+		Confirmed     bool
+		CacheDir      string
+		OnStart       func(script, host string)
+		OnResult      func(result *Result)
+		OnOutput      func(script, host, line string)
+		OnWarning     func(err error)
+		OnApprove     func(wave, waves int, hosts []string) (bool, error)
+		OnConfirm     func(deploy *Deploy, scripts []string) error
+	}
+)
+
+const (
+	OutcomeChanged     = deployctl.OutcomeChanged
+	OutcomeUnchanged   = deployctl.OutcomeUnchanged
+	OutcomeFailed      = deployctl.OutcomeFailed
+	OutcomeSkipped     = deployctl.OutcomeSkipped
+	OutcomeUnreachable = deployctl.OutcomeUnreachable
+	OutcomePlanned     = deployctl.OutcomePlanned
+)
+
+var ErrProtected = deployctl.ErrProtected
+
+func Load(reader io.Reader) ([]*Deploy, error) {
+	directory, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	return load(reader, directory)
+}
+
+func LoadFile(path string) ([]*Deploy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return load(bytes.NewReader(data), filepath.Dir(path))
+}
+
+func load(reader io.Reader, directory string) ([]*Deploy, error) {
+	deploys, err := models.Load(reader, false)
+	if err != nil {
+		return nil, err
+	}
+
+	err = models.Resolve(deploys, directory)
+	if err != nil {
+		return nil, err
+	}
+
+	return deploys, nil
+}
+
+func Run(scope context.Context, deploys []*Deploy, options Options) (reports []*Report, err error) {
+	ctx, err := deployctl.NewContext()
+	if err != nil {
+		return nil, err
+	}
+	defer ctx.Close()
+
+	ctx.SetDryRun(options.DryRun)
+	ctx.SetConfirmed(options.Confirmed)
+	ctx.SetCache(options.CacheDir)
+
+	if options.OnStart != nil {
+		ctx.OnStart(options.OnStart)
+	}
+
+	if options.OnResult != nil {
+		ctx.OnResult(options.OnResult)
+	}
+
+	if options.OnOutput != nil {
+		ctx.OnOutput(options.OnOutput)
+	}
+
+	if options.OnWarning != nil {
+		ctx.OnWarning(options.OnWarning)
+	}
+
+	if options.OnApprove != nil {
+		ctx.OnApprove(options.OnApprove)
+	}
+
+	if options.OnConfirm != nil {
+		ctx.OnConfirm(options.OnConfirm)
+	}
+
+	flags := make([]string, 0, len(options.Variables))
+	for name, value := range options.Variables {
+		flags = append(flags, name+"="+value)
+	}
+
+	sort.Strings(flags)
+
+	for _, deploy := range models.Select(deploys, options.Environment, options.Stage) {
+		resolver, err := variables.Resolve(deploy, options.VariableFiles, flags)
+		if err != nil {
+			return reports, err
+		}
+
+		report, err := ctx.ProcessContext(scope, deploy, resolver, options.Scripts...)
+		reports = append(reports, report)
+
+		if err != nil {
+			return reports, err
+		}
+	}
+
+	return reports, nil
+}
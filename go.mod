@@ -5,6 +5,7 @@ go 1.22.0
 require (
 	github.com/bytedance/sonic v1.11.6
 	github.com/iceber/iouring-go v0.0.0-20230403020409-002cfd2e2a90
+	github.com/klauspost/compress v1.17.9
 )
 
 require (
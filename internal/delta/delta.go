@@ -0,0 +1,427 @@
+package delta
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+type (
+	Block struct {
+		Weak   uint32
+		Strong [sha256.Size]byte
+		Length int
+	}
+
+	Signature struct {
+		BlockSize int
+		Blocks    []Block
+	}
+
+	Operation struct {
+		Index int
+		Data  []byte
+	}
+
+	rolling struct {
+		a, b   uint32
+		length uint32
+	}
+)
+
+const (
+	DefaultBlockSize = 64 << 10
+
+	maxBlockSize = 16 << 20
+
+	literalLimit = 1 << 20
+
+	operationCopy    = 'C'
+	operationLiteral = 'L'
+)
+
+var (
+	ErrBlockSize       = errors.New("block size must be positive")
+	ErrBlockIndex      = errors.New("copy operation references a block outside of the base file")
+	ErrOperationFormat = errors.New("malformed delta operation")
+	ErrSignatureFormat = errors.New("malformed delta signature")
+)
+
+func NewSignature(reader io.Reader, blockSize int) (signature *Signature, err error) {
+	if blockSize <= 0 {
+		return nil, ErrBlockSize
+	}
+
+	signature = &Signature{BlockSize: blockSize}
+	block := make([]byte, blockSize)
+
+	for {
+		n, err := io.ReadFull(reader, block)
+		if n > 0 {
+			signature.Blocks = append(signature.Blocks, Block{
+				Weak:   newRolling(block[:n]).sum(),
+				Strong: sha256.Sum256(block[:n]),
+				Length: n,
+			})
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return signature, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func Diff(signature *Signature, reader io.Reader, emit func(operation Operation) error) error {
+	blockSize := signature.BlockSize
+	if blockSize <= 0 {
+		return ErrBlockSize
+	}
+
+	table := make(map[uint32][]int, len(signature.Blocks))
+	for index, block := range signature.Blocks {
+		table[block.Weak] = append(table[block.Weak], index)
+	}
+
+	input := bufio.NewReader(reader)
+	buffer := make([]byte, 0, 4*blockSize)
+	start := 0
+	literal := []byte(nil)
+
+	flush := func() error {
+		if len(literal) == 0 {
+			return nil
+		}
+
+		err := emit(Operation{Index: -1, Data: literal})
+		literal = nil
+		return err
+	}
+
+	fill := func() error {
+		buffer = append(buffer[:0], buffer[start:]...)
+		start = 0
+
+		for len(buffer) < blockSize {
+			c, err := input.ReadByte()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+
+			buffer = append(buffer, c)
+		}
+
+		return nil
+	}
+
+	err := fill()
+	if err != nil {
+		return err
+	}
+
+	checksum := newRolling(buffer)
+
+	for len(buffer)-start > 0 {
+		window := buffer[start:]
+
+		if index, ok := match(signature, table, checksum.sum(), window); ok {
+			err = flush()
+			if err != nil {
+				return err
+			}
+
+			err = emit(Operation{Index: index})
+			if err != nil {
+				return err
+			}
+
+			start = len(buffer)
+
+			err = fill()
+			if err != nil {
+				return err
+			}
+
+			checksum = newRolling(buffer)
+			continue
+		}
+
+		out := buffer[start]
+		literal = append(literal, out)
+		start++
+
+		c, err := input.ReadByte()
+		switch {
+		case errors.Is(err, io.EOF):
+			checksum.remove(out)
+		case err != nil:
+			return err
+		default:
+			if len(buffer) == cap(buffer) {
+				buffer = append(buffer[:0], buffer[start:]...)
+				start = 0
+			}
+
+			buffer = append(buffer, c)
+			checksum.roll(out, c)
+		}
+
+		if len(literal) >= literalLimit {
+			err = flush()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return flush()
+}
+
+func Patch(base io.ReaderAt, blockSize int, operations func() (Operation, error), writer io.Writer) error {
+	if blockSize <= 0 {
+		return ErrBlockSize
+	}
+
+	block := make([]byte, blockSize)
+
+	for {
+		operation, err := operations()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if operation.Index < 0 {
+			_, err = writer.Write(operation.Data)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		n, err := base.ReadAt(block, int64(operation.Index)*int64(blockSize))
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		if n == 0 {
+			return ErrBlockIndex
+		}
+
+		_, err = writer.Write(block[:n])
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func Encode(writer io.Writer, signature *Signature, reader io.Reader) error {
+	output := bufio.NewWriter(writer)
+
+	_, err := output.Write(binary.AppendUvarint(nil, uint64(signature.BlockSize)))
+	if err != nil {
+		return err
+	}
+
+	err = Diff(signature, reader, func(operation Operation) error {
+		return WriteOperation(output, operation)
+	})
+	if err != nil {
+		return err
+	}
+
+	return output.Flush()
+}
+
+func Apply(reader io.Reader, base io.ReaderAt, writer io.Writer) error {
+	input := bufio.NewReader(reader)
+
+	blockSize, err := binary.ReadUvarint(input)
+	if err != nil || blockSize == 0 || blockSize > maxBlockSize {
+		return ErrOperationFormat
+	}
+
+	return Patch(base, int(blockSize), func() (Operation, error) {
+		return ReadOperation(input)
+	}, writer)
+}
+
+func WriteSignature(writer io.Writer, signature *Signature) error {
+	output := bufio.NewWriter(writer)
+
+	header := binary.AppendUvarint(nil, uint64(signature.BlockSize))
+	header = binary.AppendUvarint(header, uint64(len(signature.Blocks)))
+
+	_, err := output.Write(header)
+	if err != nil {
+		return err
+	}
+
+	for _, block := range signature.Blocks {
+		entry := binary.BigEndian.AppendUint32(nil, block.Weak)
+		entry = append(entry, block.Strong[:]...)
+		entry = binary.AppendUvarint(entry, uint64(block.Length))
+
+		_, err = output.Write(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return output.Flush()
+}
+
+func ReadSignature(reader io.Reader) (*Signature, error) {
+	input := bufio.NewReader(reader)
+
+	blockSize, err := binary.ReadUvarint(input)
+	if err != nil || blockSize == 0 || blockSize > maxBlockSize {
+		return nil, ErrSignatureFormat
+	}
+
+	count, err := binary.ReadUvarint(input)
+	if err != nil {
+		return nil, ErrSignatureFormat
+	}
+
+	signature := &Signature{BlockSize: int(blockSize)}
+
+	for i := uint64(0); i < count; i++ {
+		block := Block{}
+		weak := make([]byte, 4)
+
+		_, err = io.ReadFull(input, weak)
+		if err == nil {
+			_, err = io.ReadFull(input, block.Strong[:])
+		}
+
+		if err != nil {
+			return nil, ErrSignatureFormat
+		}
+
+		length, err := binary.ReadUvarint(input)
+		if err != nil || length == 0 || length > blockSize {
+			return nil, ErrSignatureFormat
+		}
+
+		block.Weak = binary.BigEndian.Uint32(weak)
+		block.Length = int(length)
+		signature.Blocks = append(signature.Blocks, block)
+	}
+
+	return signature, nil
+}
+
+func WriteOperation(writer io.Writer, operation Operation) error {
+	header := make([]byte, 1, 1+binary.MaxVarintLen64)
+
+	if operation.Index >= 0 {
+		header[0] = operationCopy
+		header = binary.AppendUvarint(header, uint64(operation.Index))
+
+		_, err := writer.Write(header)
+		return err
+	}
+
+	header[0] = operationLiteral
+	header = binary.AppendUvarint(header, uint64(len(operation.Data)))
+
+	_, err := writer.Write(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(operation.Data)
+	return err
+}
+
+func ReadOperation(reader *bufio.Reader) (operation Operation, err error) {
+	kind, err := reader.ReadByte()
+	if err != nil {
+		return operation, err
+	}
+
+	value, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return operation, ErrOperationFormat
+	}
+
+	switch kind {
+	case operationCopy:
+		operation.Index = int(value)
+	case operationLiteral:
+		if value > literalLimit {
+			return operation, ErrOperationFormat
+		}
+
+		operation.Index = -1
+		operation.Data = make([]byte, value)
+
+		_, err = io.ReadFull(reader, operation.Data)
+		if err != nil {
+			return operation, ErrOperationFormat
+		}
+	default:
+		return operation, ErrOperationFormat
+	}
+
+	return operation, nil
+}
+
+func match(signature *Signature, table map[uint32][]int, weak uint32, window []byte) (int, bool) {
+	candidates, ok := table[weak]
+	if !ok {
+		return 0, false
+	}
+
+	strong := sha256.Sum256(window)
+
+	for _, index := range candidates {
+		block := &signature.Blocks[index]
+		if block.Length == len(window) && bytes.Equal(block.Strong[:], strong[:]) {
+			return index, true
+		}
+	}
+
+	return 0, false
+}
+
+func newRolling(data []byte) rolling {
+	checksum := rolling{length: uint32(len(data))}
+
+	for i, c := range data {
+		checksum.a += uint32(c)
+		checksum.b += uint32(len(data)-i) * uint32(c)
+	}
+
+	return checksum
+}
+
+func (checksum rolling) sum() uint32 {
+	return checksum.a&0xffff | checksum.b<<16
+}
+
+func (checksum *rolling) remove(out byte) {
+	checksum.a -= uint32(out)
+	checksum.b -= checksum.length * uint32(out)
+	checksum.length--
+}
+
+func (checksum *rolling) roll(out, in byte) {
+	checksum.a = checksum.a - uint32(out) + uint32(in)
+	checksum.b = checksum.b - checksum.length*uint32(out) + checksum.a
+}
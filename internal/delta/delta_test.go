@@ -0,0 +1,120 @@
+package delta
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestEncodeApply(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	base := make([]byte, 5*DefaultBlockSize+123)
+	random.Read(base)
+
+	edited := bytes.Clone(base)
+	copy(edited[2*DefaultBlockSize+10:], "changed")
+
+	inserted := append(append(bytes.Clone(base[:DefaultBlockSize+7]), "inserted"...), base[DefaultBlockSize+7:]...)
+	removed := append(bytes.Clone(base[:3*DefaultBlockSize]), base[3*DefaultBlockSize+500:]...)
+
+	tests := []struct {
+		name    string
+		base    []byte
+		target  []byte
+		literal int
+	}{
+		{name: "unchanged", base: base, target: base, literal: 0},
+		{name: "edited block", base: base, target: edited, literal: DefaultBlockSize},
+		{name: "inserted bytes", base: base, target: inserted, literal: DefaultBlockSize + 8},
+		{name: "removed bytes", base: base, target: removed, literal: DefaultBlockSize - 500},
+		{name: "empty base", base: nil, target: base, literal: len(base)},
+		{name: "empty target", base: base, target: nil, literal: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			signature, err := NewSignature(bytes.NewReader(test.base), DefaultBlockSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			encoded := new(bytes.Buffer)
+
+			err = WriteSignature(encoded, signature)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			signature, err = ReadSignature(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			literal := 0
+
+			err = Diff(signature, bytes.NewReader(test.target), func(operation Operation) error {
+				literal += len(operation.Data)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if literal > test.literal {
+				t.Errorf("sent %d literal bytes, expected at most %d", literal, test.literal)
+			}
+
+			stream := new(bytes.Buffer)
+
+			err = Encode(stream, signature, bytes.NewReader(test.target))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			patched := new(bytes.Buffer)
+
+			err = Apply(stream, bytes.NewReader(test.base), patched)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(patched.Bytes(), test.target) {
+				t.Errorf("patched %d bytes do not match the %d target bytes", patched.Len(), len(test.target))
+			}
+		})
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		apply bool
+		data  []byte
+	}{
+		{name: "empty signature", data: nil},
+		{name: "zero block size", data: []byte{0, 0}},
+		{name: "truncated block", data: []byte{4, 1, 0, 0}},
+		{name: "empty delta", apply: true, data: nil},
+		{name: "unknown operation", apply: true, data: []byte{4, 'X', 1}},
+		{name: "block outside base", apply: true, data: []byte{4, operationCopy, 9}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !test.apply {
+				_, err := ReadSignature(bytes.NewReader(test.data))
+				if err == nil {
+					t.Error("malformed signature was accepted")
+				}
+
+				return
+			}
+
+			err := Apply(bytes.NewReader(test.data), bytes.NewReader([]byte("base")), new(bytes.Buffer))
+			if err == nil {
+				t.Error("malformed delta was accepted")
+			}
+		})
+	}
+}
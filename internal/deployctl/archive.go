@@ -0,0 +1,305 @@
+package deployctl
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/gohryt/dotdeploy/internal/ignore"
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+var ErrArchiveSource = errors.New("archive source must be a directory")
+
+func (ctx *Context) archive(scope context.Context, deploy *models.Deploy, archive *models.ScriptArchive, result *Result) error {
+	format, to := archiveDestination(deploy, archive)
+
+	previous, err := statFile(to)
+	if err != nil {
+		return err
+	}
+
+	skip, err := ctx.prepareDestination(to, archive.IfExists, result)
+	if err != nil || skip {
+		return err
+	}
+
+	cache, err := ctx.archiveCache(archive, format)
+	if err != nil {
+		return err
+	}
+
+	_, err = ctx.effect("archive", []string{archive.From, to, string(format)}, func() ([]byte, error) {
+		return nil, await(scope, result, func() error {
+			if cachedResult(cache) {
+				result.Cached = true
+
+				_, err := copyAtomic(scope, cache, to, 0o644, "")
+				return err
+			}
+
+			err := pack(scope, archive, format, to)
+			if err != nil {
+				return err
+			}
+
+			ctx.storeResult(cache, func(path string) error {
+				_, err := copyAtomic(scope, to, path, 0o644, "")
+				return err
+			})
+
+			return nil
+		})
+	})
+
+	result.artifact = to
+	if err != nil {
+		return err
+	}
+
+	current, err := statFile(to)
+	if err != nil {
+		return err
+	}
+
+	result.Usage.Written = current.size
+	result.Changed = current != previous || archive.IfExists == models.IfExistsBackup
+	return nil
+}
+
+func (ctx *Context) archiveCache(archive *models.ScriptArchive, format models.ArchiveFormat) (string, error) {
+	if !archive.Cache || ctx.cache == "" {
+		return "", nil
+	}
+
+	digest, err := treeDigest(archive.From)
+	if err != nil {
+		return "", err
+	}
+
+	return ctx.resultPath("archive", format, archive.Level, digest)
+}
+
+func archiveDestination(deploy *models.Deploy, archive *models.ScriptArchive) (models.ArchiveFormat, string) {
+	format := archive.Format
+	if format == "" {
+		detected, ok := models.ArchiveFormatOf(archive.To)
+		if !ok {
+			detected = models.ArchiveTarGzip
+		}
+
+		format = detected
+	}
+
+	return format, destination(deploy.Folder, filepath.Clean(archive.From)+"."+string(format), archive.To, false)
+}
+
+func planArchive(deploy *models.Deploy, archive *models.ScriptArchive) (plan []string, err error) {
+	format, to := archiveDestination(deploy, archive)
+
+	_, err = os.Stat(archive.From)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, skip, err := planDestination(to, archive.IfExists)
+	if err != nil || skip {
+		return plan, err
+	}
+
+	return append(plan, "archive "+archive.From+" to "+to+" as "+string(format)), nil
+}
+
+func pack(scope context.Context, archive *models.ScriptArchive, format models.ArchiveFormat, to string) error {
+	info, err := os.Stat(archive.From)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return ErrArchiveSource
+	}
+
+	partial := to + ".part"
+
+	file, err := os.Create(partial)
+	if err != nil {
+		return err
+	}
+
+	err = writeArchive(scope, file, archive, format)
+
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		os.Remove(partial)
+		return err
+	}
+
+	return os.Rename(partial, to)
+}
+
+func writeArchive(scope context.Context, writer io.Writer, archive *models.ScriptArchive, format models.ArchiveFormat) error {
+	if format == models.ArchiveZip {
+		return writeZip(scope, writer, archive)
+	}
+
+	compressor := io.WriteCloser(nopWriteCloser{writer})
+
+	switch format {
+	case models.ArchiveTarGzip:
+		level := archive.Level
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+
+		gzipWriter, err := gzip.NewWriterLevel(writer, level)
+		if err != nil {
+			return err
+		}
+
+		compressor = gzipWriter
+	case models.ArchiveTarZstd:
+		options := []zstd.EOption{}
+
+		if archive.Level > 0 {
+			options = append(options, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(archive.Level)))
+		}
+
+		if archive.Threads > 0 {
+			options = append(options, zstd.WithEncoderConcurrency(archive.Threads))
+		}
+
+		zstdWriter, err := zstd.NewWriter(writer, options...)
+		if err != nil {
+			return err
+		}
+
+		compressor = zstdWriter
+	}
+
+	tarWriter := tar.NewWriter(compressor)
+
+	err := walkArchive(scope, archive.From, func(path, name string, info fs.FileInfo) error {
+		link := ""
+		if info.Mode()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			link = target
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		err = tarWriter.WriteHeader(header)
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+
+		return copyFile(scope, tarWriter, path)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		return err
+	}
+
+	return compressor.Close()
+}
+
+func writeZip(scope context.Context, writer io.Writer, archive *models.ScriptArchive) error {
+	zipWriter := zip.NewWriter(writer)
+
+	if archive.Level != 0 {
+		zipWriter.RegisterCompressor(zip.Deflate, func(writer io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(writer, archive.Level)
+		})
+	}
+
+	err := walkArchive(scope, archive.From, func(path, name string, info fs.FileInfo) error {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		} else {
+			header.Method = zip.Deflate
+		}
+
+		entry, err := zipWriter.CreateHeader(header)
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+
+		return copyFile(scope, entry, path)
+	})
+	if err != nil {
+		return err
+	}
+
+	return zipWriter.Close()
+}
+
+func walkArchive(scope context.Context, root string, add func(path, name string, info fs.FileInfo) error) error {
+	return ignore.Walk(root, nil, func(path string, entry fs.DirEntry) error {
+		err := scope.Err()
+		if err != nil {
+			return err
+		}
+
+		if path == root {
+			return nil
+		}
+
+		relative, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		return add(path, filepath.ToSlash(relative), info)
+	})
+}
+
+func copyFile(scope context.Context, writer io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(writer, &scopedReader{scope: scope, reader: file})
+	return err
+}
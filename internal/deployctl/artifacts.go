@@ -0,0 +1,187 @@
+package deployctl
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	PeerError struct {
+		Peer string
+		URL  string
+		Err  error
+	}
+)
+
+const (
+	ArtifactsToken = "DEPLOY_ARTIFACTS_TOKEN"
+	ArtifactsPath  = "/artifacts/"
+
+	checksumHeader = "X-Checksum-SHA256"
+)
+
+var (
+	ErrArtifactsToken = errors.New(ArtifactsToken + " must be set to serve artifacts")
+	ErrPeerChecksum   = errors.New("peer sent no " + checksumHeader + " header")
+)
+
+func (err *PeerError) Error() string {
+	return "fetch " + err.URL + " from peer " + err.Peer + ": " + err.Err.Error()
+}
+
+func (err *PeerError) Unwrap() error {
+	return err.Err
+}
+
+func artifactKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+func (ctx *Context) ArtifactHandler(token string) (http.Handler, error) {
+	if token == "" {
+		return nil, ErrArtifactsToken
+	}
+
+	expected := []byte("Bearer " + token)
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(request.Header.Get("Authorization")), expected) != 1 {
+			http.Error(writer, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		if request.Method != http.MethodGet && request.Method != http.MethodHead {
+			http.Error(writer, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		key, ok := strings.CutPrefix(request.URL.Path, ArtifactsPath)
+		if decoded, err := hex.DecodeString(key); !ok || err != nil || len(decoded) != sha256.Size || ctx.cache == "" {
+			http.NotFound(writer, request)
+			return
+		}
+
+		path := filepath.Join(ctx.cache, key)
+
+		sum, err := os.ReadFile(path + ".sha256")
+		if err != nil {
+			http.NotFound(writer, request)
+			return
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			http.NotFound(writer, request)
+			return
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil {
+			http.Error(writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writer.Header().Set(checksumHeader, strings.TrimSpace(string(sum)))
+		http.ServeContent(writer, request, key, info.ModTime(), file)
+	}), nil
+}
+
+func (ctx *Context) retrieve(scope context.Context, deploy *models.Deploy, client *http.Client, download *models.ScriptDownload, to string, quota *quota) error {
+	token := os.Getenv(ArtifactsToken)
+
+	if token != "" && download.SHA256 != "" {
+		for _, peer := range deploy.Artifacts.Peers {
+			err := fetchPeer(scope, client, peer, token, download, to, quota)
+			if err == nil {
+				return nil
+			}
+
+			if errors.Is(err, scope.Err()) {
+				return err
+			}
+
+			ctx.warn(&PeerError{Peer: peer, URL: download.URL, Err: err})
+		}
+	}
+
+	return fetch(scope, client, download, to, quota)
+}
+
+func fetchPeer(scope context.Context, client *http.Client, peer, token string, download *models.ScriptDownload, to string, quota *quota) error {
+	address := strings.TrimRight(peer, "/") + ArtifactsPath + artifactKey(download.URL)
+
+	request, err := http.NewRequestWithContext(scope, http.MethodGet, address, nil)
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return &StatusError{URL: address, Status: response.Status, Code: response.StatusCode}
+	}
+
+	expected := response.Header.Get(checksumHeader)
+	if expected == "" {
+		return ErrPeerChecksum
+	}
+
+	if !strings.EqualFold(expected, download.SHA256) {
+		return &ChecksumError{Source: address, Expected: download.SHA256, Actual: expected}
+	}
+
+	if response.ContentLength >= 0 {
+		err = quota.reserve(response.ContentLength)
+		if err != nil {
+			return err
+		}
+	}
+
+	partial := to + ".peer"
+
+	file, err := os.Create(partial)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(file, hash), response.Body)
+
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err == nil && response.ContentLength < 0 {
+		err = quota.reserve(written)
+	}
+
+	if actual := hex.EncodeToString(hash.Sum(nil)); err == nil && !strings.EqualFold(actual, expected) {
+		err = &ChecksumError{Source: address, Expected: expected, Actual: actual}
+	}
+
+	if err != nil {
+		os.Remove(partial)
+		return err
+	}
+
+	return os.Rename(partial, to)
+}
@@ -0,0 +1,45 @@
+package deployctl
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+func (ctx *Context) SetAudit(audit bool) {
+	ctx.audit = audit
+	ctx.dryRun = ctx.dryRun || audit
+}
+
+func (ctx *Context) auditable(script *models.Script) bool {
+	return ctx.audit && (script.ReadOnly || script.Manifest != nil || script.Compat != nil)
+}
+
+func (ctx *Context) auditManifest(scope context.Context, manifest *models.ScriptManifest, result *Result) error {
+	path := ManifestPath(manifest.Path, manifest.To)
+
+	loaded, err := LoadManifest(path)
+	if err != nil {
+		return err
+	}
+
+	changes, err := VerifyManifest(scope, loaded, manifest.Path, path)
+	if err != nil || len(changes) == 0 {
+		return err
+	}
+
+	lines := make([]string, 0, len(changes))
+
+	for _, change := range changes {
+		line := string(change.Kind) + " " + change.Path
+		if change.Reason != "" {
+			line += ": " + change.Reason
+		}
+
+		lines = append(lines, line)
+	}
+
+	result.Diff = strings.Join(lines, "\n")
+	return &VerifyError{Path: manifest.Path, Changes: len(changes)}
+}
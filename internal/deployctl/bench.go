@@ -0,0 +1,112 @@
+package deployctl
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+type (
+	Benchmark struct {
+		Name      string
+		Durations []time.Duration
+		Failures  int
+		Total     time.Duration
+		Read      int64
+		Written   int64
+	}
+)
+
+func (ctx *Context) Bench(deploy *models.Deploy, resolver *variables.Resolver, name string, iterations int) (benchmark *Benchmark, err error) {
+	script, ok := deploy.Scripts.Scripts[name]
+	if !ok || script == nil {
+		return nil, &UnknownScriptError{Name: name}
+	}
+
+	err = ctx.Confirm(deploy, []string{name})
+	if err != nil {
+		return nil, err
+	}
+
+	script, err = ExpandScript(script, resolver)
+	if err != nil {
+		return nil, err
+	}
+
+	benchmark = &Benchmark{
+		Name:      name,
+		Durations: make([]time.Duration, 0, iterations),
+	}
+
+	start := time.Now()
+
+	for i := 0; i < iterations; i++ {
+		iteration := time.Now()
+
+		result := &Result{Name: name, Type: script.Type(), Start: iteration}
+
+		scope, cancel := withTimeout(ctx, script.Timeout)
+		err = ctx.execute(scope, deploy, script, resolver, result)
+		cancel()
+
+		if err != nil {
+			benchmark.Failures++
+		}
+
+		benchmark.Read += result.Usage.Read
+		benchmark.Written += result.Usage.Written
+
+		benchmark.Durations = append(benchmark.Durations, time.Since(iteration))
+	}
+
+	benchmark.Total = time.Since(start)
+
+	sort.Slice(benchmark.Durations, func(i, j int) bool {
+		return benchmark.Durations[i] < benchmark.Durations[j]
+	})
+
+	return benchmark, nil
+}
+
+func (benchmark *Benchmark) Percentile(percentile float64) time.Duration {
+	if len(benchmark.Durations) == 0 {
+		return 0
+	}
+
+	index := int(percentile / 100 * float64(len(benchmark.Durations)-1))
+	return benchmark.Durations[index]
+}
+
+func (benchmark *Benchmark) Mean() time.Duration {
+	if len(benchmark.Durations) == 0 {
+		return 0
+	}
+
+	return benchmark.Total / time.Duration(len(benchmark.Durations))
+}
+
+func (benchmark *Benchmark) Throughput() float64 {
+	if benchmark.Total == 0 {
+		return 0
+	}
+
+	return float64(len(benchmark.Durations)) / benchmark.Total.Seconds()
+}
+
+func (benchmark *Benchmark) ReadRate() float64 {
+	if benchmark.Total == 0 {
+		return 0
+	}
+
+	return float64(benchmark.Read) / benchmark.Total.Seconds()
+}
+
+func (benchmark *Benchmark) WriteRate() float64 {
+	if benchmark.Total == 0 {
+		return 0
+	}
+
+	return float64(benchmark.Written) / benchmark.Total.Seconds()
+}
@@ -0,0 +1,140 @@
+package deployctl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/ignore"
+)
+
+type (
+	CacheError struct {
+		Path string
+		Err  error
+	}
+)
+
+const resultsDirectory = "results"
+
+func (err *CacheError) Error() string {
+	return "cache result in " + err.Path + ": " + err.Err.Error()
+}
+
+func (err *CacheError) Unwrap() error {
+	return err.Err
+}
+
+func (ctx *Context) resultPath(kind string, inputs ...any) (string, error) {
+	if ctx.cache == "" {
+		return "", nil
+	}
+
+	hash := sha256.New()
+	io.WriteString(hash, kind+"\n")
+
+	for _, input := range inputs {
+		data, err := manifestAPI.Marshal(input)
+		if err != nil {
+			return "", err
+		}
+
+		hash.Write(append(data, '\n'))
+	}
+
+	return filepath.Join(ctx.cache, resultsDirectory, kind+"-"+hex.EncodeToString(hash.Sum(nil))), nil
+}
+
+func cachedResult(path string) bool {
+	if path == "" {
+		return false
+	}
+
+	expected, err := os.ReadFile(path + ".sha256")
+	if err != nil {
+		return false
+	}
+
+	sum, err := checksumFile(path)
+	if err != nil || sum != strings.TrimSpace(string(expected)) {
+		return false
+	}
+
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	return true
+}
+
+func (ctx *Context) storeResult(path string, write func(path string) error) {
+	if path == "" || ctx.dryRun {
+		return
+	}
+
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err == nil {
+		err = write(path)
+	}
+
+	sum := ""
+	if err == nil {
+		sum, err = checksumFile(path)
+	}
+
+	if err == nil {
+		err = writeFileAtomic(path+".sha256", []byte(sum+"\n"), 0o644)
+	}
+
+	if err != nil {
+		ctx.warn(&CacheError{Path: path, Err: err})
+	}
+}
+
+func treeDigest(root string) (string, error) {
+	hash := sha256.New()
+
+	err := ignore.Walk(root, nil, func(path string, entry fs.DirEntry) error {
+		relative, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		info, err := os.Lstat(path)
+		if err != nil {
+			return err
+		}
+
+		line := filepath.ToSlash(relative) + "\x00" + strconv.FormatUint(uint64(info.Mode()), 8)
+
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			line += "\x00" + link
+		case info.Mode().IsRegular():
+			sum, err := checksumFile(path)
+			if err != nil {
+				return err
+			}
+
+			line += "\x00" + sum
+		}
+
+		_, err = io.WriteString(hash, line+"\n")
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
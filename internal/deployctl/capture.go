@@ -0,0 +1,137 @@
+package deployctl
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+type (
+	Capture struct {
+		mutex   sync.Mutex
+		limit   int
+		head    []byte
+		tail    []byte
+		start   int
+		total   int64
+		spill   *os.File
+		spillTo string
+	}
+)
+
+const defaultOutputLimit = 1 << 20
+
+func NewCapture(limit int, spill bool) (capture *Capture, err error) {
+	if limit <= 0 {
+		limit = defaultOutputLimit
+	}
+
+	capture = &Capture{
+		limit: limit,
+		head:  make([]byte, 0, limit/2),
+		tail:  make([]byte, 0, limit-limit/2),
+	}
+
+	if spill {
+		capture.spill, err = os.CreateTemp("", "deploy-output-*")
+		if err != nil {
+			return nil, err
+		}
+
+		capture.spillTo = capture.spill.Name()
+	}
+
+	return capture, nil
+}
+
+func (capture *Capture) Write(data []byte) (int, error) {
+	capture.mutex.Lock()
+	defer capture.mutex.Unlock()
+
+	capture.total += int64(len(data))
+
+	if capture.spill != nil {
+		_, err := capture.spill.Write(data)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	rest := data
+
+	if room := cap(capture.head) - len(capture.head); room > 0 {
+		n := min(room, len(rest))
+		capture.head = append(capture.head, rest[:n]...)
+		rest = rest[n:]
+	}
+
+	size := cap(capture.tail)
+	if size == 0 {
+		return len(data), nil
+	}
+
+	if len(rest) > size {
+		rest = rest[len(rest)-size:]
+	}
+
+	for len(rest) > 0 {
+		if len(capture.tail) < size {
+			n := min(size-len(capture.tail), len(rest))
+			capture.tail = append(capture.tail, rest[:n]...)
+			rest = rest[n:]
+			continue
+		}
+
+		n := copy(capture.tail[capture.start:], rest)
+		capture.start = (capture.start + n) % size
+		rest = rest[n:]
+	}
+
+	return len(data), nil
+}
+
+func (capture *Capture) Close() error {
+	capture.mutex.Lock()
+	defer capture.mutex.Unlock()
+
+	if capture.spill == nil {
+		return nil
+	}
+
+	err := capture.spill.Close()
+	capture.spill = nil
+	return err
+}
+
+func (capture *Capture) Truncated() int64 {
+	capture.mutex.Lock()
+	defer capture.mutex.Unlock()
+
+	return capture.total - int64(len(capture.head)+len(capture.tail))
+}
+
+func (capture *Capture) SpillPath() string {
+	return capture.spillTo
+}
+
+func (capture *Capture) Bytes() []byte {
+	capture.mutex.Lock()
+	defer capture.mutex.Unlock()
+
+	output := make([]byte, 0, len(capture.head)+len(capture.tail)+64)
+	output = append(output, capture.head...)
+
+	if truncated := capture.total - int64(len(capture.head)+len(capture.tail)); truncated > 0 {
+		marker := fmt.Sprintf("\n... [%d bytes truncated", truncated)
+		if capture.spillTo != "" {
+			marker += ", full output in " + capture.spillTo
+		}
+
+		output = append(output, marker+"] ...\n"...)
+	}
+
+	output = append(output, capture.tail[capture.start:]...)
+	output = append(output, capture.tail[:capture.start]...)
+
+	return output
+}
@@ -0,0 +1,138 @@
+package deployctl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+func TestChanged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("downloaded"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		script string
+		modify func(t *testing.T, dir string)
+		second Outcome
+	}{
+		{name: "copy file", script: `{"Copy": {"From": "source/app", "To": "copied"}}`, second: OutcomeUnchanged},
+		{name: "copy edited file", script: `{"Copy": {"From": "source/app", "To": "copied"}}`, modify: edit("source/app", "other"), second: OutcomeChanged},
+		{name: "copy with mode", script: `{"Copy": {"From": "source/app", "To": "copied", "Mode": "0600"}}`, second: OutcomeUnchanged},
+		{name: "copy chmodded file", script: `{"Copy": {"From": "source/app", "To": "copied"}}`, modify: chmod("copied", 0o600), second: OutcomeChanged},
+		{name: "copy tree", script: `{"Copy": {"From": "source", "To": "tree"}}`, second: OutcomeUnchanged},
+		{name: "copy edited tree", script: `{"Copy": {"From": "source", "To": "tree"}}`, modify: edit("source/app", "other"), second: OutcomeChanged},
+		{name: "copy atomic", script: `{"Copy": {"From": "source/app", "To": "copied", "Atomic": true}}`, second: OutcomeUnchanged},
+		{name: "download", script: `{"Download": {"URL": "` + server.URL + `/file", "To": "downloaded"}}`, second: OutcomeUnchanged},
+		{name: "download edited", script: `{"Download": {"URL": "` + server.URL + `/file", "To": "downloaded"}}`, modify: edit("downloaded", "different"), second: OutcomeChanged},
+		{name: "archive", script: `{"Archive": {"From": "source", "To": "packed.tar.gz"}}`, second: OutcomeUnchanged},
+		{name: "archive edited", script: `{"Archive": {"From": "source", "To": "packed.tar.gz"}}`, modify: edit("source/app", "other"), second: OutcomeChanged},
+		{name: "extract", script: `{"Extract": {"From": "fixture.tar.gz", "To": "unpacked"}}`, second: OutcomeUnchanged},
+		{name: "extract edited", script: `{"Extract": {"From": "fixture.tar.gz", "To": "unpacked"}}`, modify: edit("unpacked/app", "other"), second: OutcomeChanged},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			err := os.MkdirAll(filepath.Join(dir, "source", "nested"), 0o755)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			edit("source/app", "binary")(t, dir)
+			edit("source/nested/config", "config")(t, dir)
+
+			err = os.Symlink("app", filepath.Join(dir, "source", "current"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Contains(test.script, "fixture.tar.gz") {
+				outcome := processScript(t, dir, `{"Archive": {"From": "source", "To": "fixture.tar.gz"}}`)
+				if outcome != OutcomeChanged {
+					t.Fatalf("fixture archive was %s", outcome)
+				}
+			}
+
+			outcome := processScript(t, dir, test.script)
+			if outcome != OutcomeChanged {
+				t.Fatalf("first run was %s, expected %s", outcome, OutcomeChanged)
+			}
+
+			if test.modify != nil {
+				test.modify(t, dir)
+			}
+
+			outcome = processScript(t, dir, test.script)
+			if outcome != test.second {
+				t.Errorf("second run was %s, expected %s", outcome, test.second)
+			}
+		})
+	}
+}
+
+func processScript(t *testing.T, dir, script string) Outcome {
+	t.Helper()
+
+	deploys, err := models.Load(strings.NewReader(`{"Version": 2, "Scripts": {"step": `+script+`}}`), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = models.Resolve(deploys, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolver, err := variables.Resolve(deploys[0], nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := NewContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	report, err := ctx.ProcessContext(context.Background(), deploys[0], resolver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, result := range report.Results {
+		if result.Err != nil {
+			t.Fatal(result.Err)
+		}
+	}
+
+	return report.Results[0].Outcome()
+}
+
+func edit(name, content string) func(t *testing.T, dir string) {
+	return func(t *testing.T, dir string) {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func chmod(name string, mode os.FileMode) func(t *testing.T, dir string) {
+	return func(t *testing.T, dir string) {
+		err := os.Chmod(filepath.Join(dir, name), mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package deployctl
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	ClockSkewError struct {
+		Host    string
+		Skew    time.Duration
+		MaxSkew time.Duration
+	}
+
+	ClockSyncError struct {
+		Host  string
+		Known bool
+	}
+
+	ClockError struct {
+		Host string
+		Err  error
+	}
+)
+
+const clockCommand = "date +%s.%N; timedatectl show -p NTPSynchronized --value 2>/dev/null || true"
+
+func (err *ClockSkewError) Error() string {
+	direction := "ahead of"
+	if err.Skew < 0 {
+		direction = "behind"
+	}
+
+	return "clock of " + err.Host + " is " + abs(err.Skew).Round(time.Millisecond).String() + " " + direction + " the controller, more than the allowed " + err.MaxSkew.String()
+}
+
+func (err *ClockSyncError) Error() string {
+	if !err.Known {
+		return "cannot tell whether the clock of " + err.Host + " is synchronized, timedatectl is not available"
+	}
+
+	return "clock of " + err.Host + " is not synchronized with NTP"
+}
+
+func (err *ClockError) Error() string {
+	return "check clock of " + err.Host + ": " + err.Err.Error()
+}
+
+func (err *ClockError) Unwrap() error {
+	return err.Err
+}
+
+func (ctx *Context) checkClocks(run *execution, hosts []string) error {
+	deploy := run.deploy
+	clock := deploy.Clock
+
+	if clock.MaxSkew.Duration <= 0 && !clock.Synchronized || clock.Severity == models.SeverityOff {
+		return nil
+	}
+
+	workers := make(chan struct{}, max(deploy.Parallel, defaultPrefetchWorkers))
+	errs := make([]error, len(hosts))
+	group := new(sync.WaitGroup)
+
+	for i, host := range hosts {
+		target, err := ctx.sshTarget(deploy, host)
+		if err != nil {
+			return err
+		}
+
+		if target == nil {
+			continue
+		}
+
+		group.Add(1)
+		workers <- struct{}{}
+
+		go func() {
+			defer group.Done()
+			defer func() { <-workers }()
+
+			errs[i] = ctx.checkClock(run, target, clock)
+		}()
+	}
+
+	group.Wait()
+
+	failed := []error(nil)
+
+	for _, err := range errs {
+		clockErr := (*ClockError)(nil)
+
+		switch {
+		case err == nil:
+		case errors.As(err, &clockErr), clock.Severity != models.SeverityError:
+			ctx.warn(err)
+		default:
+			failed = append(failed, err)
+		}
+	}
+
+	return errors.Join(failed...)
+}
+
+func (ctx *Context) checkClock(run *execution, target *sshTarget, clock models.Clock) error {
+	sent := time.Now()
+
+	output, err := ctx.sshShell(run.scope, target, clockCommand)
+	if err != nil {
+		return &ClockError{Host: target.host, Err: err}
+	}
+
+	received := time.Now()
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+
+	remote, err := parseEpoch(lines[0])
+	if err != nil {
+		return &ClockError{Host: target.host, Err: err}
+	}
+
+	if clock.MaxSkew.Duration > 0 {
+		latency := received.Sub(sent) / 2
+		skew := remote.Sub(sent.Add(latency))
+
+		if abs(skew)-latency > clock.MaxSkew.Duration {
+			return &ClockSkewError{Host: target.host, Skew: skew, MaxSkew: clock.MaxSkew.Duration}
+		}
+	}
+
+	if clock.Synchronized {
+		synchronized := ""
+		if len(lines) > 1 {
+			synchronized = strings.TrimSpace(lines[1])
+		}
+
+		if synchronized != "yes" {
+			return &ClockSyncError{Host: target.host, Known: synchronized != ""}
+		}
+	}
+
+	return nil
+}
+
+func parseEpoch(text string) (time.Time, error) {
+	seconds, fraction, _ := strings.Cut(strings.TrimSpace(text), ".")
+
+	whole, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	nanoseconds, err := strconv.ParseInt((fraction + "000000000")[:9], 10, 64)
+	if err != nil {
+		nanoseconds = 0
+	}
+
+	return time.Unix(whole, nanoseconds), nil
+}
+
+func abs(duration time.Duration) time.Duration {
+	if duration < 0 {
+		return -duration
+	}
+
+	return duration
+}
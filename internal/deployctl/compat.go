@@ -0,0 +1,168 @@
+package deployctl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/version"
+)
+
+type (
+	IncompatibleError struct {
+		Service    string
+		Version    string
+		Constraint string
+	}
+
+	QueryError struct {
+		Service string
+		Err     error
+	}
+)
+
+const compatBodyLimit = 1 << 20
+
+func (err *IncompatibleError) Error() string {
+	return err.Service + " " + err.Version + " does not satisfy " + err.Constraint
+}
+
+func (err *QueryError) Error() string {
+	return "query version of " + err.Service + ": " + err.Err.Error()
+}
+
+func (err *QueryError) Unwrap() error {
+	return err.Err
+}
+
+func (ctx *Context) compat(scope context.Context, deploy *models.Deploy, compat *models.ScriptCompat, target *sshTarget, result *Result) error {
+	lines, failed := []string(nil), []error(nil)
+
+	for _, dependency := range compat.Services {
+		constraint, err := version.ParseConstraint(dependency.Constraint)
+		if err != nil {
+			failed = append(failed, &QueryError{Service: dependency.Name, Err: err})
+			continue
+		}
+
+		output, err := ctx.queryVersion(scope, deploy, dependency, target)
+		if err == nil && dependency.Path != "" {
+			output, err = versionAt(output, dependency.Path)
+		}
+
+		if err != nil {
+			failed = append(failed, &QueryError{Service: dependency.Name, Err: err})
+			continue
+		}
+
+		text := strings.TrimSpace(string(output))
+
+		current, err := version.Parse(text)
+		if err != nil {
+			failed = append(failed, &QueryError{Service: dependency.Name, Err: err})
+			continue
+		}
+
+		lines = append(lines, dependency.Name+" "+text)
+
+		if !constraint.Allows(current) {
+			failed = append(failed, &IncompatibleError{Service: dependency.Name, Version: text, Constraint: constraint.String()})
+		}
+	}
+
+	result.Output = strings.Join(lines, "\n")
+	return errors.Join(failed...)
+}
+
+func (ctx *Context) queryVersion(scope context.Context, deploy *models.Deploy, dependency *models.Dependency, target *sshTarget) ([]byte, error) {
+	if dependency.URL == "" && target != nil {
+		return ctx.sshShell(scope, target, dependency.Command)
+	}
+
+	if dependency.URL == "" {
+		return ctx.effect("run", []string{"sh", "-c", dependency.Command}, func() ([]byte, error) {
+			command := exec.CommandContext(scope, "sh", "-c", dependency.Command)
+			ctx.graceful(command, models.Duration{})
+
+			stderr := new(bytes.Buffer)
+			command.Stderr = stderr
+
+			output, err := command.Output()
+			if err != nil && stderr.Len() > 0 {
+				return output, fmt.Errorf("%w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+			}
+
+			return output, err
+		})
+	}
+
+	client, err := ctx.httpClient(deploy)
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx.effect("compat", []string{dependency.Name, dependency.URL}, func() ([]byte, error) {
+		request, err := http.NewRequestWithContext(scope, http.MethodGet, dependency.URL, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for key, value := range dependency.Headers {
+			request.Header.Set(key, value)
+		}
+
+		response, err := client.Do(request)
+		if err != nil {
+			return nil, err
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode < 200 || response.StatusCode > 299 {
+			return nil, &StatusError{URL: dependency.URL, Status: response.Status, Code: response.StatusCode}
+		}
+
+		return io.ReadAll(io.LimitReader(response.Body, compatBodyLimit))
+	})
+}
+
+func versionAt(output []byte, path string) ([]byte, error) {
+	document := any(nil)
+
+	err := sonic.Unmarshal(output, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := extract(document, path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return []byte(value), nil
+}
+
+func planCompat(compat *models.ScriptCompat) (plan []string, err error) {
+	for _, dependency := range compat.Services {
+		_, err = version.ParseConstraint(dependency.Constraint)
+		if err != nil {
+			return nil, err
+		}
+
+		source := dependency.URL
+		if source == "" {
+			source = "`" + dependency.Command + "`"
+		}
+
+		plan = append(plan, "check "+dependency.Name+" version from "+source+" against "+dependency.Constraint)
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,157 @@
+package deployctl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	nopWriteCloser struct {
+		io.Writer
+	}
+)
+
+var (
+	decompressCommands = map[models.Compression]string{
+		models.CompressionGzip: "gzip -dc",
+		models.CompressionZstd: "zstd -dcq",
+	}
+
+	compressedExtensions = []string{
+		".gz", ".tgz", ".zst", ".xz", ".bz2", ".lz4", ".zip", ".7z", ".rar",
+		".jpg", ".jpeg", ".png", ".gif", ".webp", ".mp4", ".mkv", ".webm", ".mp3",
+	}
+
+	compressedMagic = [][]byte{
+		{0x1f, 0x8b},
+		{0x28, 0xb5, 0x2f, 0xfd},
+		{0xfd, '7', 'z', 'X', 'Z', 0x00},
+		{'B', 'Z', 'h'},
+		{'P', 'K', 0x03, 0x04},
+		{0x89, 'P', 'N', 'G'},
+		{0xff, 0xd8, 0xff},
+	}
+)
+
+func Compressed(path string, head []byte) bool {
+	extension := strings.ToLower(filepath.Ext(path))
+
+	for _, candidate := range compressedExtensions {
+		if extension == candidate {
+			return true
+		}
+	}
+
+	for _, magic := range compressedMagic {
+		if bytes.HasPrefix(head, magic) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TransferCompression(compression models.Compression, path string, head []byte) models.Compression {
+	if compression == "" || Compressed(path, head) {
+		return models.CompressionNone
+	}
+
+	return compression
+}
+
+func CompressWriter(writer io.Writer, compression models.Compression) (io.WriteCloser, error) {
+	switch compression {
+	case models.CompressionGzip:
+		return gzip.NewWriter(writer), nil
+	case models.CompressionZstd:
+		return zstd.NewWriter(writer)
+	}
+
+	return nopWriteCloser{writer}, nil
+}
+
+func (ctx *Context) putCompressed(scope context.Context, target *sshTarget, source, partial string, info os.FileInfo) error {
+	compression := target.remote.Compression
+	if compression == "" || compression == models.CompressionNone {
+		return ctx.sftp(scope, target, []string{"put -rp " + sftpQuote(source) + " " + sftpQuote(partial)})
+	}
+
+	if info.IsDir() {
+		compressed := &sshTarget{host: target.host, remote: target.remote, options: append([]string{"-o", "Compression=yes"}, target.options...)}
+		return ctx.sftp(scope, compressed, []string{"put -rp " + sftpQuote(source) + " " + sftpQuote(partial)})
+	}
+
+	file, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+
+	compression = TransferCompression(compression, source, head[:n])
+	if compression == models.CompressionNone {
+		return ctx.sftp(scope, target, []string{"put -rp " + sftpQuote(source) + " " + sftpQuote(partial)})
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	decompress := decompressCommands[compression] + " > " + shellQuote(partial) + " && chmod " + strconv.FormatUint(uint64(info.Mode().Perm()), 8) + " " + shellQuote(partial)
+	args := target.args(decompress)
+
+	_, err = ctx.effect("ssh", append([]string{"ssh"}, append(args, "<", source)...), func() ([]byte, error) {
+		reader, writer := io.Pipe()
+		defer reader.Close()
+
+		go func() {
+			compressor, err := CompressWriter(writer, compression)
+			if err == nil {
+				_, err = io.Copy(compressor, file)
+
+				closeErr := compressor.Close()
+				if err == nil {
+					err = closeErr
+				}
+			}
+
+			writer.CloseWithError(err)
+		}()
+
+		ssh := exec.CommandContext(scope, "ssh", args...)
+		ctx.graceful(ssh, models.Duration{})
+		ssh.Stdin = reader
+
+		output, err := ssh.CombinedOutput()
+		if err != nil && len(output) > 0 {
+			return output, fmt.Errorf("%s: %w: %s", target.host, err, bytes.TrimSpace(output))
+		}
+
+		return output, err
+	})
+
+	return err
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
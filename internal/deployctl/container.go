@@ -0,0 +1,85 @@
+package deployctl
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+const (
+	ContainerPlan   = "/plan"
+	ContainerFolder = "/deploy"
+)
+
+var (
+	containerMarkers  = []string{"/.dockerenv", "/run/.containerenv"}
+	containerCgroups  = []string{"docker", "kubepods", "containerd", "libpod", "lxc"}
+	containerServices = []string{"systemctl", "service", "journalctl"}
+)
+
+func DetectContainer() bool {
+	if os.Getenv("container") != "" || os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return true
+	}
+
+	for _, marker := range containerMarkers {
+		_, err := os.Stat(marker)
+		if err == nil {
+			return true
+		}
+	}
+
+	cgroup, err := os.ReadFile("/proc/1/cgroup")
+	if err != nil {
+		return false
+	}
+
+	for _, name := range containerCgroups {
+		if strings.Contains(string(cgroup), name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (ctx *Context) SetContainer(container bool) {
+	ctx.container = container
+}
+
+func ContainerDefaults(deploys []*models.Deploy) {
+	info, err := os.Stat(ContainerFolder)
+	if err != nil || !info.IsDir() {
+		return
+	}
+
+	for _, deploy := range deploys {
+		if deploy.Folder == "" {
+			deploy.Folder = ContainerFolder
+		}
+	}
+}
+
+func ContainerSkips(script *models.Script) bool {
+	if script.Run == nil {
+		return false
+	}
+
+	path, args := script.Run.Path, script.Run.Args
+	if filepath.Base(path) == "sudo" && len(args) > 0 {
+		path = args[0]
+	}
+
+	return slices.Contains(containerServices, filepath.Base(path))
+}
+
+func containerCommand(path string, arguments []string) (string, []string) {
+	if filepath.Base(path) != "sudo" || len(arguments) == 0 || strings.HasPrefix(arguments[0], "-") || os.Geteuid() != 0 {
+		return path, arguments
+	}
+
+	return arguments[0], arguments[1:]
+}
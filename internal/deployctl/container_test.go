@@ -0,0 +1,75 @@
+package deployctl
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+func TestContainerSkips(t *testing.T) {
+	tests := []struct {
+		name   string
+		script *models.Script
+		skips  bool
+	}{
+		{name: "systemctl", script: &models.Script{Run: &models.ScriptRun{Path: "systemctl", Args: []string{"restart", "app"}}}, skips: true},
+		{name: "absolute service", script: &models.Script{Run: &models.ScriptRun{Path: "/usr/sbin/service", Args: []string{"app", "restart"}}}, skips: true},
+		{name: "sudo journalctl", script: &models.Script{Run: &models.ScriptRun{Path: "sudo", Args: []string{"journalctl", "-u", "app"}}}, skips: true},
+		{name: "sudo", script: &models.Script{Run: &models.ScriptRun{Path: "sudo", Args: []string{"make", "install"}}}, skips: false},
+		{name: "bare sudo", script: &models.Script{Run: &models.ScriptRun{Path: "sudo"}}, skips: false},
+		{name: "run", script: &models.Script{Run: &models.ScriptRun{Path: "./app"}}, skips: false},
+		{name: "systemctl argument", script: &models.Script{Run: &models.ScriptRun{Path: "echo", Args: []string{"systemctl"}}}, skips: false},
+		{name: "delete", script: &models.Script{Delete: &models.ScriptDelete{Path: "/srv/app"}}, skips: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if skips := ContainerSkips(test.script); skips != test.skips {
+				t.Errorf("ContainerSkips() = %t, expected %t", skips, test.skips)
+			}
+		})
+	}
+}
+
+func TestTestPlan(t *testing.T) {
+	config, err := os.ReadFile("testdata/plan.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		test     *models.PlanTest
+		failures int
+	}{
+		{test: &models.PlanTest{Name: "staging", Environment: "staging", Scripts: []string{"build", "start", "restart"}}},
+		{test: &models.PlanTest{Name: "staging container", Environment: "staging", Container: true, Scripts: []string{"build", "start"}}},
+		{test: &models.PlanTest{Name: "production container", Environment: "production", Container: true, Scripts: []string{"clean"}}},
+		{test: &models.PlanTest{Name: "all", Scripts: []string{"build", "start", "restart", "clean", "logs"}}},
+		{test: &models.PlanTest{Name: "wrong scripts", Environment: "staging", Scripts: []string{"build"}}, failures: 1},
+		{test: &models.PlanTest{Name: "forbidden", Environment: "production", Forbid: []string{"Delete"}}, failures: 1},
+		{test: &models.PlanTest{Name: "forbidden in container", Environment: "production", Container: true, Forbid: []string{"Run"}}},
+		{test: &models.PlanTest{
+			Name:        "resolved",
+			Environment: "staging",
+			Env:         map[string]string{"HOME": "/home/ci"},
+			Variables:   map[string]string{"PORT": "9090"},
+			Resolved:    map[string]models.Environment{"start": {"PORT": "9090", "CACHE": "/home/ci/.cache"}},
+		}},
+		{test: &models.PlanTest{Name: "resolved mismatch", Environment: "staging", Env: map[string]string{"HOME": "/home/ci"}, Resolved: map[string]models.Environment{"start": {"PORT": "9090"}}}, failures: 1},
+		{test: &models.PlanTest{Name: "undefined", Environment: "staging", Resolved: map[string]models.Environment{"start": {"PORT": "8080"}}}, failures: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.test.Name, func(t *testing.T) {
+			failures, err := TestPlan(config, t.TempDir(), test.test, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(failures) != test.failures {
+				t.Errorf("failures %q, expected %d", failures, test.failures)
+			}
+		})
+	}
+}
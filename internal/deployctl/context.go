@@ -1,15 +1,48 @@
 package deployctl
 
 import (
+	"context"
+	"net/http"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/iceber/iouring-go"
+
+	"github.com/gohryt/dotdeploy/internal/models"
 )
 
 type (
 	Context struct {
-		ring *iouring.IOURing
-		done chan struct{}
+		ring      *iouring.IOURing
+		done      chan struct{}
+		stepper   Stepper
+		approver  Approver
+		confirmer Confirmer
+		starter   Starter
+		finisher  Finisher
+		warner    Warner
+		streamer  Streamer
+		dryRun    bool
+		audit     bool
+		container bool
+		confirmed bool
+		cache     string
+		control   string
+		effects   Effects
+
+		mutex   sync.Mutex
+		clients map[models.HTTP]*http.Client
+		paths   map[string]*sync.Mutex
+		swarms  map[string]*swarm
+		fresh   map[string]bool
+		killing map[int]bool
+		relayed *relayKey
+		relays  []*sshTarget
+		cancel  sync.Once
+
+		confirming sync.Mutex
+		accepted   map[*models.Deploy]bool
 	}
 )
 
@@ -29,20 +62,58 @@ func NewContext() (ctx *Context, err error) {
 
 func (ctx *Context) Close() error {
 	err := ctx.ring.Close()
-	close(ctx.done)
+	ctx.Cancel()
+	ctx.reap()
+
+	ctx.revokeRelays()
+
+	if ctx.control != "" {
+		os.RemoveAll(ctx.control)
+	}
+
 	return err
 }
 
+func (ctx *Context) Cancel() {
+	ctx.cancel.Do(func() {
+		close(ctx.done)
+	})
+}
+
+func (ctx *Context) lockPath(path string) func() {
+	ctx.mutex.Lock()
+
+	if ctx.paths == nil {
+		ctx.paths = make(map[string]*sync.Mutex)
+	}
+
+	lock, ok := ctx.paths[path]
+	if !ok {
+		lock = new(sync.Mutex)
+		ctx.paths[path] = lock
+	}
+
+	ctx.mutex.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
 func (ctx *Context) Deadline() (deadline time.Time, ok bool) {
 	return time.Time{}, false
 }
 
-func (ctx *Context) Done() chan struct{} {
+func (ctx *Context) Done() <-chan struct{} {
 	return ctx.done
 }
 
 func (ctx *Context) Err() error {
-	return nil
+	select {
+	case <-ctx.done:
+		return context.Canceled
+	default:
+		return nil
+	}
 }
 
 func (ctx *Context) Value(key any) any {
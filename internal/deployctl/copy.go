@@ -0,0 +1,370 @@
+package deployctl
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/ignore"
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+func (ctx *Context) copy(scope context.Context, deploy *models.Deploy, copy *models.ScriptCopy, result *Result) error {
+	list, err := transfers(deploy.Folder, copy.From, copy.To, copy.PreservePath)
+	if err != nil {
+		return err
+	}
+
+	attributes := newAttributes(copy.Mode, copy.Owner, copy.Group)
+
+	for _, transfer := range list {
+		info, err := os.Stat(transfer.From)
+		if err != nil {
+			return err
+		}
+
+		err = verifyChecksum(transfer.From, copy.SHA256)
+		if err != nil {
+			return err
+		}
+
+		mode := info.Mode()
+		if copy.Mode.Set {
+			mode = copy.Mode.FileMode
+		}
+
+		if !info.IsDir() && copy.IfExists != models.IfExistsFail {
+			same, err := sameFile(transfer.From, transfer.To, mode)
+			if err != nil {
+				return err
+			}
+
+			if same {
+				changed, err := applyAttributes(transfer.To, models.Mode{}, attributes.owner)
+				result.Changed = result.Changed || changed
+				if err != nil {
+					return err
+				}
+
+				continue
+			}
+		}
+
+		skip, err := ctx.prepareDestination(transfer.To, copy.IfExists, result)
+		if err != nil {
+			return err
+		}
+
+		if skip {
+			continue
+		}
+
+		if !info.IsDir() {
+			result.Diff += fileDiff(transfer.To, transfer.From)
+		}
+
+		_, err = ctx.effect("copy", []string{transfer.From, transfer.To}, func() ([]byte, error) {
+			return nil, await(scope, result, func() error {
+				if isGlob(copy.From) {
+					err := makeDirectories(filepath.Dir(transfer.To), 0o755, result.directories)
+					if err != nil {
+						return err
+					}
+				}
+
+				if !info.IsDir() {
+					err := result.quota.reserve(info.Size())
+					if err != nil {
+						return err
+					}
+
+					if copy.Atomic {
+						written, err := copyAtomic(scope, transfer.From, transfer.To, mode, copy.SHA256)
+						result.Usage.Written += written
+						if err != nil {
+							return err
+						}
+					} else {
+						written, err := copyRegular(scope, transfer.From, transfer.To, mode)
+						result.Usage.Written += written
+						if err != nil {
+							return err
+						}
+
+						err = verifyChecksum(transfer.To, copy.SHA256)
+						if err != nil {
+							return err
+						}
+					}
+
+					result.Changed = true
+
+					_, err = applyAttributes(transfer.To, models.Mode{}, attributes.owner)
+					return err
+				}
+
+				changed, err := copyTree(scope, transfer.From, transfer.To, copy.Exclude, copy.Atomic, attributes, result)
+				result.Changed = result.Changed || changed
+				return err
+			})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyTree(scope context.Context, from, to string, exclude []string, atomic bool, attributes attributes, result *Result) (changed bool, err error) {
+	matcher := ignore.New()
+	matcher.AddPatterns(exclude)
+
+	directories := map[string]fs.FileMode{}
+
+	err = ignore.Walk(from, matcher, func(path string, entry fs.DirEntry) error {
+		err := scope.Err()
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(to, relative)
+
+		info, err := os.Lstat(path)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case info.IsDir():
+			directories[target] = info.Mode().Perm()
+
+			_, err := os.Lstat(target)
+			if errors.Is(err, os.ErrNotExist) {
+				changed = true
+			}
+
+			return os.MkdirAll(target, 0o700)
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			if current, err := os.Readlink(target); err == nil && current == link {
+				return nil
+			}
+
+			err = os.Remove(target)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+
+			changed = true
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			mode := info.Mode()
+			if attributes.mode.Set {
+				mode = attributes.mode.FileMode
+			}
+
+			same, err := sameFile(path, target, mode)
+			if err != nil {
+				return err
+			}
+
+			if same {
+				return attributes.apply(target, info)
+			}
+
+			err = result.quota.reserve(info.Size())
+			if err != nil {
+				return err
+			}
+
+			copyFile := copyRegular
+			if atomic {
+				copyFile = func(scope context.Context, from, to string, mode fs.FileMode) (int64, error) {
+					return copyAtomic(scope, from, to, mode, "")
+				}
+			}
+
+			written, err := copyFile(scope, path, target, info.Mode())
+			result.Usage.Written += written
+			if err != nil {
+				return err
+			}
+
+			changed = true
+			return attributes.apply(target, info)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return changed, err
+	}
+
+	for directory, mode := range directories {
+		err = os.Chmod(directory, mode)
+		if err != nil {
+			return changed, err
+		}
+
+		_, err = applyAttributes(directory, models.Mode{}, attributes.owner)
+		if err != nil {
+			return changed, err
+		}
+	}
+
+	return changed, nil
+}
+
+func copyRegular(scope context.Context, from, to string, mode fs.FileMode) (written int64, err error) {
+	source, err := os.Open(from)
+	if err != nil {
+		return 0, err
+	}
+	defer source.Close()
+
+	target, err := os.OpenFile(to, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return 0, err
+	}
+
+	written, err = io.Copy(target, &scopedReader{scope: scope, reader: source})
+
+	closeErr := target.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return written, err
+	}
+
+	return written, os.Chmod(to, mode.Perm()|mode&(fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky))
+}
+
+func copyAtomic(scope context.Context, from, to string, mode fs.FileMode, sum string) (written int64, err error) {
+	source, err := os.Open(from)
+	if err != nil {
+		return 0, err
+	}
+	defer source.Close()
+
+	target, err := os.CreateTemp(filepath.Dir(to), "."+filepath.Base(to)+".tmp-*")
+	if err != nil {
+		return 0, err
+	}
+
+	temporary := target.Name()
+
+	written, err = io.Copy(target, &scopedReader{scope: scope, reader: source})
+	if err == nil {
+		err = target.Sync()
+	}
+
+	closeErr := target.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Chmod(temporary, mode.Perm()|mode&(fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky))
+	}
+
+	if err == nil {
+		err = verifyChecksum(temporary, sum)
+	}
+
+	if err == nil {
+		err = os.Rename(temporary, to)
+	}
+
+	if err != nil {
+		os.Remove(temporary)
+		return written, err
+	}
+
+	return written, syncDirectory(filepath.Dir(to))
+}
+
+func syncDirectory(path string) error {
+	directory, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	err = directory.Sync()
+
+	closeErr := directory.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+func verifyChecksum(path, expected string) error {
+	if expected == "" {
+		return nil
+	}
+
+	sum, err := checksumFile(path)
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(sum, expected) {
+		return &ChecksumError{Source: path, Expected: expected, Actual: sum}
+	}
+
+	return nil
+}
+
+func planCopy(deploy *models.Deploy, copy *models.ScriptCopy) (plan []string, err error) {
+	list, err := transfers(deploy.Folder, copy.From, copy.To, copy.PreservePath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, transfer := range list {
+		_, err = os.Stat(transfer.From)
+		if err != nil {
+			return nil, err
+		}
+
+		err = verifyChecksum(transfer.From, copy.SHA256)
+		if err != nil {
+			return nil, err
+		}
+
+		steps, skip, err := planDestination(transfer.To, copy.IfExists)
+		if err != nil {
+			return nil, err
+		}
+
+		plan = append(plan, steps...)
+
+		if !skip {
+			step := "copy " + transfer.From + " to " + transfer.To
+			if copy.Atomic {
+				step += " atomically"
+			}
+
+			plan = append(plan, step)
+		}
+	}
+
+	return plan, nil
+}
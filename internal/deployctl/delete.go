@@ -0,0 +1,76 @@
+package deployctl
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+var ErrDeleteDirectory = errors.New("path is a directory, set Recursive to delete it with its contents")
+
+func (ctx *Context) delete(scope context.Context, remove *models.ScriptDelete, result *Result) error {
+	info, err := os.Lstat(remove.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	if info.IsDir() && !remove.Recursive {
+		entries, err := os.ReadDir(remove.Path)
+		if err != nil {
+			return err
+		}
+
+		if len(entries) > 0 {
+			return ErrDeleteDirectory
+		}
+	}
+
+	_, err = ctx.effect("delete", []string{remove.Path}, func() ([]byte, error) {
+		return nil, await(scope, result, func() error {
+			if remove.Recursive {
+				return os.RemoveAll(remove.Path)
+			}
+
+			return os.Remove(remove.Path)
+		})
+	})
+
+	result.Changed = err == nil
+	return err
+}
+
+func planDelete(remove *models.ScriptDelete) (plan []string, err error) {
+	info, err := os.Lstat(remove.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []string{"nothing to delete at " + remove.Path}, nil
+		}
+
+		return nil, err
+	}
+
+	if info.IsDir() && !remove.Recursive {
+		entries, err := os.ReadDir(remove.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(entries) > 0 {
+			return nil, ErrDeleteDirectory
+		}
+	}
+
+	err = writable(filepath.Dir(remove.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{"delete " + remove.Path}, nil
+}
@@ -0,0 +1,104 @@
+package deployctl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/gohryt/dotdeploy/internal/delta"
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	DeltaError struct {
+		Host string
+		Path string
+		Err  error
+	}
+)
+
+const deltaMinSize = 1 << 20
+
+func (err *DeltaError) Error() string {
+	return "delta transfer of " + err.Path + " to " + err.Host + ", uploading the whole file: " + err.Err.Error()
+}
+
+func (err *DeltaError) Unwrap() error {
+	return err.Err
+}
+
+func (ctx *Context) put(scope context.Context, target *sshTarget, source, destination, partial string, info os.FileInfo, exists bool) error {
+	if !target.remote.Delta || !exists || !info.Mode().IsRegular() || info.Size() < deltaMinSize {
+		return ctx.putCompressed(scope, target, source, partial, info)
+	}
+
+	signature, err := ctx.remoteSignature(scope, target, destination)
+	if err != nil {
+		ctx.warn(&DeltaError{Host: target.host, Path: destination, Err: err})
+		return ctx.putCompressed(scope, target, source, partial, info)
+	}
+
+	return ctx.putDelta(scope, target, source, destination, partial, info, signature)
+}
+
+func (ctx *Context) remoteSignature(scope context.Context, target *sshTarget, destination string) (*delta.Signature, error) {
+	args := target.args("deployctl delta-signature " + shellQuote(destination))
+
+	output, err := ctx.effect("ssh", append([]string{"ssh"}, args...), func() ([]byte, error) {
+		return ctx.sshStream(scope, target, args, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return delta.ReadSignature(bytes.NewReader(output))
+}
+
+func (ctx *Context) putDelta(scope context.Context, target *sshTarget, source, destination, partial string, info os.FileInfo, signature *delta.Signature) error {
+	sum, err := checksumFile(source)
+	if err != nil {
+		return err
+	}
+
+	patch := "deployctl delta-patch -sha256 " + sum + " " + shellQuote(destination) + " " + shellQuote(partial) + " && chmod " + strconv.FormatUint(uint64(info.Mode().Perm()), 8) + " " + shellQuote(partial)
+	args := target.args(patch)
+
+	_, err = ctx.effect("ssh", append([]string{"ssh"}, append(args, "<", source)...), func() ([]byte, error) {
+		file, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		reader, writer := io.Pipe()
+		defer reader.Close()
+
+		go func() {
+			writer.CloseWithError(delta.Encode(writer, signature, file))
+		}()
+
+		return ctx.sshStream(scope, target, args, reader)
+	})
+
+	return err
+}
+
+func (ctx *Context) sshStream(scope context.Context, target *sshTarget, args []string, input io.Reader) ([]byte, error) {
+	ssh := exec.CommandContext(scope, "ssh", args...)
+	ctx.graceful(ssh, models.Duration{})
+	ssh.Stdin = input
+
+	stderr := new(bytes.Buffer)
+	ssh.Stderr = stderr
+
+	output, err := ssh.Output()
+	if err != nil && stderr.Len() > 0 {
+		return output, fmt.Errorf("%s: %w: %s", target.host, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	return output, err
+}
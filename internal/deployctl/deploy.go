@@ -0,0 +1,183 @@
+package deployctl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+type (
+	depthKey struct{}
+)
+
+const maxDeployDepth = 8
+
+var ErrDeployDepth = errors.New("child deploys are nested too deeply, check for a deploy including itself")
+
+func (ctx *Context) deploy(scope context.Context, deploy *models.Deploy, script *models.Script, resolver *variables.Resolver, result *Result) error {
+	child := script.Deploy
+
+	config := child.Config
+	flags := make([]string, 0, len(child.Variables))
+
+	for _, name := range sortedKeys(child.Variables) {
+		flags = append(flags, name+"="+child.Variables[name])
+	}
+
+	if child.Remote != "" {
+		if ctx.dryRun {
+			return planRemoteDeploy(deploy, script, config, result)
+		}
+
+		return ctx.deployRemote(scope, deploy, script, config, flags, result)
+	}
+
+	depth, _ := scope.Value(depthKey{}).(int)
+	if depth >= maxDeployDepth {
+		return ErrDeployDepth
+	}
+
+	data, err := os.ReadFile(config)
+	if err != nil {
+		return err
+	}
+
+	children, err := models.Load(bytes.NewReader(data), false)
+	if err != nil {
+		return err
+	}
+
+	err = models.Resolve(children, filepath.Dir(config))
+	if err != nil {
+		return err
+	}
+
+	scope = context.WithValue(scope, depthKey{}, depth+1)
+
+	output := new(strings.Builder)
+	errs := []error(nil)
+
+	for _, childDeploy := range models.Select(children, child.Environment, child.Stage) {
+		childResolver, err := variables.Resolve(childDeploy, child.VariableFiles, flags)
+		if err != nil {
+			return err
+		}
+
+		report, err := ctx.process(scope, childDeploy, childResolver, child.Scripts...)
+
+		for _, childResult := range report.Results {
+			fmt.Fprintf(output, "%s %s %s\n", childResult.Type, childResult.Label(), childResult.Outcome())
+			result.Changed = result.Changed || childResult.Changed
+
+			for _, action := range childResult.Plan {
+				result.Plan = append(result.Plan, childResult.Type+" "+childResult.Label()+": "+action)
+			}
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+
+			if deploy.Strategy != models.StrategyContinue {
+				break
+			}
+		}
+	}
+
+	result.Output = output.String()
+	return errors.Join(errs...)
+}
+
+func planRemoteDeploy(deploy *models.Deploy, script *models.Script, config string, result *Result) error {
+	remote := script.Deploy.Remote
+
+	if deploy.Remotes.Remotes[remote] == nil {
+		return &UnknownRemoteError{Script: result.Name, Remote: remote}
+	}
+
+	result.Plan = []string{"deploy " + config + " on " + remote}
+	return nil
+}
+
+func (ctx *Context) deployRemote(scope context.Context, deploy *models.Deploy, script *models.Script, config string, flags []string, result *Result) error {
+	child := script.Deploy
+
+	remote := deploy.Remotes.Remotes[child.Remote]
+	if remote == nil {
+		return &UnknownRemoteError{Script: result.Name, Remote: child.Remote}
+	}
+
+	target, err := ctx.remoteTarget(deploy, child.Remote)
+	if err != nil {
+		return err
+	}
+
+	arguments := []string{"-config", config}
+
+	if child.Environment != "" {
+		arguments = append(arguments, "-environment", child.Environment)
+	}
+
+	if child.Stage != "" {
+		arguments = append(arguments, "-stage", child.Stage)
+	}
+
+	for _, file := range child.VariableFiles {
+		arguments = append(arguments, "-var-file", file)
+	}
+
+	for _, flag := range flags {
+		arguments = append(arguments, "-var", flag)
+	}
+
+	if ctx.confirmed {
+		arguments = append(arguments, "-yes-i-mean-production")
+	}
+
+	arguments = append(arguments, "run")
+	args, _ := target.command("deployctl", append(arguments, child.Scripts...), "", nil)
+
+	capture, err := NewCapture(0, false)
+	if err != nil {
+		return err
+	}
+
+	output, err := ctx.effect("run", append([]string{"ssh"}, args...), func() ([]byte, error) {
+		command := exec.CommandContext(scope, "ssh", args...)
+		ctx.graceful(command, models.Duration{})
+		command.Stdout = capture
+		command.Stderr = capture
+
+		err := command.Run()
+		result.Usage = processUsage(command.ProcessState)
+
+		closeErr := capture.Close()
+		if err == nil {
+			err = closeErr
+		}
+
+		return capture.Bytes(), err
+	})
+
+	result.Output = string(output)
+	result.Changed = err == nil
+	return err
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,263 @@
+package deployctl
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	RelayError struct {
+		From string
+		To   string
+		Err  error
+	}
+
+	swarm struct {
+		idle chan *seed
+	}
+
+	seed struct {
+		target *sshTarget
+		path   string
+		known  string
+	}
+
+	relayKey struct {
+		private string
+		public  string
+		comment string
+	}
+)
+
+const relayTimeout = 30 * time.Second
+
+func (err *RelayError) Error() string {
+	return "relay from " + err.From + " to " + err.To + ": " + err.Err.Error()
+}
+
+var ErrRelayHostKeys = errors.New("no SSH host keys found to pin for peer relays")
+
+func (err *RelayError) Unwrap() error {
+	return err.Err
+}
+
+func swarmKey(from string, info os.FileInfo) string {
+	return from + "\x00" + strconv.FormatInt(info.Size(), 10) + "\x00" + info.ModTime().String()
+}
+
+func (ctx *Context) swarm(deploy *models.Deploy, key string) *swarm {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.swarms == nil {
+		ctx.swarms = make(map[string]*swarm)
+	}
+
+	found, ok := ctx.swarms[key]
+	if !ok {
+		found = &swarm{idle: make(chan *seed, len(deploy.Remotes.Remotes)+1)}
+		found.idle <- new(seed)
+		ctx.swarms[key] = found
+	}
+
+	return found
+}
+
+func (swarm *swarm) release(seed *seed) {
+	select {
+	case swarm.idle <- seed:
+	default:
+	}
+}
+
+func (ctx *Context) distribute(scope context.Context, deploy *models.Deploy, target *sshTarget, key, source, partial string) (release func(path string), err error) {
+	swarm := ctx.swarm(deploy, key)
+
+	for {
+		serving := (*seed)(nil)
+
+		select {
+		case serving = <-swarm.idle:
+		case <-scope.Done():
+			return nil, scope.Err()
+		}
+
+		if serving.target == nil {
+			err = ctx.sftp(scope, target, []string{"put -rp " + sftpQuote(source) + " " + sftpQuote(partial)})
+		} else {
+			err = ctx.relay(scope, serving, target, partial)
+		}
+
+		if err == nil {
+			return ctx.returner(swarm, serving, target), nil
+		}
+
+		if serving.target == nil || scope.Err() != nil {
+			swarm.release(serving)
+			return nil, err
+		}
+
+		ctx.warn(&RelayError{From: serving.target.host, To: target.host, Err: err})
+	}
+}
+
+func (ctx *Context) returner(swarm *swarm, serving *seed, target *sshTarget) func(path string) {
+	once := new(sync.Once)
+
+	return func(path string) {
+		once.Do(func() {
+			if path != "" {
+				known, err := ctx.authorizeRelay(target)
+				if err != nil {
+					ctx.warn(&RelayError{From: target.host, To: "peers", Err: err})
+				} else {
+					swarm.release(&seed{target: target, path: path, known: known})
+				}
+			}
+
+			swarm.release(serving)
+		})
+	}
+}
+
+func (ctx *Context) relayKey() (*relayKey, error) {
+	control, err := ctx.sshControl()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.relayed != nil {
+		return ctx.relayed, nil
+	}
+
+	random := make([]byte, 8)
+
+	_, err = rand.Read(random)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &relayKey{private: filepath.Join(control, "relay"), comment: "deployctl-relay-" + hex.EncodeToString(random)}
+
+	public, err := ctx.effect("ssh-keygen", []string{"ssh-keygen", "-t", "ed25519", "-f", key.private}, func() ([]byte, error) {
+		output, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", key.comment, "-f", key.private).CombinedOutput()
+		if err != nil {
+			return nil, fmt.Errorf("ssh-keygen: %w: %s", err, bytes.TrimSpace(output))
+		}
+
+		return os.ReadFile(key.private + ".pub")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	key.public = strings.TrimSpace(string(public))
+	ctx.relayed = key
+
+	return key, nil
+}
+
+func (ctx *Context) authorizeRelay(target *sshTarget) (known string, err error) {
+	key, err := ctx.relayKey()
+	if err != nil {
+		return "", err
+	}
+
+	scope, cancel := context.WithTimeout(context.Background(), relayTimeout)
+	defer cancel()
+
+	output, err := ctx.sshShell(scope, target, "umask 077 && mkdir -p ~/.ssh && printf '%s\\n' "+shellQuote("restrict "+key.public)+" >> ~/.ssh/authorized_keys && cat /etc/ssh/ssh_host_*_key.pub")
+	if err != nil {
+		return "", err
+	}
+
+	ctx.mutex.Lock()
+	ctx.relays = append(ctx.relays, target)
+	ctx.mutex.Unlock()
+
+	host := target.remote.IPv4
+	if target.remote.Port > 0 && target.remote.Port != 22 {
+		host = "[" + host + "]:" + strconv.Itoa(target.remote.Port)
+	}
+
+	lines := []string(nil)
+
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 {
+			lines = append(lines, host+" "+fields[0]+" "+fields[1])
+		}
+	}
+
+	if len(lines) == 0 {
+		return "", ErrRelayHostKeys
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func (ctx *Context) relay(scope context.Context, serving *seed, target *sshTarget, partial string) error {
+	key, err := ctx.relayKey()
+	if err != nil {
+		return err
+	}
+
+	private, err := os.ReadFile(key.private)
+	if err != nil {
+		return err
+	}
+
+	_, err = ctx.sshInput(scope, target, relayCommand(serving, partial), private)
+	return err
+}
+
+func (ctx *Context) revokeRelays() {
+	ctx.mutex.Lock()
+	relays, key := ctx.relays, ctx.relayed
+	ctx.relays = nil
+	ctx.mutex.Unlock()
+
+	for _, target := range relays {
+		scope, cancel := context.WithTimeout(context.Background(), relayTimeout)
+
+		_, err := ctx.sshShell(scope, target, "grep -v -F "+shellQuote(key.comment)+" ~/.ssh/authorized_keys > ~/.ssh/authorized_keys.deployctl; cat ~/.ssh/authorized_keys.deployctl > ~/.ssh/authorized_keys && rm -f ~/.ssh/authorized_keys.deployctl")
+		if err != nil {
+			ctx.warn(&RelayError{From: target.host, To: "peers", Err: err})
+		}
+
+		cancel()
+	}
+}
+
+func relayCommand(seed *seed, partial string) string {
+	words := []string{"scp", "-rpq", "-i", `"$relay/key"`, "-o", "IdentitiesOnly=yes", "-o", "BatchMode=yes", "-o", "StrictHostKeyChecking=yes", "-o", `UserKnownHostsFile="$relay/known_hosts"`}
+
+	if seed.target.remote.Port > 0 {
+		words = append(words, "-P", strconv.Itoa(seed.target.remote.Port))
+	}
+
+	source := seed.target.remote.IPv4 + ":" + seed.path
+	if seed.target.remote.User != "" {
+		source = seed.target.remote.User + "@" + source
+	}
+
+	words = append(words, shellQuote(source), shellQuote(partial))
+
+	return "relay=$(mktemp -d) && trap 'rm -rf \"$relay\"' EXIT && umask 077 && cat > \"$relay/key\" && printf '%s\\n' " + shellQuote(seed.known) + " > \"$relay/known_hosts\" && rm -rf " + shellQuote(partial) + " && " + strings.Join(words, " ")
+}
@@ -0,0 +1,449 @@
+package deployctl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	StatusError struct {
+		URL    string
+		Status string
+		Code   int
+	}
+
+	ChecksumError struct {
+		Source   string
+		Expected string
+		Actual   string
+	}
+
+	chunk struct {
+		start     int64
+		end       int64
+		written   int64
+		validator string
+	}
+)
+
+const (
+	downloadAttempts     = 3
+	downloadRetryDelay   = time.Second
+	downloadMinChunkSize = 8 << 20
+)
+
+var ErrResumeRejected = errors.New("resource changed since the interrupted download")
+
+func (err *StatusError) Error() string {
+	return err.URL + ": unexpected status " + err.Status
+}
+
+func (err *ChecksumError) Error() string {
+	return err.Source + ": sha256 " + err.Actual + " does not match " + err.Expected
+}
+
+func (ctx *Context) download(scope context.Context, deploy *models.Deploy, download *models.ScriptDownload, result *Result) error {
+	to := downloadDestination(deploy, download)
+
+	previous, err := statFile(to)
+	if err != nil {
+		return err
+	}
+
+	if download.SHA256 != "" && download.IfExists != models.IfExistsFail && strings.EqualFold(previous.sum, download.SHA256) {
+		result.artifact = to
+		return nil
+	}
+
+	skip, err := ctx.prepareDestination(to, download.IfExists, result)
+	if err != nil || skip {
+		result.artifact = to
+		return err
+	}
+
+	client, err := ctx.httpClient(deploy)
+	if err != nil {
+		return err
+	}
+
+	scope, cancel := downloadScope(scope, download)
+	defer cancel()
+
+	_, err = ctx.effect("download", []string{download.URL, to}, func() ([]byte, error) {
+		if cache, ok := ctx.cached(download); deploy.Prefetch && ok {
+			info, err := os.Stat(cache)
+			if err != nil {
+				return nil, err
+			}
+
+			err = result.quota.reserve(info.Size())
+			if err != nil {
+				return nil, err
+			}
+
+			_, err = copyRegular(scope, cache, to, 0o644)
+			return nil, err
+		}
+
+		return nil, ctx.retrieve(scope, deploy, client, download, to, result.quota)
+	})
+
+	result.artifact = to
+	if err != nil {
+		return err
+	}
+
+	current, err := statFile(to)
+	if err != nil {
+		return err
+	}
+
+	result.Usage.Written = current.size
+	result.Changed = current != previous || download.IfExists == models.IfExistsBackup
+	return nil
+}
+
+func downloadScope(scope context.Context, download *models.ScriptDownload) (context.Context, context.CancelFunc) {
+	if download.Timeout.Duration <= 0 {
+		return context.WithCancel(scope)
+	}
+
+	return context.WithTimeout(scope, download.Timeout.Duration)
+}
+
+func downloadDestination(deploy *models.Deploy, download *models.ScriptDownload) string {
+	name := "download"
+
+	parsed, err := url.Parse(download.URL)
+	if err == nil && path.Base(parsed.Path) != "/" && path.Base(parsed.Path) != "." {
+		name = path.Base(parsed.Path)
+	}
+
+	return destination(deploy.Folder, name, download.To, false)
+}
+
+func planDownload(deploy *models.Deploy, download *models.ScriptDownload) (plan []string, err error) {
+	_, err = url.ParseRequestURI(download.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	to := downloadDestination(deploy, download)
+
+	plan, skip, err := planDestination(to, download.IfExists)
+	if err != nil || skip {
+		return plan, err
+	}
+
+	return append(plan, "download "+download.URL+" to "+to), nil
+}
+
+func fetch(scope context.Context, client *http.Client, download *models.ScriptDownload, to string, quota *quota) error {
+	size, ranges, validator, err := probe(scope, client, download)
+	if err != nil {
+		return err
+	}
+
+	if size >= 0 {
+		err = quota.reserve(size)
+		if err != nil {
+			return err
+		}
+	}
+
+	partial := to + ".part"
+
+	file, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	connections := max(download.Connections, 1)
+	if !ranges || size < 0 {
+		connections = 1
+	} else if chunks := size / downloadMinChunkSize; chunks < int64(connections) {
+		connections = int(max(chunks, 1))
+	}
+
+	if connections == 1 {
+		offset := resumeOffset(file, partial, size, ranges, validator)
+		if offset == 0 {
+			err = file.Truncate(0)
+		}
+
+		if err == nil {
+			err = storeValidator(partial, validator)
+		}
+
+		if err == nil {
+			err = fetchChunk(scope, client, download, file, &chunk{start: 0, written: offset, end: size - 1, validator: validator}, ranges)
+		}
+
+		if errors.Is(err, ErrResumeRejected) {
+			err = file.Truncate(0)
+			if err == nil {
+				err = fetchChunk(scope, client, download, file, &chunk{start: 0, end: size - 1, validator: validator}, ranges)
+			}
+		}
+	} else {
+		err = storeValidator(partial, "")
+		if err == nil {
+			err = fetchParallel(scope, client, download, file, size, connections, validator)
+		}
+	}
+
+	closeErr := file.Close()
+	if err != nil {
+		return err
+	}
+
+	os.Remove(partial + ".validator")
+
+	if closeErr != nil {
+		return closeErr
+	}
+
+	if size < 0 {
+		info, err := os.Stat(partial)
+		if err != nil {
+			return err
+		}
+
+		err = quota.reserve(info.Size())
+		if err != nil {
+			os.Remove(partial)
+			return err
+		}
+	}
+
+	err = verify(download, partial)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(partial, to)
+}
+
+func resumeOffset(file *os.File, partial string, size int64, ranges bool, validator string) int64 {
+	if !ranges || validator == "" {
+		return 0
+	}
+
+	stored, err := os.ReadFile(partial + ".validator")
+	if err != nil || strings.TrimSpace(string(stored)) != validator {
+		return 0
+	}
+
+	info, err := file.Stat()
+	if err != nil || size >= 0 && info.Size() > size {
+		return 0
+	}
+
+	return info.Size()
+}
+
+func storeValidator(partial, validator string) error {
+	if validator == "" {
+		err := os.Remove(partial + ".validator")
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	return os.WriteFile(partial+".validator", []byte(validator+"\n"), 0o644)
+}
+
+func verify(download *models.ScriptDownload, path string) error {
+	if download.SHA256 == "" {
+		return nil
+	}
+
+	sum, err := checksumFile(path)
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(sum, download.SHA256) {
+		os.Remove(path)
+		return &ChecksumError{Source: download.URL, Expected: download.SHA256, Actual: sum}
+	}
+
+	return nil
+}
+
+func request(scope context.Context, method string, download *models.ScriptDownload) (*http.Request, error) {
+	request, err := http.NewRequestWithContext(scope, method, download.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range download.Headers {
+		request.Header.Set(key, value)
+	}
+
+	return request, nil
+}
+
+func probe(scope context.Context, client *http.Client, download *models.ScriptDownload) (size int64, ranges bool, validator string, err error) {
+	head, err := request(scope, http.MethodHead, download)
+	if err != nil {
+		return 0, false, "", err
+	}
+
+	response, err := client.Do(head)
+	if err != nil {
+		return 0, false, "", err
+	}
+	response.Body.Close()
+
+	if response.StatusCode >= 400 {
+		return -1, false, "", nil
+	}
+
+	validator = response.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = response.Header.Get("Last-Modified")
+	}
+
+	return response.ContentLength, response.Header.Get("Accept-Ranges") == "bytes", validator, nil
+}
+
+func fetchParallel(scope context.Context, client *http.Client, download *models.ScriptDownload, file *os.File, size int64, connections int, validator string) error {
+	err := file.Truncate(size)
+	if err != nil {
+		return err
+	}
+
+	step := (size + int64(connections) - 1) / int64(connections)
+	chunks := make([]*chunk, 0, connections)
+
+	for start := int64(0); start < size; start += step {
+		chunks = append(chunks, &chunk{start: start, end: min(start+step, size) - 1, validator: validator})
+	}
+
+	group := new(sync.WaitGroup)
+	errs := make([]error, len(chunks))
+
+	for i, part := range chunks {
+		group.Add(1)
+
+		go func(i int, part *chunk) {
+			defer group.Done()
+			errs[i] = fetchChunk(scope, client, download, file, part, true)
+		}(i, part)
+	}
+
+	group.Wait()
+	return errors.Join(errs...)
+}
+
+func fetchChunk(scope context.Context, client *http.Client, download *models.ScriptDownload, file *os.File, part *chunk, ranges bool) error {
+	err := error(nil)
+
+	attempts := downloadAttempts
+	if download.Retries > 0 {
+		attempts = download.Retries + 1
+	}
+
+	for attempt := 0; attempt < attempts; attempt++ {
+		if !ranges {
+			part.written = 0
+
+			err = file.Truncate(0)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = fetchRange(scope, client, download, file, part, ranges)
+		if err == nil {
+			return nil
+		}
+
+		var status *StatusError
+		if errors.As(err, &status) && status.Code < http.StatusInternalServerError || errors.Is(err, ErrResumeRejected) || scope.Err() != nil {
+			return err
+		}
+
+		if attempt+1 < attempts {
+			select {
+			case <-time.After(downloadRetryDelay << attempt):
+			case <-scope.Done():
+				return scope.Err()
+			}
+		}
+	}
+
+	return err
+}
+
+func fetchRange(scope context.Context, client *http.Client, download *models.ScriptDownload, file *os.File, part *chunk, ranges bool) error {
+	get, err := request(scope, http.MethodGet, download)
+	if err != nil {
+		return err
+	}
+
+	offset := part.start + part.written
+
+	if ranges {
+		if part.end >= 0 && offset > part.end {
+			return nil
+		}
+
+		value := "bytes=" + strconv.FormatInt(offset, 10) + "-"
+		if part.end >= 0 {
+			value += strconv.FormatInt(part.end, 10)
+		}
+
+		get.Header.Set("Range", value)
+
+		if offset > 0 && part.validator != "" {
+			get.Header.Set("If-Range", part.validator)
+		}
+	}
+
+	response, err := client.Do(get)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	switch {
+	case response.StatusCode == http.StatusPartialContent:
+	case response.StatusCode == http.StatusOK && offset == 0:
+	case response.StatusCode == http.StatusOK && part.validator != "":
+		return ErrResumeRejected
+	case response.StatusCode == http.StatusRequestedRangeNotSatisfiable && part.end < 0:
+		return nil
+	default:
+		return &StatusError{URL: download.URL, Status: response.Status, Code: response.StatusCode}
+	}
+
+	writer := io.NewOffsetWriter(file, offset)
+
+	n, err := io.Copy(writer, &scopedReader{scope: scope, reader: response.Body})
+	part.written += n
+	if err != nil {
+		return err
+	}
+
+	if part.end >= 0 && part.start+part.written != part.end+1 {
+		return fmt.Errorf("%s: short read %d of %d bytes", download.URL, part.written, part.end+1-part.start)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package deployctl
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+func TestFetchResume(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	ranges := []string(nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Method == http.MethodGet {
+			ranges = append(ranges, request.Header.Get("Range"))
+		}
+
+		writer.Header().Set("ETag", `"current"`)
+		http.ServeContent(writer, request, "artifact", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		partial   []byte
+		validator string
+		ranged    bool
+	}{
+		{name: "no partial", ranged: false},
+		{name: "validated partial", partial: content[:4000], validator: `"current"`, ranged: true},
+		{name: "stale partial", partial: bytes.Repeat([]byte("x"), 4000), validator: `"previous"`, ranged: false},
+		{name: "unvalidated partial", partial: bytes.Repeat([]byte("x"), 4000), ranged: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ranges = nil
+			to := filepath.Join(t.TempDir(), "artifact")
+
+			if test.partial != nil {
+				err := os.WriteFile(to+".part", test.partial, 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if test.validator != "" {
+				err := os.WriteFile(to+".part.validator", []byte(test.validator+"\n"), 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := fetch(context.Background(), server.Client(), &models.ScriptDownload{URL: server.URL + "/artifact"}, to, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(to)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(data, content) {
+				t.Errorf("downloaded %d bytes do not match the %d bytes served", len(data), len(content))
+			}
+
+			if ranged := len(ranges) > 0 && strings.HasPrefix(ranges[0], "bytes=4000-"); ranged != test.ranged {
+				t.Errorf("requested ranges %q, expected resuming %t", ranges, test.ranged)
+			}
+
+			_, err = os.Stat(to + ".part.validator")
+			if !os.IsNotExist(err) {
+				t.Errorf("validator of the partial download was left behind: %v", err)
+			}
+		})
+	}
+}
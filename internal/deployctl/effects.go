@@ -0,0 +1,174 @@
+package deployctl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	Effects interface {
+		Do(effect string, args []string, run func() ([]byte, error)) ([]byte, error)
+	}
+
+	SystemEffects struct{}
+
+	Recorder struct {
+		mutex    sync.Mutex
+		inner    Effects
+		fixtures models.Fixtures
+		redactor redactor
+	}
+
+	Replayer struct {
+		mutex    sync.Mutex
+		fixtures []*models.Fixture
+		redactor redactor
+	}
+
+	redactingEffects interface {
+		addRedactor(redactor redactor)
+	}
+
+	ReplayError struct {
+		Effect string
+		Args   []string
+		Reason string
+	}
+)
+
+func (err *ReplayError) Error() string {
+	return fmt.Sprintf("replay %s %q: %s", err.Effect, err.Args, err.Reason)
+}
+
+func (ctx *Context) UseEffects(effects Effects) {
+	ctx.effects = effects
+}
+
+const secretEffect = "secret"
+
+func (ctx *Context) redactEffects(redactor redactor) {
+	if effects, ok := ctx.effects.(redactingEffects); ok {
+		effects.addRedactor(redactor)
+	}
+}
+
+func (ctx *Context) effect(effect string, args []string, run func() ([]byte, error)) ([]byte, error) {
+	if ctx.effects == nil {
+		return run()
+	}
+
+	return ctx.effects.Do(effect, args, run)
+}
+
+func (SystemEffects) Do(effect string, args []string, run func() ([]byte, error)) ([]byte, error) {
+	return run()
+}
+
+func NewRecorder(inner Effects) *Recorder {
+	return &Recorder{inner: inner}
+}
+
+func (recorder *Recorder) Do(effect string, args []string, run func() ([]byte, error)) ([]byte, error) {
+	output, err := recorder.inner.Do(effect, args, run)
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	fixture := &models.Fixture{
+		Effect: effect,
+		Args:   recorder.redactor.args(args),
+		Output: output,
+	}
+
+	switch {
+	case effect == secretEffect && len(output) > 0:
+		fixture.Output = []byte(redacted)
+	case len(output) > 0:
+		fixture.Output = []byte(recorder.redactor.redact(string(output)))
+	}
+
+	if err != nil {
+		fixture.Error = recorder.redactor.redact(err.Error())
+	}
+
+	recorder.fixtures.Fixtures = append(recorder.fixtures.Fixtures, fixture)
+
+	return output, err
+}
+
+func (recorder *Recorder) addRedactor(redactor redactor) {
+	recorder.mutex.Lock()
+	recorder.redactor = append(recorder.redactor, redactor...)
+	recorder.mutex.Unlock()
+}
+
+func (recorder *Recorder) Save(path string) error {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	data, err := sonic.ConfigDefault.MarshalIndent(&recorder.fixtures, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+func LoadReplayer(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fixtures := new(models.Fixtures)
+
+	err = sonic.Unmarshal(data, fixtures)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Replayer{fixtures: fixtures.Fixtures}, nil
+}
+
+func (replayer *Replayer) Do(effect string, args []string, run func() ([]byte, error)) ([]byte, error) {
+	replayer.mutex.Lock()
+	defer replayer.mutex.Unlock()
+
+	redactedArgs := replayer.redactor.args(args)
+
+	for i, fixture := range replayer.fixtures {
+		if fixture.Effect != effect || !slices.Equal(fixture.Args, redactedArgs) {
+			continue
+		}
+
+		replayer.fixtures = slices.Delete(replayer.fixtures, i, i+1)
+
+		if fixture.Error != "" {
+			return fixture.Output, errors.New(fixture.Error)
+		}
+
+		return fixture.Output, nil
+	}
+
+	return nil, &ReplayError{Effect: effect, Args: args, Reason: "no recorded fixture"}
+}
+
+func (replayer *Replayer) addRedactor(redactor redactor) {
+	replayer.mutex.Lock()
+	replayer.redactor = append(replayer.redactor, redactor...)
+	replayer.mutex.Unlock()
+}
+
+func (replayer *Replayer) Remaining() int {
+	replayer.mutex.Lock()
+	defer replayer.mutex.Unlock()
+
+	return len(replayer.fixtures)
+}
@@ -0,0 +1,93 @@
+package deployctl
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRecorderConcurrent(t *testing.T) {
+	tests := []struct {
+		name   string
+		effect string
+		args   []string
+		output string
+		err    error
+		stored string
+	}{
+		{name: "run", effect: "run", args: []string{"echo", "hello"}, output: "hello\n", stored: "hello\n"},
+		{name: "failed", effect: "run", args: []string{"false"}, err: errors.New("exit status 1")},
+		{name: "secret", effect: secretEffect, args: []string{"TOKEN", "vault"}, output: "hunter2", stored: redacted},
+		{name: "redacted", effect: "ssh", args: []string{"ssh", "password=hunter2"}, output: "password=hunter2", stored: "password=" + redacted},
+	}
+
+	redactor, err := newRedactor([]string{`password=(\S+)`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := NewRecorder(SystemEffects{})
+	recorder.addRedactor(redactor)
+
+	group := sync.WaitGroup{}
+
+	for i := range 8 {
+		group.Add(1)
+
+		go func() {
+			defer group.Done()
+			recorder.addRedactor(redactor)
+		}()
+
+		for _, test := range tests {
+			group.Add(1)
+
+			go func() {
+				defer group.Done()
+
+				output, err := recorder.Do(test.effect, slices.Concat(test.args, []string{strconv.Itoa(i)}), func() ([]byte, error) {
+					return []byte(test.output), test.err
+				})
+
+				if string(output) != test.output || !errors.Is(err, test.err) {
+					t.Errorf("%s returned %q, %v", test.name, output, err)
+				}
+			}()
+		}
+	}
+
+	group.Wait()
+
+	err = recorder.Save(filepath.Join(t.TempDir(), "fixtures.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(recorder.fixtures.Fixtures) != 8*len(tests) {
+		t.Fatalf("recorded %d fixtures, expected %d", len(recorder.fixtures.Fixtures), 8*len(tests))
+	}
+
+	for _, fixture := range recorder.fixtures.Fixtures {
+		for _, test := range tests {
+			if fixture.Effect != test.effect || fixture.Args[0] != test.args[0] {
+				continue
+			}
+
+			if string(fixture.Output) != test.stored {
+				t.Errorf("%s stored output %q, expected %q", test.name, fixture.Output, test.stored)
+			}
+
+			if (fixture.Error != "") != (test.err != nil) {
+				t.Errorf("%s stored error %q, expected %v", test.name, fixture.Error, test.err)
+			}
+
+			if strings.Contains(strings.Join(fixture.Args, " "), "hunter2") {
+				t.Errorf("%s stored unredacted arguments %q", test.name, fixture.Args)
+			}
+		}
+	}
+}
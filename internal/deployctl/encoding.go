@@ -0,0 +1,64 @@
+package deployctl
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+var cp1251 = [64]rune{
+	0x0402, 0x0403, 0x201A, 0x0453, 0x201E, 0x2026, 0x2020, 0x2021, 0x20AC, 0x2030, 0x0409, 0x2039, 0x040A, 0x040C, 0x040B, 0x040F,
+	0x0452, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014, 0xFFFD, 0x2122, 0x0459, 0x203A, 0x045A, 0x045C, 0x045B, 0x045F,
+	0x00A0, 0x040E, 0x045E, 0x0408, 0x00A4, 0x0490, 0x00A6, 0x00A7, 0x0401, 0x00A9, 0x0404, 0x00AB, 0x00AC, 0x00AD, 0x00AE, 0x0407,
+	0x00B0, 0x00B1, 0x0406, 0x0456, 0x0491, 0x00B5, 0x00B6, 0x00B7, 0x0451, 0x2116, 0x0454, 0x00BB, 0x0458, 0x0405, 0x0455, 0x0457,
+}
+
+func decode(encoding models.Encoding, data []byte) string {
+	switch encoding {
+	case models.EncodingLatin1, models.EncodingCP1251:
+		builder := strings.Builder{}
+		builder.Grow(len(data))
+
+		for _, char := range data {
+			switch {
+			case char < 0x80 || encoding == models.EncodingLatin1:
+				builder.WriteRune(rune(char))
+			case char < 0xC0:
+				builder.WriteRune(cp1251[char-0x80])
+			default:
+				builder.WriteRune(0x0410 + rune(char-0xC0))
+			}
+		}
+
+		return builder.String()
+	}
+
+	if utf8.Valid(data) {
+		return string(data)
+	}
+
+	return escapeInvalid(data)
+}
+
+func escapeInvalid(data []byte) string {
+	const hex = "0123456789abcdef"
+
+	builder := strings.Builder{}
+	builder.Grow(len(data))
+
+	for len(data) > 0 {
+		char, size := utf8.DecodeRune(data)
+		if char == utf8.RuneError && size == 1 {
+			builder.WriteString(`\x`)
+			builder.WriteByte(hex[data[0]>>4])
+			builder.WriteByte(hex[data[0]&0x0F])
+		} else {
+			builder.Write(data[:size])
+		}
+
+		data = data[size:]
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,76 @@
+package deployctl
+
+import (
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+const EstimateRuns = 5
+
+func Durations(runs []*models.Run, recent int) map[string]time.Duration {
+	samples := make(map[string][]time.Duration)
+
+	for i := len(runs) - 1; i >= 0; i-- {
+		for _, result := range runs[i].Results {
+			if result.Skipped || result.Outcome == string(OutcomeUnreachable) {
+				continue
+			}
+
+			for _, key := range []string{result.Label(), result.Name} {
+				if len(samples[key]) < recent {
+					samples[key] = append(samples[key], result.Duration)
+				}
+			}
+		}
+	}
+
+	durations := make(map[string]time.Duration, len(samples))
+	for key, values := range samples {
+		sum := time.Duration(0)
+		for _, value := range values {
+			sum += value
+		}
+
+		durations[key] = sum / time.Duration(len(values))
+	}
+
+	return durations
+}
+
+func Estimate(deploy *models.Deploy, durations map[string]time.Duration, labels []string) time.Duration {
+	names, only := parseSelection(labels)
+	if len(names) == 0 {
+		names = deploy.Order()
+	}
+
+	hosts, err := deploy.Hosts()
+	if err != nil || len(hosts) == 0 {
+		hosts = []string{""}
+	}
+
+	total := time.Duration(0)
+
+	for _, name := range names {
+		script := deploy.Scripts.Scripts[name]
+		if script == nil {
+			continue
+		}
+
+		for i, host := range hosts {
+			if (script.RunOnce && i > 0) || !only.allows(name, host) {
+				continue
+			}
+
+			duration, ok := durations[models.Label(name, host)]
+			if !ok {
+				duration = durations[name]
+			}
+
+			total += duration
+		}
+	}
+
+	waves := len(waves(hosts, deploy.Rollout.Serial.Size(len(hosts))))
+	return total + time.Duration(waves-1)*deploy.Rollout.Pause.Duration
+}
@@ -0,0 +1,141 @@
+package deployctl
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	Event struct {
+		Type        models.EventType
+		Time        time.Time
+		Environment string
+		Stage       string
+		Script      string   `json:",omitempty"`
+		Host        string   `json:",omitempty"`
+		Scripts     []string `json:",omitempty"`
+		Summary     *Summary `json:",omitempty"`
+		Error       string   `json:",omitempty"`
+	}
+
+	Summary struct {
+		Changed     int
+		Unchanged   int
+		Failed      int
+		Skipped     int
+		Unreachable int
+		RolledBack  int
+	}
+
+	EventError struct {
+		URL    string
+		Type   models.EventType
+		Err    error
+		Status string
+	}
+
+	Warner func(err error)
+)
+
+const eventTimeout = 10 * time.Second
+
+func (err *EventError) Error() string {
+	if err.Err != nil {
+		return "event " + string(err.Type) + " to " + err.URL + ": " + err.Err.Error()
+	}
+
+	return "event " + string(err.Type) + " to " + err.URL + ": unexpected status " + err.Status
+}
+
+func (err *EventError) Unwrap() error {
+	return err.Err
+}
+
+func (ctx *Context) OnWarning(warner Warner) {
+	ctx.warner = warner
+}
+
+func (ctx *Context) warn(err error) {
+	if ctx.warner != nil {
+		ctx.warner(err)
+	}
+}
+
+func (report *Report) Summary() *Summary {
+	return &Summary{
+		Changed:     report.Count(OutcomeChanged),
+		Unchanged:   report.Count(OutcomeUnchanged),
+		Failed:      report.Count(OutcomeFailed),
+		Skipped:     report.Count(OutcomeSkipped),
+		Unreachable: report.Count(OutcomeUnreachable),
+		RolledBack:  report.Count(OutcomeRolledBack),
+	}
+}
+
+func (ctx *Context) emit(deploy *models.Deploy, event *Event) {
+	if len(deploy.Events) == 0 || ctx.dryRun {
+		return
+	}
+
+	event.Time = time.Now()
+	event.Environment = deploy.Target.Environment
+	event.Stage = deploy.Target.Stage
+
+	data, err := sonic.Marshal(event)
+	if err != nil {
+		ctx.warn(&EventError{Type: event.Type, Err: err})
+		return
+	}
+
+	for _, sink := range deploy.Events {
+		if !sink.Wants(event.Type) {
+			continue
+		}
+
+		_, err = ctx.effect("event", []string{sink.URL, string(event.Type)}, func() ([]byte, error) {
+			return nil, ctx.post(deploy, sink, event.Type, data)
+		})
+		if err != nil {
+			ctx.warn(err)
+		}
+	}
+}
+
+func (ctx *Context) post(deploy *models.Deploy, sink *models.EventSink, event models.EventType, data []byte) error {
+	client, err := ctx.httpClient(deploy)
+	if err != nil {
+		return &EventError{URL: sink.URL, Type: event, Err: err}
+	}
+
+	scope, cancel := context.WithTimeout(context.WithoutCancel(ctx), eventTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(scope, http.MethodPost, sink.URL, bytes.NewReader(data))
+	if err != nil {
+		return &EventError{URL: sink.URL, Type: event, Err: err}
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	for key, value := range sink.Headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return &EventError{URL: sink.URL, Type: event, Err: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return &EventError{URL: sink.URL, Type: event, Status: response.Status}
+	}
+
+	return nil
+}
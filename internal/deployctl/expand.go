@@ -0,0 +1,121 @@
+package deployctl
+
+import (
+	"reflect"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+var literalFields = map[string]bool{
+	"Follow":      true,
+	"Phase":       true,
+	"When":        true,
+	"DelegateTo":  true,
+	"Connection":  true,
+	"Register":    true,
+	"Environment": true,
+	"Content":     true,
+}
+
+func ExpandScript(script *models.Script, resolver *variables.Resolver) (*models.Script, error) {
+	expanded := reflect.New(reflect.TypeOf(*script))
+	expanded.Elem().Set(reflect.ValueOf(script).Elem())
+
+	err := expandValue(expanded.Elem(), resolver)
+	if err != nil {
+		return nil, err
+	}
+
+	return expanded.Interface().(*models.Script), nil
+}
+
+func (run *execution) view(host string, resolver *variables.Resolver) (*models.Deploy, error) {
+	if run.deploy.Folder == "" {
+		return run.deploy, nil
+	}
+
+	folder, err := resolver.Expand(run.deploy.Folder)
+	if err != nil || folder == run.deploy.Folder {
+		return run.deploy, err
+	}
+
+	view := *run.deploy
+	view.Folder = folder
+
+	return &view, nil
+}
+
+func expandValue(value reflect.Value, resolver *variables.Resolver) error {
+	switch value.Kind() {
+	case reflect.String:
+		expanded, err := resolver.Expand(value.String())
+		if err != nil {
+			return err
+		}
+
+		value.SetString(expanded)
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if !field.IsExported() || literalFields[field.Name] {
+				continue
+			}
+
+			err := expandValue(value.Field(i), resolver)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Pointer:
+		if value.IsNil() {
+			return nil
+		}
+
+		copied := reflect.New(value.Type().Elem())
+		copied.Elem().Set(value.Elem())
+
+		err := expandValue(copied.Elem(), resolver)
+		if err != nil {
+			return err
+		}
+
+		value.Set(copied)
+	case reflect.Slice:
+		if kind := value.Type().Elem().Kind(); value.IsNil() || kind != reflect.String && kind != reflect.Pointer {
+			return nil
+		}
+
+		copied := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		reflect.Copy(copied, value)
+
+		for i := 0; i < copied.Len(); i++ {
+			err := expandValue(copied.Index(i), resolver)
+			if err != nil {
+				return err
+			}
+		}
+
+		value.Set(copied)
+	case reflect.Map:
+		if value.IsNil() || value.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+
+		copied := reflect.MakeMapWithSize(value.Type(), value.Len())
+		iterator := value.MapRange()
+
+		for iterator.Next() {
+			expanded, err := resolver.Expand(iterator.Value().String())
+			if err != nil {
+				return err
+			}
+
+			copied.SetMapIndex(iterator.Key(), reflect.ValueOf(expanded).Convert(value.Type().Elem()))
+		}
+
+		value.Set(copied)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package deployctl
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+func TestExpandScript(t *testing.T) {
+	resolver := variables.NewResolver()
+	resolver.Set(variables.SourceConfig, "TARGET", "/opt/app")
+
+	script := &models.Script{
+		Environment: models.Environment{"HOME_DIR": "$$HOME"},
+		Run: &models.ScriptRun{
+			Path: "sh",
+			Args: []string{"-c", "echo arg=$$1 in ${TARGET}", "sh", "$TARGET"},
+		},
+	}
+
+	expanded, err := ExpandScript(script, resolver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"-c", "echo arg=$1 in /opt/app", "sh", "/opt/app"}; !slices.Equal(expanded.Run.Args, expected) {
+		t.Errorf("args %q, expected %q", expanded.Run.Args, expected)
+	}
+
+	if expanded.Environment["HOME_DIR"] != "$$HOME" {
+		t.Errorf("environment was expanded early: %q", expanded.Environment["HOME_DIR"])
+	}
+
+	if script.Run.Args[1] != "echo arg=$$1 in ${TARGET}" {
+		t.Errorf("original script was modified: %q", script.Run.Args[1])
+	}
+}
@@ -0,0 +1,438 @@
+package deployctl
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	UnsafeEntryError struct {
+		Archive string
+		Name    string
+	}
+)
+
+func (err *UnsafeEntryError) Error() string {
+	return err.Archive + ": entry " + err.Name + " escapes the destination"
+}
+
+func (ctx *Context) extract(scope context.Context, deploy *models.Deploy, extract *models.ScriptExtract, result *Result) error {
+	to := extractDestination(deploy, extract)
+
+	skip, err := ctx.prepareDestination(to, extract.IfExists, result)
+	if err != nil || skip {
+		result.artifact = to
+		return err
+	}
+
+	written := int64(0)
+
+	_, err = ctx.effect("extract", []string{extract.From, to}, func() ([]byte, error) {
+		return nil, await(scope, result, func() (err error) {
+			written, err = unpack(scope, extract, to, result)
+			return err
+		})
+	})
+
+	result.Usage.Written = written
+	result.artifact = to
+	result.Changed = result.Changed && err == nil
+	return err
+}
+
+func extractDestination(deploy *models.Deploy, extract *models.ScriptExtract) string {
+	name := filepath.Base(extract.From)
+
+	trimmed := false
+
+	for _, suffix := range []string{".tar.gz", ".tgz", ".tar.zst", ".tzst", ".tar", ".zip"} {
+		if strings.HasSuffix(strings.ToLower(name), suffix) {
+			name, trimmed = name[:len(name)-len(suffix)], true
+			break
+		}
+	}
+
+	if !trimmed {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	return destination(deploy.Folder, name, extract.To, false)
+}
+
+func planExtract(deploy *models.Deploy, extract *models.ScriptExtract) (plan []string, err error) {
+	to := extractDestination(deploy, extract)
+
+	file, err := os.Open(extract.From)
+	if err != nil {
+		return nil, err
+	}
+
+	format, err := sniffArchive(extract, bufio.NewReader(file))
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	plan, skip, err := planDestination(to, extract.IfExists)
+	if err != nil || skip {
+		return plan, err
+	}
+
+	return append(plan, "extract "+extract.From+" as "+string(format)+" to "+to), nil
+}
+
+func sniffArchive(extract *models.ScriptExtract, reader *bufio.Reader) (models.ArchiveFormat, error) {
+	if extract.Format != "" {
+		return extract.Format, nil
+	}
+
+	magic, err := reader.Peek(4)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return models.ArchiveTarGzip, nil
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return models.ArchiveTarZstd, nil
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+		return models.ArchiveZip, nil
+	}
+
+	if format, ok := models.ArchiveFormatOf(extract.From); ok {
+		return format, nil
+	}
+
+	return models.ArchiveTar, nil
+}
+
+func unpack(scope context.Context, extract *models.ScriptExtract, to string, result *Result) (int64, error) {
+	file, err := os.Open(extract.From)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+
+	format, err := sniffArchive(extract, reader)
+	if err != nil {
+		return 0, err
+	}
+
+	err = makeDirectories(to, 0o755, result.directories)
+	if err != nil {
+		return 0, err
+	}
+
+	if format == models.ArchiveZip {
+		return unpackZip(scope, extract, file, to, result)
+	}
+
+	decompressed := io.Reader(reader)
+
+	switch format {
+	case models.ArchiveTarGzip:
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return 0, err
+		}
+		defer gzipReader.Close()
+
+		decompressed = gzipReader
+	case models.ArchiveTarZstd:
+		zstdReader, err := zstd.NewReader(reader)
+		if err != nil {
+			return 0, err
+		}
+		defer zstdReader.Close()
+
+		decompressed = zstdReader
+	}
+
+	return unpackTar(scope, extract, tar.NewReader(decompressed), to, result)
+}
+
+func unpackTar(scope context.Context, extract *models.ScriptExtract, reader *tar.Reader, to string, result *Result) (written int64, err error) {
+	for {
+		err = scope.Err()
+		if err != nil {
+			return written, err
+		}
+
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return written, nil
+		}
+
+		if err != nil {
+			return written, err
+		}
+
+		target, ok, err := entryPath(extract, to, header.Name)
+		if err != nil || !ok {
+			if err != nil {
+				return written, err
+			}
+
+			continue
+		}
+
+		mode := fs.FileMode(header.Mode).Perm()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = extractDirectory(target, mode, result)
+		case tar.TypeReg:
+			n := int64(0)
+			n, err = writeEntry(scope, reader, target, mode, result)
+			written += n
+		case tar.TypeSymlink:
+			err = extractLink(extract, to, target, header.Linkname, result)
+		case tar.TypeLink:
+			source := ""
+			source, ok, err = entryPath(extract, to, header.Linkname)
+			if err == nil && ok {
+				err = extractHardLink(source, target, result)
+			}
+		}
+
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+func unpackZip(scope context.Context, extract *models.ScriptExtract, file *os.File, to string, result *Result) (written int64, err error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	reader, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entry := range reader.File {
+		err = scope.Err()
+		if err != nil {
+			return written, err
+		}
+
+		target, ok, err := entryPath(extract, to, entry.Name)
+		if err != nil {
+			return written, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		mode := entry.Mode()
+
+		switch {
+		case mode.IsDir():
+			err = extractDirectory(target, mode.Perm(), result)
+		case mode&fs.ModeSymlink != 0:
+			err = extractZipLink(extract, to, target, entry, result)
+		default:
+			n := int64(0)
+			n, err = writeZipEntry(scope, entry, target, mode.Perm(), result)
+			written += n
+		}
+
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+func entryPath(extract *models.ScriptExtract, to, name string) (target string, ok bool, err error) {
+	clean := path.Clean(strings.TrimLeft(filepath.ToSlash(name), "/"))
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", false, &UnsafeEntryError{Archive: extract.From, Name: name}
+	}
+
+	strip := max(extract.StripComponents, 0)
+
+	parts := strings.Split(clean, "/")
+	if clean == "." || len(parts) <= strip {
+		return "", false, nil
+	}
+
+	target = filepath.Join(to, filepath.FromSlash(path.Join(parts[strip:]...)))
+
+	if linkedParent(to, target) {
+		return "", false, &UnsafeEntryError{Archive: extract.From, Name: name}
+	}
+
+	return target, true, nil
+}
+
+func linkedParent(to, target string) bool {
+	relative, err := filepath.Rel(to, filepath.Dir(target))
+	if err != nil || relative == "." {
+		return false
+	}
+
+	current := to
+
+	for _, part := range strings.Split(relative, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+
+		info, err := os.Lstat(current)
+		if err != nil {
+			return false
+		}
+
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func extractLink(extract *models.ScriptExtract, to, target, link string, result *Result) error {
+	resolved := link
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(filepath.Dir(target), link)
+	}
+
+	relative, err := filepath.Rel(to, resolved)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) || innerParent(link) {
+		return &UnsafeEntryError{Archive: extract.From, Name: link}
+	}
+
+	err = makeDirectories(filepath.Dir(target), 0o755, result.directories)
+	if err != nil {
+		return err
+	}
+
+	if current, err := os.Readlink(target); err == nil && current == link {
+		return nil
+	}
+
+	os.Remove(target)
+	result.Changed = true
+	return os.Symlink(link, target)
+}
+
+func extractDirectory(target string, mode fs.FileMode, result *Result) error {
+	_, err := os.Lstat(target)
+	if errors.Is(err, os.ErrNotExist) {
+		result.Changed = true
+	}
+
+	return os.MkdirAll(target, max(mode, 0o700))
+}
+
+func extractHardLink(source, target string, result *Result) error {
+	sourceInfo, sourceErr := os.Lstat(source)
+	targetInfo, targetErr := os.Lstat(target)
+	if sourceErr == nil && targetErr == nil && os.SameFile(sourceInfo, targetInfo) {
+		return nil
+	}
+
+	os.Remove(target)
+	result.Changed = true
+	return os.Link(source, target)
+}
+
+func innerParent(link string) bool {
+	leading := !filepath.IsAbs(link)
+
+	for _, part := range strings.Split(filepath.ToSlash(link), "/") {
+		switch {
+		case part == "..":
+			if !leading {
+				return true
+			}
+		case part != "" && part != ".":
+			leading = false
+		}
+	}
+
+	return false
+}
+
+func extractZipLink(extract *models.ScriptExtract, to, target string, entry *zip.File, result *Result) error {
+	reader, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	link, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	return extractLink(extract, to, target, string(link), result)
+}
+
+func writeZipEntry(scope context.Context, entry *zip.File, target string, mode fs.FileMode, result *Result) (int64, error) {
+	reader, err := entry.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	return writeEntry(scope, reader, target, mode, result)
+}
+
+func writeEntry(scope context.Context, reader io.Reader, target string, mode fs.FileMode, result *Result) (int64, error) {
+	err := makeDirectories(filepath.Dir(target), 0o755, result.directories)
+	if err != nil {
+		return 0, err
+	}
+
+	previous, err := statFile(target)
+	if err != nil {
+		return 0, err
+	}
+
+	os.Remove(target)
+
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return 0, err
+	}
+
+	written, err := io.Copy(result.quota.writer(file), &scopedReader{scope: scope, reader: reader})
+
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return written, err
+	}
+
+	current, err := statFile(target)
+	if current != previous {
+		result.Changed = true
+	}
+
+	return written, err
+}
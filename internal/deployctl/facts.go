@@ -0,0 +1,189 @@
+package deployctl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+type (
+	FactsError struct {
+		Host string
+		Err  error
+	}
+)
+
+const (
+	factsPrefix = "facts."
+
+	factsCommand = `printf 'os=%s\n' "$(uname -s | tr '[:upper:]' '[:lower:]')"
+case "$(uname -m)" in x86_64) arch=amd64 ;; aarch64) arch=arm64 ;; i?86) arch=386 ;; *) arch=$(uname -m) ;; esac
+printf 'arch=%s\n' "$arch"
+printf 'cpus=%s\n' "$(getconf _NPROCESSORS_ONLN 2>/dev/null || nproc 2>/dev/null)"
+printf 'hostname=%s\n' "$(hostname 2>/dev/null || uname -n)"
+printf 'kernel=%s\n' "$(uname -r)"
+if [ -r /etc/os-release ]; then (. /etc/os-release; printf 'distribution=%s\ndistribution_version=%s\n' "$ID" "$VERSION_ID"); fi
+if [ -r /proc/meminfo ]; then awk '/^MemTotal:/ { printf "memory_gb=%.1f\n", $2 / 1048576 } /^MemFree:/ { printf "memory_free_gb=%.1f\n", $2 / 1048576 }' /proc/meminfo; fi
+df -Pk "$1" 2>/dev/null | awk 'NR == 2 { printf "disk_gb=%.1f\ndisk_free_gb=%.1f\n", $2 / 1048576, $4 / 1048576 }'
+`
+
+	factsPackage = `version=$(dpkg-query -W -f='${Version}' "$name" 2>/dev/null) || version=$(rpm -q --qf '%{VERSION}' "$name" 2>/dev/null) || version=$(apk info -e -v "$name" 2>/dev/null) || version=
+printf 'package.%s=%s\n' "$name" "$version"
+`
+
+	factsService = `state=$(systemctl is-active "$name" 2>/dev/null)
+printf 'service.%s=%s\n' "$name" "${state:-unknown}"
+`
+)
+
+func (err *FactsError) Error() string {
+	if err.Host == "" {
+		return "gather facts: " + err.Err.Error()
+	}
+
+	return "gather facts of " + err.Host + ": " + err.Err.Error()
+}
+
+func (err *FactsError) Unwrap() error {
+	return err.Err
+}
+
+func factsScript(config *models.Facts) string {
+	builder := new(strings.Builder)
+	builder.WriteString(factsCommand)
+
+	for _, name := range config.Packages {
+		builder.WriteString("name=" + shellQuote(name) + "\n" + factsPackage)
+	}
+
+	for _, name := range config.Services {
+		builder.WriteString("name=" + shellQuote(name) + "\n" + factsService)
+	}
+
+	return builder.String()
+}
+
+func (ctx *Context) loadFacts(run *execution, hosts []string) error {
+	deploy := run.deploy
+	if deploy.Facts == nil {
+		return nil
+	}
+
+	folder := deploy.Folder
+	if folder == "" {
+		folder = "."
+	}
+
+	script := factsScript(deploy.Facts)
+
+	targets := make([]*sshTarget, len(hosts))
+	remote := false
+
+	for i, host := range hosts {
+		target, err := ctx.sshTarget(deploy, host)
+		if err != nil {
+			return err
+		}
+
+		targets[i] = target
+		remote = remote || target != nil
+	}
+
+	if !remote {
+		facts, err := ctx.gatherFacts(run.scope, nil, script, folder)
+		if err != nil {
+			ctx.warn(&FactsError{Err: err})
+		}
+
+		setFacts(run.base, facts, ctx.container)
+		return nil
+	}
+
+	workers := make(chan struct{}, max(deploy.Parallel, defaultPrefetchWorkers))
+	gathered := make([]map[string]string, len(hosts))
+	errs := make([]error, len(hosts))
+	group := new(sync.WaitGroup)
+
+	for i, target := range targets {
+		if target == nil {
+			continue
+		}
+
+		group.Add(1)
+		workers <- struct{}{}
+
+		go func() {
+			defer group.Done()
+			defer func() { <-workers }()
+
+			gathered[i], errs[i] = ctx.gatherFacts(run.scope, target, script, folder)
+		}()
+	}
+
+	group.Wait()
+
+	for i, host := range hosts {
+		if errs[i] != nil {
+			ctx.warn(&FactsError{Host: host, Err: errs[i]})
+		}
+
+		setFacts(run.resolver(host), gathered[i], ctx.container)
+	}
+
+	return nil
+}
+
+func (ctx *Context) gatherFacts(scope context.Context, target *sshTarget, script, folder string) (map[string]string, error) {
+	output, err := []byte(nil), error(nil)
+
+	if target != nil {
+		output, err = ctx.sshShell(scope, target, "sh -c "+shellQuote(script)+" facts "+shellQuote(folder))
+	} else {
+		arguments := []string{"sh", "-c", script, "facts", folder}
+
+		output, err = ctx.effect("run", arguments, func() ([]byte, error) {
+			command := exec.CommandContext(scope, arguments[0], arguments[1:]...)
+			ctx.graceful(command, models.Duration{})
+
+			stderr := new(bytes.Buffer)
+			command.Stderr = stderr
+
+			output, err := command.Output()
+			if err != nil && stderr.Len() > 0 {
+				return output, fmt.Errorf("%w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+			}
+
+			return output, err
+		})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	facts := make(map[string]string)
+
+	for _, line := range strings.Split(string(output), "\n") {
+		name, value, ok := strings.Cut(line, "=")
+		if ok && name != "" {
+			facts[name] = strings.TrimSpace(value)
+		}
+	}
+
+	return facts, nil
+}
+
+func setFacts(resolver *variables.Resolver, facts map[string]string, container bool) {
+	for name, value := range facts {
+		resolver.Set(variables.SourceBuiltin, factsPrefix+name, value)
+	}
+
+	resolver.Set(variables.SourceBuiltin, factsPrefix+"container", strconv.FormatBool(container))
+}
@@ -0,0 +1,201 @@
+package deployctl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+var ErrFileSource = errors.New("file state needs either Content or Source, not both")
+
+func (ctx *Context) file(scope context.Context, file *models.ScriptFile, result *Result) error {
+	undo, err := snapshot(file.Path)
+	if err != nil {
+		return err
+	}
+
+	_, err = ctx.effect("file", []string{file.Path, string(file.State)}, func() ([]byte, error) {
+		return nil, await(scope, result, func() error {
+			changed, err := ensureFile(file, result)
+			result.Changed = changed
+
+			if changed && undo != nil {
+				result.onUndo(undo)
+			}
+
+			return err
+		})
+	})
+
+	return err
+}
+
+func ensureFile(file *models.ScriptFile, result *Result) (changed bool, err error) {
+	info, statErr := os.Lstat(file.Path)
+	exists := statErr == nil
+
+	if statErr != nil && !errors.Is(statErr, os.ErrNotExist) {
+		return false, statErr
+	}
+
+	switch file.State {
+	case models.FileStateAbsent:
+		if !exists {
+			return false, nil
+		}
+
+		return true, os.RemoveAll(file.Path)
+	case models.FileStateDirectory:
+		if exists && !info.IsDir() {
+			return false, &ExistsError{Path: file.Path}
+		}
+
+		if !exists {
+			err = makeDirectories(file.Path, os.ModePerm, result.directories)
+			if err != nil {
+				return false, err
+			}
+
+			changed = true
+		}
+	case models.FileStateLink:
+		if file.Source == "" {
+			return false, ErrFileSource
+		}
+
+		current, err := os.Readlink(file.Path)
+		if err != nil || current != file.Source {
+			if exists {
+				err = os.Remove(file.Path)
+				if err != nil {
+					return false, err
+				}
+			}
+
+			err = os.Symlink(file.Source, file.Path)
+			if err != nil {
+				return false, err
+			}
+
+			changed = true
+		}
+
+		return changed, nil
+	default:
+		if file.Content != "" && file.Source != "" {
+			return false, ErrFileSource
+		}
+
+		content := []byte(file.Content)
+		if file.Source != "" {
+			content, err = os.ReadFile(file.Source)
+			if err != nil {
+				return false, err
+			}
+		}
+
+		changed, err = ensureContent(file.Path, content, result)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	attributes, err := applyAttributes(file.Path, file.Mode, joinOwner(file.Owner, file.Group))
+	return changed || attributes, err
+}
+
+func ensureContent(path string, content []byte, result *Result) (changed bool, err error) {
+	info, err := os.Lstat(path)
+	exists := err == nil
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+
+	if exists && !info.Mode().IsRegular() {
+		return false, &ExistsError{Path: path}
+	}
+
+	current := []byte(nil)
+	if exists {
+		current, err = os.ReadFile(path)
+		if err != nil {
+			return false, err
+		}
+
+		if bytes.Equal(current, content) {
+			return false, nil
+		}
+	}
+
+	mode := fs.FileMode(0o644)
+	if exists {
+		mode = info.Mode().Perm()
+	}
+
+	if exists {
+		result.Diff = textDiff(path, current, content)
+	}
+
+	err = writeFileAtomic(path, content, mode)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func planFile(file *models.ScriptFile) (plan []string, err error) {
+	info, err := os.Lstat(file.Path)
+	exists := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	switch file.State {
+	case models.FileStateAbsent:
+		if !exists {
+			return []string{"nothing to remove at " + file.Path}, nil
+		}
+	case models.FileStateDirectory:
+		if exists && !info.IsDir() {
+			return nil, &ExistsError{Path: file.Path}
+		}
+	case models.FileStateLink:
+		if file.Source == "" {
+			return nil, ErrFileSource
+		}
+	default:
+		if file.Content != "" && file.Source != "" {
+			return nil, ErrFileSource
+		}
+
+		if exists && !info.Mode().IsRegular() {
+			return nil, &ExistsError{Path: file.Path}
+		}
+
+		if file.Source != "" {
+			_, err = os.Stat(file.Source)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err = writable(filepath.Dir(file.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	state := file.State
+	if state == "" {
+		state = models.FileStateFile
+	}
+
+	return []string{"ensure " + file.Path + " is " + string(state)}, nil
+}
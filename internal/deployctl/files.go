@@ -0,0 +1,286 @@
+package deployctl
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	attributes struct {
+		mode  models.Mode
+		owner string
+	}
+
+	fileState struct {
+		size int64
+		mode fs.FileMode
+		sum  string
+	}
+)
+
+var ErrOwnerFormat = errors.New("owner must be in user, user:group or :group format")
+
+func newAttributes(mode models.Mode, owner, group string) attributes {
+	return attributes{mode: mode, owner: joinOwner(owner, group)}
+}
+
+func joinOwner(owner, group string) string {
+	if group == "" {
+		return owner
+	}
+
+	name, _, _ := strings.Cut(owner, ":")
+	return name + ":" + group
+}
+
+func (attributes attributes) empty() bool {
+	return !attributes.mode.Set && attributes.owner == ""
+}
+
+func (attributes attributes) apply(path string, info fs.FileInfo) error {
+	switch {
+	case attributes.empty(), info.Mode()&fs.ModeSymlink != 0:
+		return nil
+	case info.IsDir():
+		_, err := applyAttributes(path, models.Mode{}, attributes.owner)
+		return err
+	}
+
+	_, err := applyAttributes(path, attributes.mode, attributes.owner)
+	return err
+}
+
+func (attributes attributes) applyTree(root string) error {
+	if attributes.empty() {
+		return nil
+	}
+
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		return attributes.apply(path, info)
+	})
+}
+
+func statFile(path string) (state fileState, err error) {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fileState{}, nil
+	}
+
+	if err != nil || !info.Mode().IsRegular() {
+		return fileState{}, err
+	}
+
+	sum, err := checksumFile(path)
+	if err != nil {
+		return fileState{}, err
+	}
+
+	return fileState{size: info.Size(), mode: info.Mode().Perm(), sum: sum}, nil
+}
+
+func sameFile(from, to string, mode fs.FileMode) (bool, error) {
+	info, err := os.Lstat(to)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	source, err := os.Stat(from)
+	if err != nil {
+		return false, err
+	}
+
+	if !info.Mode().IsRegular() || info.Size() != source.Size() || info.Mode().Perm() != mode.Perm() {
+		return false, nil
+	}
+
+	current, err := checksumFile(to)
+	if err != nil {
+		return false, err
+	}
+
+	sum, err := checksumFile(from)
+	if err != nil {
+		return false, err
+	}
+
+	return current == sum, nil
+}
+
+func writeFileAtomic(path string, data []byte, mode fs.FileMode) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	temporary := file.Name()
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Chmod(temporary, mode)
+	}
+
+	if err == nil {
+		err = os.Rename(temporary, path)
+	}
+
+	if err != nil {
+		os.Remove(temporary)
+	}
+
+	return err
+}
+
+func makeDirectories(path string, fallback fs.FileMode, directories models.Directories) error {
+	missing := []string(nil)
+
+	for current := filepath.Clean(path); ; {
+		_, err := os.Stat(current)
+		if err == nil {
+			break
+		}
+
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		missing = append(missing, current)
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+
+		current = parent
+	}
+
+	mode := fallback
+	if directories.Mode.Set {
+		mode = directories.Mode.FileMode
+	}
+
+	for i := len(missing) - 1; i >= 0; i-- {
+		err := os.Mkdir(missing[i], mode.Perm())
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		_, err = applyAttributes(missing[i], directories.Mode, directories.Owner)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func lookupOwner(owner string) (uid, gid int, err error) {
+	uid, gid = -1, -1
+
+	if owner == "" {
+		return uid, gid, nil
+	}
+
+	name, group, _ := strings.Cut(owner, ":")
+
+	if name != "" {
+		uid, err = strconv.Atoi(name)
+		if err != nil {
+			found, err := user.Lookup(name)
+			if err != nil {
+				return -1, -1, err
+			}
+
+			uid, _ = strconv.Atoi(found.Uid)
+		}
+	}
+
+	if group != "" {
+		gid, err = strconv.Atoi(group)
+		if err != nil {
+			found, err := user.LookupGroup(group)
+			if err != nil {
+				return -1, -1, err
+			}
+
+			gid, _ = strconv.Atoi(found.Gid)
+		}
+	}
+
+	if uid < 0 && gid < 0 {
+		return -1, -1, ErrOwnerFormat
+	}
+
+	return uid, gid, nil
+}
+
+func applyAttributes(path string, mode models.Mode, owner string) (changed bool, err error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false, err
+	}
+
+	if mode.Set && info.Mode()&(fs.ModePerm|fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky) != mode.FileMode {
+		err = os.Chmod(path, mode.FileMode)
+		if err != nil {
+			return false, err
+		}
+
+		changed = true
+	}
+
+	uid, gid, err := lookupOwner(owner)
+	if err != nil {
+		return changed, err
+	}
+
+	if uid < 0 && gid < 0 {
+		return changed, nil
+	}
+
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		if (uid < 0 || uint32(uid) == stat.Uid) && (gid < 0 || uint32(gid) == stat.Gid) {
+			return changed, nil
+		}
+	}
+
+	err = os.Lchown(path, uid, gid)
+	if err != nil {
+		return changed, err
+	}
+
+	return true, nil
+}
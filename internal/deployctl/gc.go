@@ -0,0 +1,205 @@
+package deployctl
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	Garbage struct {
+		Path   string
+		Size   int64
+		Reason string
+	}
+
+	Collection struct {
+		Garbage []*Garbage
+		Runs    int
+	}
+)
+
+const (
+	defaultCacheRetention     = 30 * 24 * time.Hour
+	defaultWorkspaceRetention = 24 * time.Hour
+)
+
+var (
+	workspacePatterns = []string{"deployctl-ssh-*", "deployctl-upload-*", "deploy-output-*"}
+	cacheSidecars     = []string{".sha256", ".validator"}
+)
+
+func (garbage *Garbage) String() string {
+	return garbage.Path + ": " + garbage.Reason + ", " + bytesString(garbage.Size)
+}
+
+func (collection *Collection) Freed() (size int64) {
+	for _, garbage := range collection.Garbage {
+		size += garbage.Size
+	}
+
+	return size
+}
+
+func (ctx *Context) CollectGarbage(retention models.Retention, history string, now time.Time) (*Collection, error) {
+	collection := new(Collection)
+
+	cacheAge := retention.Cache.Duration
+	if cacheAge <= 0 {
+		cacheAge = defaultCacheRetention
+	}
+
+	workspaceAge := retention.Workspaces.Duration
+	if workspaceAge <= 0 {
+		workspaceAge = defaultWorkspaceRetention
+	}
+
+	if ctx.cache != "" {
+		for _, directory := range []string{ctx.cache, filepath.Join(ctx.cache, resultsDirectory)} {
+			found, err := staleCache(directory, now.Add(-cacheAge))
+			if err != nil {
+				return collection, err
+			}
+
+			collection.Garbage = append(collection.Garbage, found...)
+		}
+	}
+
+	found, err := staleWorkspaces(os.TempDir(), now.Add(-workspaceAge))
+	if err != nil {
+		return collection, err
+	}
+
+	collection.Garbage = append(collection.Garbage, found...)
+
+	if !ctx.dryRun {
+		for _, garbage := range collection.Garbage {
+			err = os.RemoveAll(garbage.Path)
+			if err != nil {
+				return collection, err
+			}
+		}
+	}
+
+	if history == "" || retention.Runs <= 0 {
+		return collection, nil
+	}
+
+	if ctx.dryRun {
+		runs, err := ReadHistory(history)
+		collection.Runs = max(len(runs)-retention.Runs, 0)
+		return collection, err
+	}
+
+	collection.Runs, err = PruneHistory(history, retention.Runs)
+	return collection, err
+}
+
+func staleCache(directory string, cutoff time.Time) (found []*Garbage, err error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		names[entry.Name()] = true
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		name, path := entry.Name(), filepath.Join(directory, entry.Name())
+
+		switch artifact, sidecar := cutSidecar(name); {
+		case sidecar && !names[artifact]:
+			found = append(found, &Garbage{Path: path, Size: info.Size(), Reason: "metadata of a removed entry"})
+		case sidecar:
+		case strings.HasSuffix(name, ".part") && info.ModTime().Before(cutoff):
+			found = append(found, &Garbage{Path: path, Size: info.Size(), Reason: "interrupted download"})
+		case info.ModTime().Before(cutoff):
+			found = append(found, &Garbage{Path: path, Size: info.Size(), Reason: "unused since " + info.ModTime().Format(time.DateOnly)})
+
+			for _, suffix := range cacheSidecars {
+				if sidecar, err := os.Stat(path + suffix); err == nil {
+					found = append(found, &Garbage{Path: path + suffix, Size: sidecar.Size(), Reason: "metadata of a removed entry"})
+				}
+			}
+		}
+	}
+
+	return found, nil
+}
+
+func cutSidecar(name string) (artifact string, ok bool) {
+	for _, suffix := range cacheSidecars {
+		artifact, ok = strings.CutSuffix(name, suffix)
+		if ok {
+			return artifact, true
+		}
+	}
+
+	return name, false
+}
+
+func staleWorkspaces(directory string, cutoff time.Time) (found []*Garbage, err error) {
+	for _, pattern := range workspacePatterns {
+		matches, err := filepath.Glob(filepath.Join(directory, pattern))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range matches {
+			info, err := os.Lstat(path)
+			if err != nil || !info.ModTime().Before(cutoff) {
+				continue
+			}
+
+			size, err := diskUsage(path)
+			if err != nil {
+				return nil, err
+			}
+
+			found = append(found, &Garbage{Path: path, Size: size, Reason: "workspace left since " + info.ModTime().Format(time.DateOnly)})
+		}
+	}
+
+	return found, nil
+}
+
+func diskUsage(root string) (size int64, err error) {
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.Type().IsRegular() {
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	return size, err
+}
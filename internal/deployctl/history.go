@@ -0,0 +1,212 @@
+package deployctl
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+var ErrRunNotFound = errors.New("run not found in history")
+
+var historyMutex sync.Mutex
+
+func NewRun(config []byte, start time.Time, reports []*Report) *models.Run {
+	run := &models.Run{
+		Start:    start,
+		Duration: time.Since(start),
+		Config:   checksum(config),
+	}
+
+	for _, report := range reports {
+		for _, result := range report.Results {
+			record := &models.RunResult{
+				Name:     result.Name,
+				Host:     result.Host,
+				Type:     result.Type,
+				Start:    result.Start,
+				Duration: result.Duration,
+				Skipped:  result.Skipped,
+				Outcome:  string(result.Outcome()),
+				CPU:      result.Usage.CPU,
+				MaxRSS:   result.Usage.MaxRSS,
+				Read:     result.Usage.Read,
+				Written:  result.Usage.Written,
+			}
+
+			if result.Err != nil {
+				record.Error = result.Err.Error()
+				run.Failed = true
+			}
+
+			run.Results = append(run.Results, record)
+		}
+	}
+
+	return run
+}
+
+func ReadHistory(path string) (runs []*models.Run, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 16<<20)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		run := new(models.Run)
+
+		err = sonic.Unmarshal(line, run)
+		if err != nil {
+			return nil, err
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs, scanner.Err()
+}
+
+func AppendHistory(path string, run *models.Run, keep int) error {
+	historyMutex.Lock()
+	defer historyMutex.Unlock()
+
+	runs, err := ReadHistory(path)
+	if err != nil {
+		return err
+	}
+
+	run.ID = 1
+	if len(runs) > 0 {
+		run.ID = runs[len(runs)-1].ID + 1
+	}
+
+	runs = append(runs, run)
+
+	if keep > 0 && len(runs) > keep {
+		runs = runs[len(runs)-keep:]
+	}
+
+	return writeHistory(path, runs)
+}
+
+func PruneHistory(path string, keep int) (pruned int, err error) {
+	historyMutex.Lock()
+	defer historyMutex.Unlock()
+
+	runs, err := ReadHistory(path)
+	if err != nil || keep <= 0 || len(runs) <= keep {
+		return 0, err
+	}
+
+	return len(runs) - keep, writeHistory(path, runs[len(runs)-keep:])
+}
+
+func writeHistory(path string, runs []*models.Run) error {
+	buffer := new(bytes.Buffer)
+
+	for _, run := range runs {
+		data, err := sonic.Marshal(run)
+		if err != nil {
+			return err
+		}
+
+		buffer.Write(data)
+		buffer.WriteByte('\n')
+	}
+
+	temporary := path + ".tmp"
+
+	err := os.WriteFile(temporary, buffer.Bytes(), 0o644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(temporary, path)
+}
+
+func FindRun(runs []*models.Run, id int) (*models.Run, error) {
+	for _, run := range runs {
+		if run.ID == id {
+			return run, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %d", ErrRunNotFound, id)
+}
+
+func DiffRuns(from, to *models.Run) []string {
+	lines := []string(nil)
+
+	if from.Config != to.Config {
+		lines = append(lines, fmt.Sprintf("config: %.12s -> %.12s", from.Config, to.Config))
+	}
+
+	if from.Failed != to.Failed {
+		lines = append(lines, fmt.Sprintf("failed: %t -> %t", from.Failed, to.Failed))
+	}
+
+	if from.Aborted != to.Aborted {
+		lines = append(lines, fmt.Sprintf("aborted: %t -> %t", from.Aborted, to.Aborted))
+	}
+
+	lines = append(lines, fmt.Sprintf("duration: %s -> %s (%+v)", from.Duration, to.Duration, to.Duration-from.Duration))
+
+	previous := make(map[string]*models.RunResult, len(from.Results))
+	for _, result := range from.Results {
+		previous[result.Label()] = result
+	}
+
+	current := make(map[string]bool, len(to.Results))
+
+	for _, result := range to.Results {
+		current[result.Label()] = true
+
+		before, ok := previous[result.Label()]
+		if !ok {
+			lines = append(lines, fmt.Sprintf("+ %s %s", result.Type, result.Label()))
+			continue
+		}
+
+		if before.Type != result.Type {
+			lines = append(lines, fmt.Sprintf("~ %s: type %s -> %s", result.Label(), before.Type, result.Type))
+		}
+
+		if before.Outcome != result.Outcome {
+			lines = append(lines, fmt.Sprintf("~ %s: outcome %s -> %s", result.Label(), before.Outcome, result.Outcome))
+		}
+
+		if before.Error != result.Error {
+			lines = append(lines, fmt.Sprintf("~ %s: error %q -> %q", result.Label(), before.Error, result.Error))
+		}
+
+		lines = append(lines, fmt.Sprintf("  %s: %s -> %s (%+v)", result.Label(), before.Duration, result.Duration, result.Duration-before.Duration))
+	}
+
+	for _, result := range from.Results {
+		if !current[result.Label()] {
+			lines = append(lines, fmt.Sprintf("- %s %s", result.Type, result.Label()))
+		}
+	}
+
+	return lines
+}
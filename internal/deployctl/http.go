@@ -0,0 +1,192 @@
+package deployctl
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	retryTransport struct {
+		inner   http.RoundTripper
+		retries int
+		delay   time.Duration
+	}
+)
+
+const defaultRetryDelay = time.Second
+
+var ErrCABundle = errors.New("no certificates found in CA bundle")
+
+func NewHTTPClient(config *models.HTTP) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if config.Proxy != "" {
+		proxy, err := url.Parse(config.Proxy)
+		if err != nil {
+			return nil, err
+		}
+
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if config.CABundle != "" || config.ClientCertificate != "" || config.Insecure {
+		tlsConfig := &tls.Config{
+			InsecureSkipVerify: config.Insecure,
+		}
+
+		if config.CABundle != "" {
+			data, err := os.ReadFile(config.CABundle)
+			if err != nil {
+				return nil, err
+			}
+
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(data) {
+				return nil, ErrCABundle
+			}
+
+			tlsConfig.RootCAs = pool
+		}
+
+		if config.ClientCertificate != "" {
+			certificate, err := tls.LoadX509KeyPair(config.ClientCertificate, config.ClientKey)
+			if err != nil {
+				return nil, err
+			}
+
+			tlsConfig.Certificates = []tls.Certificate{certificate}
+		}
+
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	delay := config.RetryDelay.Duration
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+
+	cooldown := config.BreakerCooldown.Duration
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+
+	limited := &limitTransport{
+		inner:     transport,
+		rate:      config.RateLimit,
+		burst:     max(float64(config.Burst), 1),
+		threshold: config.BreakerThreshold,
+		cooldown:  cooldown,
+		hosts:     make(map[string]*hostLimit),
+	}
+
+	client := &http.Client{
+		Transport: &retryTransport{
+			inner:   limited,
+			retries: config.Retries,
+			delay:   delay,
+		},
+		Timeout: config.Timeout.Duration,
+	}
+
+	return client, nil
+}
+
+func (ctx *Context) httpClient(deploy *models.Deploy) (*http.Client, error) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	client, ok := ctx.clients[deploy.HTTP]
+	if ok {
+		return client, nil
+	}
+
+	client, err := NewHTTPClient(&deploy.HTTP)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx.clients == nil {
+		ctx.clients = make(map[models.HTTP]*http.Client)
+	}
+
+	ctx.clients[deploy.HTTP] = client
+	return client, nil
+}
+
+func (transport *retryTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	retryable := request.Body == nil || request.GetBody != nil
+	delay := transport.delay
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && request.GetBody != nil {
+			body, err := request.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			request.Body = body
+		}
+
+		response, err := transport.inner.RoundTrip(request)
+
+		if attempt >= transport.retries || !retryable || !shouldRetry(response, err) || errors.Is(err, ErrCircuitOpen) {
+			return response, err
+		}
+
+		wait := delay
+		if response != nil {
+			if after := retryAfter(response); after > 0 {
+				wait = after
+			}
+
+			response.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-request.Context().Done():
+			timer.Stop()
+			return nil, request.Context().Err()
+		case <-timer.C:
+		}
+
+		delay *= 2
+	}
+}
+
+func shouldRetry(response *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+
+	return response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500
+}
+
+func retryAfter(response *http.Response) time.Duration {
+	value := response.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	at, err := http.ParseTime(value)
+	if err == nil {
+		return time.Until(at)
+	}
+
+	return 0
+}
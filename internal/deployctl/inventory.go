@@ -0,0 +1,49 @@
+package deployctl
+
+import (
+	"context"
+
+	"github.com/gohryt/dotdeploy/internal/inventory"
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+func (ctx *Context) loadInventory(scope context.Context, deploy *models.Deploy, resolver *variables.Resolver) (*models.Deploy, error) {
+	if len(deploy.Inventory) == 0 {
+		return deploy, nil
+	}
+
+	client, err := ctx.httpClient(deploy)
+	if err != nil {
+		return nil, err
+	}
+
+	view := *deploy
+	view.Remotes.Remotes = make(map[string]*models.Remote, len(deploy.Remotes.Remotes))
+
+	for name, remote := range deploy.Remotes.Remotes {
+		view.Remotes.Remotes[name] = remote
+	}
+
+	for _, source := range deploy.Inventory {
+		resolved := *source
+
+		resolved.Token, err = resolver.Expand(source.Token)
+		if err != nil {
+			return nil, err
+		}
+
+		remotes, err := inventory.Load(scope, client, &resolved)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, remote := range remotes {
+			if _, ok := view.Remotes.Remotes[name]; !ok {
+				view.Remotes.Remotes[name] = remote
+			}
+		}
+	}
+
+	return &view, nil
+}
@@ -0,0 +1,134 @@
+package deployctl
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type (
+	limitTransport struct {
+		inner     http.RoundTripper
+		rate      float64
+		burst     float64
+		threshold int
+		cooldown  time.Duration
+
+		mutex sync.Mutex
+		hosts map[string]*hostLimit
+	}
+
+	hostLimit struct {
+		mutex     sync.Mutex
+		tokens    float64
+		last      time.Time
+		failures  int
+		openUntil time.Time
+	}
+
+	CircuitOpenError struct {
+		Host  string
+		Until time.Time
+	}
+)
+
+const defaultBreakerCooldown = 30 * time.Second
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+func (err *CircuitOpenError) Error() string {
+	return err.Host + ": " + ErrCircuitOpen.Error() + " until " + err.Until.Format(time.TimeOnly)
+}
+
+func (err *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+func (transport *limitTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	limit := transport.host(request.URL.Host)
+
+	err := transport.acquire(request, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := transport.inner.RoundTrip(request)
+	transport.record(limit, shouldRetry(response, err))
+
+	return response, err
+}
+
+func (transport *limitTransport) host(name string) *hostLimit {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+
+	limit, ok := transport.hosts[name]
+	if !ok {
+		limit = &hostLimit{tokens: transport.burst, last: time.Now()}
+		transport.hosts[name] = limit
+	}
+
+	return limit
+}
+
+func (transport *limitTransport) acquire(request *http.Request, limit *hostLimit) error {
+	for {
+		limit.mutex.Lock()
+
+		now := time.Now()
+
+		if transport.threshold > 0 && now.Before(limit.openUntil) {
+			until := limit.openUntil
+			limit.mutex.Unlock()
+			return &CircuitOpenError{Host: request.URL.Host, Until: until}
+		}
+
+		if transport.rate <= 0 {
+			limit.mutex.Unlock()
+			return nil
+		}
+
+		limit.tokens = min(transport.burst, limit.tokens+now.Sub(limit.last).Seconds()*transport.rate)
+		limit.last = now
+
+		if limit.tokens >= 1 {
+			limit.tokens--
+			limit.mutex.Unlock()
+			return nil
+		}
+
+		wait := time.Duration((1 - limit.tokens) / transport.rate * float64(time.Second))
+		limit.mutex.Unlock()
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-request.Context().Done():
+			timer.Stop()
+			return request.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (transport *limitTransport) record(limit *hostLimit, failed bool) {
+	if transport.threshold <= 0 {
+		return
+	}
+
+	limit.mutex.Lock()
+	defer limit.mutex.Unlock()
+
+	if !failed {
+		limit.failures = 0
+		return
+	}
+
+	limit.failures++
+
+	if limit.failures >= transport.threshold {
+		limit.openUntil = time.Now().Add(transport.cooldown)
+		limit.failures = 0
+	}
+}
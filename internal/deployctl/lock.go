@@ -0,0 +1,335 @@
+package deployctl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/ignore"
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	LockError struct {
+		Reference string
+		Reason    string
+	}
+
+	reference struct {
+		key      string
+		path     string
+		deploy   *models.Deploy
+		download *models.ScriptDownload
+	}
+)
+
+func (err *LockError) Error() string {
+	return "lock " + err.Reference + ": " + err.Reason
+}
+
+func (ctx *Context) Lock(config []byte, deploys []*models.Deploy) (lock *models.Lock, err error) {
+	lock = &models.Lock{
+		Config:    checksum(config),
+		Artifacts: make(map[string]string),
+	}
+
+	for _, reference := range references(deploys) {
+		sum, err := ctx.checksumReference(reference)
+		if err != nil {
+			return nil, err
+		}
+
+		lock.Artifacts[reference.key] = sum
+	}
+
+	return lock, nil
+}
+
+func (ctx *Context) VerifyLock(lock *models.Lock, config []byte, deploys []*models.Deploy) error {
+	if lock.Config != checksum(config) {
+		return &LockError{Reference: "config", Reason: "changed since the lock was written"}
+	}
+
+	for _, reference := range references(deploys) {
+		expected, ok := lock.Artifacts[reference.key]
+		if !ok {
+			return &LockError{Reference: reference.key, Reason: "missing from lock"}
+		}
+
+		if download := reference.download; download != nil {
+			if download.SHA256 == "" {
+				download.SHA256 = expected
+			}
+
+			if !strings.EqualFold(download.SHA256, expected) {
+				return &LockError{Reference: reference.key, Reason: "SHA256 " + download.SHA256 + " does not match locked " + expected}
+			}
+
+			continue
+		}
+
+		sum, err := checksumFile(reference.path)
+		if err != nil {
+			return err
+		}
+
+		if sum != expected {
+			return &LockError{Reference: reference.key, Reason: "checksum " + sum + " does not match locked " + expected}
+		}
+	}
+
+	return nil
+}
+
+func references(deploys []*models.Deploy) []reference {
+	seen := make(map[string]bool)
+	list := []reference(nil)
+
+	for _, deploy := range deploys {
+		outputs := []string(nil)
+
+		for _, name := range deploy.Order() {
+			script := deploy.Scripts.Scripts[name]
+			if script == nil {
+				continue
+			}
+
+			if download := script.Download; download != nil {
+				if !seen[download.URL] {
+					seen[download.URL] = true
+					list = append(list, reference{key: download.URL, deploy: deploy, download: download})
+				}
+			}
+
+			for _, path := range expandReferences(scriptReferences(script)) {
+				if produced(outputs, path) {
+					continue
+				}
+
+				key := path
+				if relative, err := filepath.Rel(deploy.BaseDir, path); deploy.BaseDir != "" && err == nil && !strings.HasPrefix(relative, "..") {
+					key = filepath.ToSlash(relative)
+				}
+
+				if !seen[key] {
+					seen[key] = true
+					list = append(list, reference{key: key, path: path})
+				}
+			}
+
+			outputs = append(outputs, scriptOutputs(deploy, script)...)
+		}
+	}
+
+	return list
+}
+
+func scriptReferences(script *models.Script) []string {
+	switch {
+	case script.Move != nil:
+		return []string{script.Move.From}
+	case script.Copy != nil:
+		return []string{script.Copy.From}
+	case script.Archive != nil:
+		return []string{script.Archive.From}
+	case script.Extract != nil:
+		return []string{script.Extract.From}
+	case script.Template != nil:
+		return []string{script.Template.Source}
+	}
+
+	return nil
+}
+
+func scriptOutputs(deploy *models.Deploy, script *models.Script) []string {
+	switch {
+	case script.Download != nil:
+		return []string{downloadDestination(deploy, script.Download)}
+	case script.Move != nil:
+		return transferOutputs(deploy, script.Move.From, script.Move.To, script.Move.PreservePath)
+	case script.Copy != nil:
+		return transferOutputs(deploy, script.Copy.From, script.Copy.To, script.Copy.PreservePath)
+	case script.Archive != nil:
+		_, to := archiveDestination(deploy, script.Archive)
+		return []string{to}
+	case script.Extract != nil:
+		return []string{extractDestination(deploy, script.Extract)}
+	case script.Template != nil:
+		return []string{script.Template.To}
+	case script.File != nil:
+		return []string{script.File.Path}
+	}
+
+	return nil
+}
+
+func transferOutputs(deploy *models.Deploy, from, to string, preservePath bool) []string {
+	list, err := transfers(deploy.Folder, from, to, preservePath)
+	if err != nil {
+		return nil
+	}
+
+	outputs := make([]string, len(list))
+	for i, transfer := range list {
+		outputs[i] = transfer.To
+	}
+
+	return outputs
+}
+
+func produced(outputs []string, path string) bool {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	for _, output := range outputs {
+		output, err := filepath.Abs(output)
+		if err != nil {
+			continue
+		}
+
+		if relative, err := filepath.Rel(output, path); err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (ctx *Context) checksumReference(reference reference) (string, error) {
+	download := reference.download
+	if download == nil {
+		return checksumFile(reference.path)
+	}
+
+	if download.SHA256 != "" {
+		return strings.ToLower(download.SHA256), nil
+	}
+
+	if strings.Contains(download.URL, "$") {
+		return "", &LockError{Reference: reference.key, Reason: "URL uses variables, set SHA256 to lock it"}
+	}
+
+	client, err := ctx.httpClient(reference.deploy)
+	if err != nil {
+		return "", err
+	}
+
+	sum, err := ctx.effect("checksum", []string{download.URL}, func() ([]byte, error) {
+		scope, cancel := downloadScope(context.Background(), download)
+		defer cancel()
+
+		request, err := request(scope, http.MethodGet, download)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := client.Do(request)
+		if err != nil {
+			return nil, err
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode < 200 || response.StatusCode > 299 {
+			return nil, &StatusError{URL: download.URL, Status: response.Status, Code: response.StatusCode}
+		}
+
+		hash := sha256.New()
+
+		_, err = io.Copy(hash, response.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		return []byte(hex.EncodeToString(hash.Sum(nil))), nil
+	})
+
+	return string(sum), err
+}
+
+func expandReferences(references []string) []string {
+	list := []string(nil)
+
+	for _, reference := range references {
+		if !isGlob(reference) {
+			list = append(list, reference)
+			continue
+		}
+
+		matches, _ := filepath.Glob(reference)
+		list = append(list, matches...)
+	}
+
+	return list
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func checksumFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	if info.IsDir() {
+		return checksumTree(path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func checksumTree(root string) (string, error) {
+	hash := sha256.New()
+
+	err := ignore.Walk(root, nil, func(path string, entry fs.DirEntry) error {
+		relative, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			_, err = io.WriteString(hash, filepath.ToSlash(relative)+"/\n")
+			return err
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		sum, err := checksumFile(path)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.WriteString(hash, filepath.ToSlash(relative)+"\x00"+sum+"\n")
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,239 @@
+package deployctl
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	ManifestChange struct {
+		Path   string
+		Kind   ChangeKind
+		Reason string
+	}
+
+	VerifyError struct {
+		Path    string
+		Changes int
+	}
+)
+
+const ManifestSuffix = ".manifest.json"
+
+var manifestAPI = sonic.Config{SortMapKeys: true}.Froze()
+
+func (err *VerifyError) Error() string {
+	return err.Path + ": " + strconv.Itoa(err.Changes) + " files differ from the manifest"
+}
+
+func ManifestPath(root, to string) string {
+	if to != "" {
+		return to
+	}
+
+	return filepath.Clean(root) + ManifestSuffix
+}
+
+func (ctx *Context) manifest(scope context.Context, manifest *models.ScriptManifest, result *Result) error {
+	to := ManifestPath(manifest.Path, manifest.To)
+
+	undo, err := snapshot(to)
+	if err != nil {
+		return err
+	}
+
+	_, err = ctx.effect("manifest", []string{manifest.Path, to}, func() ([]byte, error) {
+		return nil, await(scope, result, func() error {
+			built, err := BuildManifest(scope, manifest.Path, to)
+			if err != nil {
+				return err
+			}
+
+			data, err := manifestAPI.MarshalIndent(built, "", "\t")
+			if err != nil {
+				return err
+			}
+
+			current, readErr := os.ReadFile(to)
+			if readErr == nil && bytes.Equal(current, data) {
+				return nil
+			}
+
+			result.Changed = true
+			result.Usage.Written = int64(len(data))
+
+			if undo != nil {
+				result.onUndo(undo)
+			}
+
+			return writeFileAtomic(to, data, 0o644)
+		})
+	})
+
+	result.artifact = to
+	return err
+}
+
+func BuildManifest(scope context.Context, root, exclude string) (*models.Manifest, error) {
+	manifest := &models.Manifest{Files: make(map[string]*models.ManifestEntry)}
+
+	excluded, err := filepath.Abs(exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil {
+			err = scope.Err()
+		}
+
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		absolute, err := filepath.Abs(path)
+		if err != nil || absolute == excluded {
+			return err
+		}
+
+		relative, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		entryManifest, err := manifestEntry(path)
+		if err != nil {
+			return err
+		}
+
+		manifest.Files[filepath.ToSlash(relative)] = entryManifest
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+func manifestEntry(path string) (*models.ManifestEntry, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.ManifestEntry{
+		Size: info.Size(),
+		Mode: models.Mode{FileMode: info.Mode().Perm() | info.Mode()&(fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky), Set: true},
+	}
+
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		entry.Link, err = os.Readlink(path)
+	case info.Mode().IsRegular():
+		entry.SHA256, err = checksumFile(path)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func LoadManifest(path string) (*models.Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := new(models.Manifest)
+
+	err = sonic.Unmarshal(data, manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+func VerifyManifest(scope context.Context, manifest *models.Manifest, root, path string) (changes []*ManifestChange, err error) {
+	current, err := BuildManifest(scope, root, path)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(manifest.Files)+len(current.Files))
+	for name := range manifest.Files {
+		names = append(names, name)
+	}
+
+	for name := range current.Files {
+		if _, ok := manifest.Files[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		expected, actual := manifest.Files[name], current.Files[name]
+
+		switch {
+		case actual == nil:
+			changes = append(changes, &ManifestChange{Path: name, Kind: ChangeRemoved})
+		case expected == nil:
+			changes = append(changes, &ManifestChange{Path: name, Kind: ChangeAdded})
+		default:
+			reasons := []string(nil)
+
+			if expected.SHA256 != actual.SHA256 || expected.Size != actual.Size {
+				reasons = append(reasons, "content")
+			}
+
+			if expected.Mode.FileMode != actual.Mode.FileMode {
+				reasons = append(reasons, "mode "+octal(expected.Mode)+" -> "+octal(actual.Mode))
+			}
+
+			if expected.Link != actual.Link {
+				reasons = append(reasons, "link "+expected.Link+" -> "+actual.Link)
+			}
+
+			if len(reasons) > 0 {
+				changes = append(changes, &ManifestChange{Path: name, Kind: ChangeChanged, Reason: strings.Join(reasons, ", ")})
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+func octal(mode models.Mode) string {
+	return "0" + strconv.FormatUint(uint64(mode.Octal()), 8)
+}
+
+func planManifest(manifest *models.ScriptManifest) (plan []string, err error) {
+	_, err = os.Stat(manifest.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	to := ManifestPath(manifest.Path, manifest.To)
+
+	err = writable(filepath.Dir(to))
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{"write manifest of " + manifest.Path + " to " + to}, nil
+}
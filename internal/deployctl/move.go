@@ -0,0 +1,219 @@
+package deployctl
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	SpecialFileError struct {
+		Path string
+	}
+)
+
+func (err *SpecialFileError) Error() string {
+	return "cannot move " + err.Path + " across filesystems, it is not a regular file, directory or symlink"
+}
+
+func (ctx *Context) move(scope context.Context, deploy *models.Deploy, move *models.ScriptMove, result *Result) error {
+	list, err := transfers(deploy.Folder, move.From, move.To, move.PreservePath)
+	if err != nil {
+		return err
+	}
+
+	attributes := newAttributes(move.Mode, move.Owner, move.Group)
+
+	for _, transfer := range list {
+		skip, err := ctx.prepareDestination(transfer.To, move.IfExists, result)
+		if err != nil {
+			return err
+		}
+
+		if skip {
+			continue
+		}
+
+		result.Diff += fileDiff(transfer.To, transfer.From)
+
+		_, err = ctx.effect("rename", []string{transfer.From, transfer.To}, func() ([]byte, error) {
+			return nil, await(scope, result, func() error {
+				if isGlob(move.From) {
+					err := makeDirectories(filepath.Dir(transfer.To), 0o755, result.directories)
+					if err != nil {
+						return err
+					}
+				}
+
+				err := rename(scope, transfer.From, transfer.To, result)
+				if err != nil {
+					return err
+				}
+
+				return attributes.applyTree(transfer.To)
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		result.onUndo(func() error {
+			return rename(context.Background(), transfer.To, transfer.From, new(Result))
+		})
+
+		result.Changed = true
+	}
+
+	return nil
+}
+
+func rename(scope context.Context, from, to string, result *Result) error {
+	err := os.Rename(from, to)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	info, err := os.Lstat(from)
+	if err != nil {
+		return err
+	}
+
+	if info.Mode().IsRegular() {
+		sum, err := checksumFile(from)
+		if err != nil {
+			return err
+		}
+
+		written, err := copyAtomic(scope, from, to, info.Mode(), sum)
+		result.Usage.Written += written
+		if err != nil {
+			return err
+		}
+
+		return os.Remove(from)
+	}
+
+	staging, err := os.MkdirTemp(filepath.Dir(to), "."+filepath.Base(to)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	temporary := filepath.Join(staging, filepath.Base(to))
+
+	err = copyAll(scope, from, temporary, result)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(temporary, to)
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(from)
+}
+
+func copyAll(scope context.Context, from, to string, result *Result) error {
+	directories := map[string]fs.FileMode{}
+
+	err := filepath.WalkDir(from, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		err = scope.Err()
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(to, relative)
+
+		info, err := os.Lstat(path)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case info.IsDir():
+			directories[target] = info.Mode().Perm()
+			return os.Mkdir(target, 0o700)
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			sum, err := checksumFile(path)
+			if err != nil {
+				return err
+			}
+
+			written, err := copyRegular(scope, path, target, info.Mode())
+			result.Usage.Written += written
+			if err != nil {
+				return err
+			}
+
+			return verifyChecksum(target, sum)
+		}
+
+		return &SpecialFileError{Path: path}
+	})
+	if err != nil {
+		return err
+	}
+
+	for directory, mode := range directories {
+		err = os.Chmod(directory, mode)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func planMove(deploy *models.Deploy, move *models.ScriptMove) (plan []string, err error) {
+	list, err := transfers(deploy.Folder, move.From, move.To, move.PreservePath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, transfer := range list {
+		_, err = os.Lstat(transfer.From)
+		if err != nil {
+			return nil, err
+		}
+
+		err = writable(filepath.Dir(transfer.From))
+		if err != nil {
+			return nil, err
+		}
+
+		steps, skip, err := planDestination(transfer.To, move.IfExists)
+		if err != nil {
+			return nil, err
+		}
+
+		plan = append(plan, steps...)
+
+		if !skip {
+			plan = append(plan, "rename "+transfer.From+" to "+transfer.To)
+		}
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,163 @@
+package deployctl
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/diff"
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	ExistsError struct {
+		Path string
+	}
+
+	NoMatchError struct {
+		Pattern string
+	}
+
+	transfer struct {
+		From string
+		To   string
+	}
+)
+
+const diffLimit = 1 << 20
+
+func (err *ExistsError) Error() string {
+	return err.Path + " already exists"
+}
+
+func (err *NoMatchError) Error() string {
+	return "no files match " + err.Pattern
+}
+
+func isGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+func transfers(folder, from, to string, preservePath bool) ([]transfer, error) {
+	if !isGlob(from) {
+		return []transfer{{From: from, To: destination(folder, from, to, preservePath)}}, nil
+	}
+
+	matches, err := filepath.Glob(from)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matches) == 0 {
+		return nil, &NoMatchError{Pattern: from}
+	}
+
+	if to == "" {
+		to = folder
+	}
+
+	list := make([]transfer, len(matches))
+
+	for i, match := range matches {
+		list[i] = transfer{From: match, To: destination(to, match, "", preservePath)}
+	}
+
+	return list, nil
+}
+
+func destination(folder, from, to string, preservePath bool) string {
+	if to != "" {
+		return to
+	}
+
+	if preservePath {
+		return filepath.Join(folder, from)
+	}
+
+	return filepath.Join(folder, filepath.Base(from))
+}
+
+func fileDiff(existing, replacement string) string {
+	before, ok := readText(existing)
+	if !ok {
+		return ""
+	}
+
+	after, ok := readText(replacement)
+	if !ok {
+		return ""
+	}
+
+	return diff.Unified(existing, replacement, before, after, diff.DefaultContext)
+}
+
+func textDiff(path string, current, content []byte) string {
+	if !diff.Text(current) || !diff.Text(content) {
+		return ""
+	}
+
+	if len(current) > diffLimit || len(content) > diffLimit {
+		return diff.TooLarge
+	}
+
+	return diff.Unified(path, path, current, content, diff.DefaultContext)
+}
+
+func readText(path string) ([]byte, bool) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > diffLimit {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || !diff.Text(data) {
+		return nil, false
+	}
+
+	return data, true
+}
+
+func (ctx *Context) prepareDestination(to string, ifExists models.IfExists, result *Result) (skip bool, err error) {
+	_, err = os.Lstat(to)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			result.onUndo(func() error {
+				return os.RemoveAll(to)
+			})
+
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	switch ifExists {
+	case models.IfExistsSkip:
+		return true, nil
+	case models.IfExistsFail:
+		return false, &ExistsError{Path: to}
+	case models.IfExistsBackup:
+		backup := to + ".bak." + time.Now().Format("20060102T150405")
+
+		_, err = ctx.effect("rename", []string{to, backup}, func() ([]byte, error) {
+			return nil, os.Rename(to, backup)
+		})
+
+		if err == nil {
+			result.onUndo(func() error {
+				err := os.RemoveAll(to)
+				if err != nil {
+					return err
+				}
+
+				return os.Rename(backup, to)
+			})
+		}
+
+		return false, err
+	}
+
+	return false, nil
+}
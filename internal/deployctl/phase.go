@@ -0,0 +1,76 @@
+package deployctl
+
+import (
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	PrepareError struct {
+		Err error
+	}
+)
+
+func (err *PrepareError) Error() string {
+	return "prepare phase failed, nothing was committed: " + err.Err.Error()
+}
+
+func (err *PrepareError) Unwrap() error {
+	return err.Err
+}
+
+func splitPhases(deploy *models.Deploy, names []string) (prepare, commit []string) {
+	for _, name := range names {
+		if deploy.Scripts.Scripts[name].Commits() {
+			commit = append(commit, name)
+		} else {
+			prepare = append(prepare, name)
+		}
+	}
+
+	return prepare, commit
+}
+
+func (ctx *Context) preparePhase(run *execution, hosts, prepare, names []string) (ready []string, failed []error, fatal error) {
+	deploy := run.deploy
+	first := true
+
+	for _, host := range hosts {
+		if run.scope.Err() != nil {
+			return ready, failed, ErrAborted
+		}
+
+		if host != "" {
+			err := ctx.reach(run.scope, deploy, host)
+			if err != nil {
+				ctx.unreachable(run, host, names, err)
+
+				if !deploy.Rollout.SkipUnreachable {
+					return ready, append(failed, err), nil
+				}
+
+				continue
+			}
+		}
+
+		hostFailed, err := ctx.processHost(run, host, first, prepare)
+		first = false
+
+		failed = append(failed, hostFailed...)
+
+		if err != nil {
+			return ready, failed, err
+		}
+
+		if len(hostFailed) > 0 && !ctx.dryRun {
+			if deploy.Strategy != models.StrategyContinue {
+				return ready, failed, nil
+			}
+
+			continue
+		}
+
+		ready = append(ready, host)
+	}
+
+	return ready, failed, nil
+}
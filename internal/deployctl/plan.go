@@ -0,0 +1,137 @@
+package deployctl
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+type (
+	NotWritableError struct {
+		Path string
+	}
+
+	NotDirectoryError struct {
+		Path string
+	}
+)
+
+const accessWrite = 2
+
+func (err *NotWritableError) Error() string {
+	return err.Path + " is not writable"
+}
+
+func (err *NotDirectoryError) Error() string {
+	return err.Path + " is not a directory"
+}
+
+func (ctx *Context) SetDryRun(dryRun bool) {
+	ctx.dryRun = dryRun
+}
+
+func (ctx *Context) plan(scope context.Context, deploy *models.Deploy, script *models.Script, resolver *variables.Resolver, result *Result) (err error) {
+	target, err := ctx.scriptTarget(deploy, script, result.Host)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case script.Move != nil && target != nil:
+		result.Plan, err = planUpload(deploy, target, script.Move.From, script.Move.To, script.Move.PreservePath, false)
+	case script.Copy != nil && target != nil:
+		result.Plan, err = planUpload(deploy, target, script.Copy.From, script.Copy.To, script.Copy.PreservePath, script.Copy.Distribute)
+	case script.Move != nil:
+		result.Plan, err = planMove(deploy, script.Move)
+	case script.Copy != nil:
+		result.Plan, err = planCopy(deploy, script.Copy)
+	case script.Download != nil:
+		result.Plan, err = planDownload(deploy, script.Download)
+	case script.Run != nil:
+		result.Plan, err = ctx.planRun(script, resolver, target)
+	case script.File != nil:
+		result.Plan, err = planFile(script.File)
+	case script.Template != nil:
+		result.Plan, err = ctx.planTemplate(deploy, script.Template, resolver, result.Host, result)
+	case script.Symlink != nil:
+		result.Plan, err = planSymlink(script.Symlink, target)
+	case script.Delete != nil:
+		result.Plan, err = planDelete(script.Delete)
+	case script.Archive != nil:
+		result.Plan, err = planArchive(deploy, script.Archive)
+	case script.Extract != nil:
+		result.Plan, err = planExtract(deploy, script.Extract)
+	case script.Manifest != nil:
+		result.Plan, err = planManifest(script.Manifest)
+	case script.Wait != nil:
+		result.Plan, err = planWait(script.Wait)
+	case script.Compat != nil:
+		result.Plan, err = planCompat(script.Compat)
+	case script.Deploy != nil:
+		err = ctx.deploy(scope, deploy, script, resolver, result)
+	default:
+		err = ErrScriptEmpty
+	}
+
+	return err
+}
+
+func placeholders(script *models.Script, resolver *variables.Resolver) {
+	for name := range script.Register {
+		resolver.Set(variables.SourceRegister, name, "<"+name+">")
+	}
+}
+
+func planDestination(to string, ifExists models.IfExists) (plan []string, skip bool, err error) {
+	_, err = os.Lstat(to)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, false, err
+		}
+
+		return nil, false, writable(filepath.Dir(to))
+	}
+
+	switch ifExists {
+	case models.IfExistsSkip:
+		return []string{"keep existing " + to}, true, nil
+	case models.IfExistsFail:
+		return nil, false, &ExistsError{Path: to}
+	case models.IfExistsBackup:
+		plan = []string{"back up " + to}
+	}
+
+	return plan, false, writable(filepath.Dir(to))
+}
+
+func writable(path string) error {
+	for {
+		_, err := os.Stat(path)
+		if err == nil {
+			break
+		}
+
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			break
+		}
+
+		path = parent
+	}
+
+	err := syscall.Access(path, accessWrite)
+	if err != nil {
+		return &NotWritableError{Path: path}
+	}
+
+	return nil
+}
@@ -0,0 +1,253 @@
+package deployctl
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	ChangeKind byte
+
+	FieldChange struct {
+		Path string
+		From string
+		To   string
+	}
+
+	PlanChange struct {
+		Document string
+		Script   string
+		Kind     ChangeKind
+		Type     string
+		Fields   []*FieldChange
+	}
+)
+
+const (
+	ChangeAdded   ChangeKind = '+'
+	ChangeRemoved ChangeKind = '-'
+	ChangeChanged ChangeKind = '~'
+)
+
+func DiffPlans(from, to []*models.Deploy) (changes []*PlanChange, err error) {
+	previous := documents(from)
+	current := documents(to)
+
+	keys := make([]string, 0, len(previous)+len(current))
+	for key := range previous {
+		keys = append(keys, key)
+	}
+
+	for key := range current {
+		if _, ok := previous[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		documentChanges, err := diffDocument(key, previous[key], current[key])
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, documentChanges...)
+	}
+
+	return changes, nil
+}
+
+func documents(deploys []*models.Deploy) map[string]*models.Deploy {
+	keyed := make(map[string]*models.Deploy, len(deploys))
+
+	for _, deploy := range deploys {
+		key := "environment=" + strconv.Quote(deploy.Target.Environment) + " stage=" + strconv.Quote(deploy.Target.Stage)
+
+		unique := key
+		for i := 2; keyed[unique] != nil; i++ {
+			unique = key + " #" + strconv.Itoa(i)
+		}
+
+		keyed[unique] = deploy
+	}
+
+	return keyed
+}
+
+func diffDocument(key string, from, to *models.Deploy) (changes []*PlanChange, err error) {
+	fromSettings, err := flattenSettings(from)
+	if err != nil {
+		return nil, err
+	}
+
+	toSettings, err := flattenSettings(to)
+	if err != nil {
+		return nil, err
+	}
+
+	if fields := diffFields(fromSettings, toSettings); len(fields) > 0 {
+		changes = append(changes, &PlanChange{Document: key, Kind: ChangeChanged, Fields: fields})
+	}
+
+	names := []string(nil)
+	if from != nil {
+		names = append(names, from.Order()...)
+	}
+
+	if to != nil {
+		for _, name := range to.Order() {
+			if from == nil || from.Scripts.Scripts[name] == nil {
+				names = append(names, name)
+			}
+		}
+	}
+
+	for _, name := range names {
+		before, after := (*models.Script)(nil), (*models.Script)(nil)
+		if from != nil {
+			before = from.Scripts.Scripts[name]
+		}
+
+		if to != nil {
+			after = to.Scripts.Scripts[name]
+		}
+
+		change := &PlanChange{Document: key, Script: name}
+
+		switch {
+		case before == nil:
+			change.Kind, change.Type = ChangeAdded, after.Type()
+		case after == nil:
+			change.Kind, change.Type = ChangeRemoved, before.Type()
+		default:
+			fromFields, err := flatten(before)
+			if err != nil {
+				return nil, err
+			}
+
+			toFields, err := flatten(after)
+			if err != nil {
+				return nil, err
+			}
+
+			change.Kind, change.Type = ChangeChanged, after.Type()
+			change.Fields = diffFields(fromFields, toFields)
+
+			if len(change.Fields) == 0 {
+				continue
+			}
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+func flattenSettings(deploy *models.Deploy) (map[string]string, error) {
+	if deploy == nil {
+		return nil, nil
+	}
+
+	fields, err := flatten(deploy)
+	if err != nil {
+		return nil, err
+	}
+
+	for path := range fields {
+		if hasRoot(path, "Scripts") || hasRoot(path, "Defaults") {
+			delete(fields, path)
+		}
+	}
+
+	return fields, nil
+}
+
+func hasRoot(path, root string) bool {
+	return path == root || (len(path) > len(root) && path[:len(root)] == root && path[len(root)] == '.')
+}
+
+func flatten(value any) (map[string]string, error) {
+	data, err := sonic.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	generic := any(nil)
+
+	err = sonic.Unmarshal(data, &generic)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]string)
+
+	err = flattenInto(fields, "", generic)
+	return fields, err
+}
+
+func flattenInto(fields map[string]string, path string, value any) error {
+	join := func(name string) string {
+		if path == "" {
+			return name
+		}
+
+		return path + "." + name
+	}
+
+	switch typed := value.(type) {
+	case map[string]any:
+		for name, inner := range typed {
+			err := flattenInto(fields, join(name), inner)
+			if err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		data, err := sonic.Marshal(typed)
+		if err != nil {
+			return err
+		}
+
+		switch string(data) {
+		case `""`, `"0s"`, "false", "0", "[]":
+			return nil
+		}
+
+		fields[path] = string(data)
+	}
+
+	return nil
+}
+
+func diffFields(from, to map[string]string) []*FieldChange {
+	paths := make([]string, 0, len(from)+len(to))
+
+	for path := range from {
+		paths = append(paths, path)
+	}
+
+	for path := range to {
+		if _, ok := from[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Strings(paths)
+
+	changes := []*FieldChange(nil)
+
+	for _, path := range paths {
+		if from[path] != to[path] {
+			changes = append(changes, &FieldChange{Path: path, From: from[path], To: to[path]})
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,92 @@
+package deployctl
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+func TestPlan(config []byte, directory string, test *models.PlanTest, strict bool) (failures []string, err error) {
+	deploys, err := models.Load(bytes.NewReader(config), strict)
+	if err != nil {
+		return nil, err
+	}
+
+	err = models.Resolve(deploys, directory)
+	if err != nil {
+		return nil, err
+	}
+
+	environ := make([]string, 0, len(test.Env))
+	for name, value := range test.Env {
+		environ = append(environ, name+"="+value)
+	}
+
+	sort.Strings(environ)
+
+	flags := make([]string, 0, len(test.Variables))
+	for name, value := range test.Variables {
+		flags = append(flags, name+"="+value)
+	}
+
+	sort.Strings(flags)
+
+	scripts := []string(nil)
+	resolved := make(map[string][]string)
+
+	for _, deploy := range models.Select(deploys, test.Environment, test.Stage) {
+		resolver := variables.NewResolver()
+		resolver.LoadBuiltins(&deploy.Target)
+		resolver.LoadMap(variables.SourceConfig, deploy.Variables.Variables)
+		resolver.LoadEnvironment(environ)
+
+		err = resolver.LoadFlags(flags)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range deploy.Order() {
+			script := deploy.Scripts.Scripts[name]
+			if test.Container && ContainerSkips(script) {
+				continue
+			}
+
+			scripts = append(scripts, name)
+
+			if slices.Contains(test.Forbid, script.Type()) {
+				failures = append(failures, fmt.Sprintf("script %s has forbidden type %s", name, script.Type()))
+			}
+
+			if _, ok := test.Resolved[name]; ok {
+				environment, err := resolver.Environment(script.Environment)
+				if err != nil {
+					failures = append(failures, fmt.Sprintf("script %s: %v", name, err))
+					continue
+				}
+
+				resolved[name] = environment
+			}
+		}
+	}
+
+	if test.Scripts != nil && !slices.Equal(scripts, test.Scripts) {
+		failures = append(failures, fmt.Sprintf("scripts %q, expected %q", scripts, test.Scripts))
+	}
+
+	for name, expected := range test.Resolved {
+		environment := resolved[name]
+
+		for _, key := range expected.Names() {
+			pair := key + "=" + expected[key]
+			if !slices.Contains(environment, pair) {
+				failures = append(failures, fmt.Sprintf("script %s environment %q, expected %s", name, environment, pair))
+			}
+		}
+	}
+
+	return failures, nil
+}
@@ -0,0 +1,216 @@
+package deployctl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	PrefetchError struct {
+		URL string
+		Err error
+	}
+)
+
+const defaultPrefetchWorkers = 4
+
+func (err *PrefetchError) Error() string {
+	return "prefetch " + err.URL + ": " + err.Err.Error()
+}
+
+func (err *PrefetchError) Unwrap() error {
+	return err.Err
+}
+
+func (ctx *Context) SetCache(directory string) {
+	ctx.cache = directory
+}
+
+func (ctx *Context) prefetch(run *execution, hosts, names []string) error {
+	deploy := run.deploy
+	if !deploy.Prefetch || ctx.cache == "" || ctx.dryRun {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	downloads := []*models.ScriptDownload(nil)
+
+	for _, host := range hosts {
+		for _, name := range names {
+			script := deploy.Scripts.Scripts[name]
+			if script == nil || script.Download == nil {
+				continue
+			}
+
+			target := host
+			if script.DelegateTo != "" {
+				target = script.DelegateTo
+			}
+
+			expanded, err := ExpandScript(script, run.resolver(target))
+			if err != nil || seen[expanded.Download.URL] {
+				continue
+			}
+
+			seen[expanded.Download.URL] = true
+			downloads = append(downloads, expanded.Download)
+		}
+	}
+
+	client, err := ctx.httpClient(deploy)
+	if err != nil {
+		return err
+	}
+
+	workers := make(chan struct{}, max(deploy.Parallel, defaultPrefetchWorkers))
+	errs := make([]error, len(downloads))
+	group := new(sync.WaitGroup)
+
+	for i, download := range downloads {
+		group.Add(1)
+		workers <- struct{}{}
+
+		go func() {
+			defer group.Done()
+			defer func() { <-workers }()
+
+			_, err := ctx.effect("prefetch", []string{download.URL}, func() ([]byte, error) {
+				return nil, ctx.fill(run.scope, deploy, client, download)
+			})
+			if err != nil {
+				errs[i] = &PrefetchError{URL: download.URL, Err: err}
+			}
+		}()
+	}
+
+	group.Wait()
+	return errors.Join(errs...)
+}
+
+func (ctx *Context) cachePath(url string) string {
+	return filepath.Join(ctx.cache, artifactKey(url))
+}
+
+func (ctx *Context) cached(download *models.ScriptDownload) (path string, ok bool) {
+	path, ok = ctx.cacheEntry(download)
+	if !ok {
+		return "", false
+	}
+
+	if download.SHA256 == "" {
+		ctx.mutex.Lock()
+		fresh := ctx.fresh[download.URL]
+		ctx.mutex.Unlock()
+
+		if !fresh {
+			return "", false
+		}
+	}
+
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	return path, true
+}
+
+func (ctx *Context) cacheEntry(download *models.ScriptDownload) (path string, ok bool) {
+	if ctx.cache == "" {
+		return "", false
+	}
+
+	path = ctx.cachePath(download.URL)
+
+	expected, err := os.ReadFile(path + ".sha256")
+	if err != nil {
+		return "", false
+	}
+
+	sum, err := checksumFile(path)
+	if err != nil || sum != strings.TrimSpace(string(expected)) {
+		return "", false
+	}
+
+	if download.SHA256 != "" && !strings.EqualFold(sum, download.SHA256) {
+		return "", false
+	}
+
+	return path, true
+}
+
+func (ctx *Context) refresh(url string) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.fresh == nil {
+		ctx.fresh = make(map[string]bool)
+	}
+
+	ctx.fresh[url] = true
+}
+
+func (ctx *Context) fill(scope context.Context, deploy *models.Deploy, client *http.Client, download *models.ScriptDownload) error {
+	defer ctx.lockPath(ctx.cachePath(download.URL))()
+
+	path, ok := ctx.cacheEntry(download)
+	if ok && download.SHA256 != "" {
+		return nil
+	}
+
+	scope, cancel := downloadScope(scope, download)
+	defer cancel()
+
+	_, _, current, err := probe(scope, client, download)
+	if err != nil {
+		return err
+	}
+
+	if ok && current != "" {
+		stored, err := os.ReadFile(path + ".validator")
+		if err == nil && strings.TrimSpace(string(stored)) == current {
+			ctx.refresh(download.URL)
+			return nil
+		}
+	}
+
+	err = os.MkdirAll(ctx.cache, 0o755)
+	if err != nil {
+		return err
+	}
+
+	path = ctx.cachePath(download.URL)
+
+	err = ctx.retrieve(scope, deploy, client, download, path, nil)
+	if err != nil {
+		return err
+	}
+
+	sum, err := checksumFile(path)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(path+".sha256", []byte(sum+"\n"), 0o644)
+	if err != nil {
+		return err
+	}
+
+	os.Remove(path + ".validator")
+
+	if current != "" {
+		err = os.WriteFile(path+".validator", []byte(current+"\n"), 0o644)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx.refresh(download.URL)
+	return nil
+}
@@ -0,0 +1,253 @@
+package deployctl
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type (
+	RouteError struct {
+		From string
+		To   string
+		Name string
+		Err  error
+	}
+
+	PortError struct {
+		Scheme string
+	}
+
+	route struct {
+		target  *sshTarget
+		host    string
+		name    string
+		address string
+	}
+)
+
+const controller = "controller"
+
+var schemePorts = map[string]string{
+	"http":       "80",
+	"https":      "443",
+	"ssh":        "22",
+	"postgres":   "5432",
+	"postgresql": "5432",
+	"mysql":      "3306",
+	"redis":      "6379",
+	"rediss":     "6379",
+	"amqp":       "5672",
+	"amqps":      "5671",
+	"mongodb":    "27017",
+}
+
+func (err *RouteError) Error() string {
+	to := err.To
+	if err.Name != "" {
+		to = err.Name + " at " + err.To
+	}
+
+	return err.From + " cannot reach " + to + ": " + err.Err.Error()
+}
+
+func (err *RouteError) Unwrap() error {
+	return err.Err
+}
+
+func (err *PortError) Error() string {
+	return "no port given and no default port for scheme " + err.Scheme
+}
+
+func (route *route) from() string {
+	if route.target == nil {
+		return controller
+	}
+
+	return route.target.host
+}
+
+func endpointAddress(address string) (string, error) {
+	parsed, err := url.Parse(address)
+	if err != nil || parsed.Host == "" {
+		_, _, err = net.SplitHostPort(address)
+		return address, err
+	}
+
+	port := parsed.Port()
+	if port == "" {
+		port = schemePorts[parsed.Scheme]
+	}
+
+	if port == "" {
+		return "", &PortError{Scheme: parsed.Scheme}
+	}
+
+	return net.JoinHostPort(parsed.Hostname(), port), nil
+}
+
+func (ctx *Context) preflight(run *execution, hosts, names []string) error {
+	deploy := run.deploy
+	preflight := deploy.Preflight
+
+	if !preflight.Plan && len(preflight.Endpoints) == 0 {
+		return nil
+	}
+
+	timeout := preflight.Timeout.Duration
+	if timeout <= 0 {
+		timeout = defaultConnectTimeout
+	}
+
+	local, remote, failed := []*route(nil), map[string][]*route{}, []error(nil)
+	seen := make(map[string]bool)
+
+	add := func(target *sshTarget, host, name, address string) {
+		resolved, err := endpointAddress(address)
+		if err != nil {
+			failed = append(failed, &RouteError{From: (&route{target: target}).from(), To: address, Name: name, Err: err})
+			return
+		}
+
+		found := &route{target: target, host: host, name: name, address: resolved}
+		if seen[found.from()+"\x00"+resolved] {
+			return
+		}
+
+		seen[found.from()+"\x00"+resolved] = true
+
+		if target == nil {
+			local = append(local, found)
+		} else {
+			remote[target.host] = append(remote[target.host], found)
+		}
+	}
+
+	targets := []*sshTarget(nil)
+
+	for _, host := range hosts {
+		if host == "" {
+			continue
+		}
+
+		if preflight.Plan {
+			add(nil, host, "ssh of "+host, sshAddress(deploy.Remotes.Remotes[host]))
+		}
+
+		target, err := ctx.sshTarget(deploy, host)
+		if err != nil {
+			return err
+		}
+
+		if target != nil {
+			targets = append(targets, target)
+		}
+	}
+
+	if preflight.Plan {
+		for _, name := range names {
+			if download := deploy.Scripts.Scripts[name].Download; download != nil {
+				address, err := run.base.Expand(download.URL)
+				if err == nil {
+					add(nil, "", "download "+name, address)
+				}
+			}
+		}
+
+		if os.Getenv(ArtifactsToken) != "" {
+			for _, peer := range deploy.Artifacts.Peers {
+				add(nil, "", "artifact server", peer)
+			}
+		}
+
+		for _, sink := range deploy.Events {
+			add(nil, "", "event sink", sink.URL)
+		}
+	}
+
+	for _, endpoint := range preflight.Endpoints {
+		address, err := run.base.Expand(endpoint.Address)
+		if err != nil {
+			return err
+		}
+
+		if !endpoint.Remote {
+			add(nil, "", endpoint.Name, address)
+			continue
+		}
+
+		for _, target := range targets {
+			add(target, "", endpoint.Name, address)
+		}
+	}
+
+	for i, err := range ctx.checkRoutes(run, local, timeout) {
+		if err != nil {
+			failed = append(failed, err)
+			delete(remote, local[i].host)
+		}
+	}
+
+	routes := []*route(nil)
+	for _, target := range targets {
+		routes = append(routes, remote[target.host]...)
+	}
+
+	for _, err := range ctx.checkRoutes(run, routes, timeout) {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+
+	return errors.Join(failed...)
+}
+
+func (ctx *Context) checkRoutes(run *execution, routes []*route, timeout time.Duration) []error {
+	workers := make(chan struct{}, max(run.deploy.Parallel, defaultPrefetchWorkers))
+	errs := make([]error, len(routes))
+	group := new(sync.WaitGroup)
+
+	for i, route := range routes {
+		group.Add(1)
+		workers <- struct{}{}
+
+		go func() {
+			defer group.Done()
+			defer func() { <-workers }()
+
+			err := ctx.checkRoute(run, route, timeout)
+			if err != nil {
+				errs[i] = &RouteError{From: route.from(), To: route.address, Name: route.name, Err: err}
+			}
+		}()
+	}
+
+	group.Wait()
+
+	return errs
+}
+
+func (ctx *Context) checkRoute(run *execution, route *route, timeout time.Duration) error {
+	if route.target == nil {
+		return ctx.dial(run.scope, route.address, timeout)
+	}
+
+	host, port, err := net.SplitHostPort(route.address)
+	if err != nil {
+		return err
+	}
+
+	_, err = ctx.sshShell(run.scope, route.target, probeCommand(host, port, timeout))
+	return err
+}
+
+func probeCommand(host, port string, timeout time.Duration) string {
+	seconds := strconv.Itoa(max(int(timeout.Round(time.Second)/time.Second), 1))
+	address := shellQuote(host) + " " + shellQuote(port)
+
+	return "if command -v nc >/dev/null 2>&1; then nc -z -w " + seconds + " " + address + "; else timeout " + seconds + " bash -c 'exec 3<>/dev/tcp/$1/$2' bash " + address + "; fi"
+}
@@ -0,0 +1,750 @@
+package deployctl
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+type (
+	Outcome string
+
+	Result struct {
+		Name        string
+		Host        string
+		Type        string
+		Start       time.Time
+		Duration    time.Duration
+		Skipped     bool
+		Changed     bool
+		Unreachable bool
+		Planned     bool
+		RolledBack  bool
+		Streamed    bool
+		Cached      bool
+		Attempts    int
+		Diff        string
+		Plan        []string
+		Output      string
+		Usage       Usage
+		Err         error
+
+		stdout      []byte
+		artifact    string
+		undo        []func() error
+		redactor    redactor
+		quota       *quota
+		directories models.Directories
+		worker      chan struct{}
+	}
+
+	Report struct {
+		Results []*Result
+	}
+
+	ScriptError struct {
+		Name string
+		Host string
+		Err  error
+	}
+
+	UnknownScriptError struct {
+		Name string
+	}
+
+	execution struct {
+		scope     context.Context
+		deploy    *models.Deploy
+		base      *variables.Resolver
+		only      selection
+		report    *Report
+		resolvers map[string]*variables.Resolver
+		deadline  time.Time
+		redactor  redactor
+		shared    map[string]bool
+	}
+
+	task struct {
+		name     string
+		script   *models.Script
+		resolver *variables.Resolver
+		result   *Result
+	}
+
+	UnknownRemoteError struct {
+		Script string
+		Remote string
+	}
+
+	scopedReader struct {
+		scope  context.Context
+		reader io.Reader
+	}
+)
+
+const (
+	OutcomeChanged     Outcome = "changed"
+	OutcomeUnchanged   Outcome = "unchanged"
+	OutcomeFailed      Outcome = "failed"
+	OutcomeSkipped     Outcome = "skipped"
+	OutcomeUnreachable Outcome = "unreachable"
+	OutcomePlanned     Outcome = "planned"
+	OutcomeRolledBack  Outcome = "rolled_back"
+)
+
+var ErrScriptEmpty = errors.New("script has no action")
+
+func (err *ScriptError) Error() string {
+	return "script " + models.Label(err.Name, err.Host) + ": " + err.Err.Error()
+}
+
+func (err *ScriptError) Unwrap() error {
+	return err.Err
+}
+
+func (err *UnknownScriptError) Error() string {
+	return "script " + err.Name + " is not defined"
+}
+
+func (err *UnknownRemoteError) Error() string {
+	return "script " + err.Script + " is delegated to undefined remote " + err.Remote
+}
+
+func (ctx *Context) Process(deploy *models.Deploy, resolver *variables.Resolver, labels ...string) (report *Report, err error) {
+	return ctx.process(ctx, deploy, resolver, labels...)
+}
+
+func (ctx *Context) ProcessContext(scope context.Context, deploy *models.Deploy, resolver *variables.Resolver, labels ...string) (report *Report, err error) {
+	scope, cancel := context.WithCancel(scope)
+	defer cancel()
+
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	return ctx.process(scope, deploy, resolver, labels...)
+}
+
+func (ctx *Context) process(scope context.Context, deploy *models.Deploy, resolver *variables.Resolver, labels ...string) (report *Report, err error) {
+	names, only := parseSelection(labels)
+
+	for _, name := range names {
+		if deploy.Scripts.Scripts[name] == nil {
+			return new(Report), &UnknownScriptError{Name: name}
+		}
+	}
+
+	check := deploy.Rollout.Check
+	if check != "" && deploy.Scripts.Scripts[check] == nil {
+		return new(Report), &UnknownScriptError{Name: check}
+	}
+
+	if len(names) == 0 {
+		names = deploy.Order()
+	} else {
+		names = filterOrder(deploy.Order(), names)
+	}
+
+	names = slices.DeleteFunc(names, func(name string) bool {
+		return name == check
+	})
+
+	err = ctx.Confirm(deploy, labels)
+	if err != nil {
+		return new(Report), err
+	}
+
+	deadline, err := openWindow(deploy, time.Now())
+	if err != nil {
+		return new(Report), err
+	}
+
+	resolver = resolver.Clone()
+
+	secrets, err := ctx.loadSecrets(scope, deploy, resolver)
+	if err != nil {
+		return new(Report), err
+	}
+
+	deploy, err = ctx.loadInventory(scope, deploy, resolver)
+	if err != nil {
+		return new(Report), err
+	}
+
+	hosts, err := deploy.Hosts()
+	if err != nil {
+		return new(Report), err
+	}
+
+	if len(hosts) == 0 {
+		hosts = []string{""}
+	}
+
+	report = new(Report)
+	errs := []error(nil)
+
+	ctx.emit(deploy, &Event{Type: models.EventRunStarted, Scripts: names})
+
+	defer func() {
+		event := &Event{Type: models.EventRunFinished, Summary: report.Summary()}
+		if err != nil {
+			event.Error = err.Error()
+		}
+
+		ctx.emit(deploy, event)
+	}()
+
+	defer func() {
+		if scope.Err() != nil && !errors.Is(err, ErrAborted) {
+			err = errors.Join(err, ErrAborted)
+		}
+	}()
+
+	redactor, err := newRedactor(append(slices.Clone(deploy.Redact), secrets...))
+	if err != nil {
+		return report, err
+	}
+
+	ctx.redactEffects(redactor)
+
+	run := &execution{
+		scope:     scope,
+		deploy:    deploy,
+		base:      resolver,
+		only:      only,
+		report:    report,
+		resolvers: make(map[string]*variables.Resolver, len(hosts)),
+		deadline:  deadline,
+		redactor:  redactor,
+		shared:    make(map[string]bool),
+	}
+
+	err = ctx.checkClocks(run, hosts)
+	if err != nil {
+		return report, err
+	}
+
+	err = ctx.loadFacts(run, hosts)
+	if err != nil {
+		return report, err
+	}
+
+	err = ctx.preflight(run, hosts, names)
+	if err != nil {
+		return report, err
+	}
+
+	err = ctx.prefetch(run, hosts, names)
+	if err != nil {
+		return report, err
+	}
+
+	prepare, commit := splitPhases(deploy, names)
+
+	if len(prepare) > 0 && len(commit) > 0 {
+		ready, failed, err := ctx.preparePhase(run, hosts, prepare, names)
+		errs = append(errs, failed...)
+
+		if err != nil {
+			return report, errors.Join(append(errs, err)...)
+		}
+
+		if len(failed) > 0 && !ctx.dryRun {
+			return report, &PrepareError{Err: errors.Join(errs...)}
+		}
+
+		if len(ready) == 0 {
+			return report, errors.Join(errs...)
+		}
+
+		hosts, names = ready, commit
+	}
+
+	batches := waves(hosts, deploy.Rollout.Serial.Size(len(hosts)))
+	first := true
+
+	for i, batch := range batches {
+		waveFailed := false
+		processed := []string(nil)
+
+		for _, host := range batch {
+			if scope.Err() != nil {
+				return report, errors.Join(append(errs, ErrAborted)...)
+			}
+
+			if host != "" {
+				err := ctx.reach(scope, deploy, host)
+				if err != nil {
+					ctx.unreachable(run, host, names, err)
+
+					if !deploy.Rollout.SkipUnreachable {
+						errs = append(errs, err)
+						waveFailed = true
+						break
+					}
+
+					continue
+				}
+			}
+
+			failed, err := ctx.processHost(run, host, first, names)
+			first = false
+			processed = append(processed, host)
+
+			errs = append(errs, failed...)
+
+			if err != nil {
+				return report, errors.Join(append(errs, err)...)
+			}
+
+			if len(failed) > 0 {
+				waveFailed = true
+
+				if deploy.Strategy != models.StrategyContinue {
+					break
+				}
+			}
+		}
+
+		if waveFailed || i == len(batches)-1 {
+			break
+		}
+
+		failed, err := ctx.betweenWaves(run, i+1, len(batches), processed)
+		errs = append(errs, failed...)
+
+		if err != nil {
+			return report, errors.Join(append(errs, err)...)
+		}
+
+		if len(failed) > 0 {
+			break
+		}
+	}
+
+	return report, errors.Join(errs...)
+}
+
+func (ctx *Context) processHost(run *execution, host string, first bool, names []string) (failed []error, fatal error) {
+	deploy, report := run.deploy, run.report
+
+	schedule := deploy.Schedule(names)
+	workers := max(deploy.Parallel, 1)
+	doneC := make(chan *task, len(names))
+	running := 0
+	stopped := false
+	completed := []*task(nil)
+
+	for {
+		for !stopped && running < workers {
+			name, ok := schedule.Next()
+			if !ok {
+				break
+			}
+
+			task, err := ctx.prepare(run, host, first, name)
+			if err != nil {
+				fatal = err
+				stopped = true
+				break
+			}
+
+			switch {
+			case task == nil:
+				schedule.Done(name)
+			case task.result.Skipped:
+				report.Results = append(report.Results, task.result)
+				ctx.finish(task.result)
+				schedule.Done(name)
+			default:
+				running++
+
+				go func() {
+					ctx.perform(run, task)
+					doneC <- task
+				}()
+			}
+		}
+
+		if running == 0 {
+			break
+		}
+
+		task := <-doneC
+		running--
+
+		report.Results = append(report.Results, task.result)
+		ctx.finish(task.result)
+		completed = append(completed, task)
+
+		if task.result.Err == nil {
+			schedule.Done(task.name)
+		} else {
+			failed = append(failed, &ScriptError{Name: task.name, Host: task.result.Host, Err: task.result.Err})
+			ctx.emit(deploy, &Event{Type: models.EventScriptFailed, Script: task.name, Host: task.result.Host, Error: task.result.Err.Error()})
+
+			for _, name := range schedule.Fail(task.name) {
+				result := ctx.unmet(run, host, name, task.name)
+				report.Results = append(report.Results, result)
+				ctx.finish(result)
+			}
+
+			if deploy.Strategy != models.StrategyContinue && !ctx.dryRun {
+				stopped = true
+			}
+		}
+	}
+
+	if deploy.OnFailure == models.OnFailureRollback && (len(failed) > 0 || fatal != nil) && !ctx.dryRun && run.scope.Err() == nil {
+		failed = append(failed, ctx.rollback(run, completed)...)
+	}
+
+	return failed, fatal
+}
+
+func (ctx *Context) unmet(run *execution, host, name, dependency string) *Result {
+	script := run.deploy.Scripts.Scripts[name]
+
+	if script.DelegateTo != "" {
+		host = script.DelegateTo
+	}
+
+	return &Result{
+		Name:     name,
+		Host:     host,
+		Type:     script.Type(),
+		Start:    time.Now(),
+		Skipped:  true,
+		Output:   "unmet dependency " + dependency,
+		redactor: run.redactor,
+	}
+}
+
+func (ctx *Context) prepare(run *execution, host string, first bool, name string) (*task, error) {
+	deploy := run.deploy
+
+	if run.scope.Err() != nil {
+		return nil, ErrAborted
+	}
+
+	script, ok := deploy.Scripts.Scripts[name]
+	if !ok || script == nil {
+		return nil, &UnknownScriptError{Name: name}
+	}
+
+	if script.RunOnce && !first {
+		return nil, nil
+	}
+
+	target := host
+	if script.DelegateTo != "" {
+		if deploy.Remotes.Remotes[script.DelegateTo] == nil {
+			return nil, &UnknownRemoteError{Script: name, Remote: script.DelegateTo}
+		}
+
+		target = script.DelegateTo
+	}
+
+	if !run.only.allows(name, target) {
+		return nil, nil
+	}
+
+	if !run.deadline.IsZero() && time.Now().After(run.deadline) {
+		return nil, &WindowError{Window: deploy.Window.Text, Closed: true}
+	}
+
+	resolver := run.resolver(target)
+
+	shared := ""
+	if script.Shared != "" {
+		storage, err := resolver.Expand(script.Shared)
+		if err != nil {
+			return nil, err
+		}
+
+		shared = name + "\x00" + storage
+		if run.shared[shared] {
+			return nil, nil
+		}
+	}
+
+	met, err := ctx.when(run.scope, deploy, name, script, target, resolver)
+	if err != nil {
+		return nil, err
+	}
+
+	step := StepContinue
+	if met {
+		step, err = ctx.step(name, script, resolver)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if step == StepAbort {
+		return nil, ErrAborted
+	}
+
+	task := &task{
+		name:     name,
+		script:   script,
+		resolver: resolver,
+		result: &Result{
+			Name:     name,
+			Host:     target,
+			Type:     script.Type(),
+			Start:    time.Now(),
+			redactor: run.redactor,
+		},
+	}
+
+	if !met || step == StepSkip || ctx.container && ContainerSkips(script) {
+		task.result.Skipped = true
+		return task, nil
+	}
+
+	if shared != "" {
+		run.shared[shared] = true
+	}
+
+	ctx.start(name, target)
+
+	return task, nil
+}
+
+func (ctx *Context) perform(run *execution, task *task) {
+	result := task.result
+
+	scope, cancel := withTimeout(run.scope, task.script.Timeout)
+	defer cancel()
+
+	defer func() {
+		result.Duration = time.Since(result.Start)
+		result.redact()
+	}()
+
+	deploy, err := run.view(result.Host, task.resolver)
+	if err == nil {
+		var expanded *models.Script
+
+		expanded, err = ExpandScript(task.script, task.resolver)
+		if err == nil {
+			task.script = expanded
+		}
+	}
+
+	script := task.script
+
+	if ctx.auditable(script) && err == nil && script.Manifest != nil {
+		result.Err = ctx.auditManifest(scope, script.Manifest, result)
+		return
+	}
+
+	if ctx.dryRun && !ctx.auditable(script) {
+		result.Planned = true
+		result.Err = err
+
+		if err == nil {
+			result.Err = ctx.plan(scope, deploy, script, task.resolver, result)
+		}
+
+		placeholders(script, task.resolver)
+		return
+	}
+
+	if err != nil {
+		result.Err = err
+		return
+	}
+
+	result.Err = ctx.retry(run.scope, script, result, func() error {
+		err := result.settle(run.scope)
+		if err != nil {
+			return err
+		}
+
+		scope, cancel := withTimeout(run.scope, script.Timeout)
+		defer cancel()
+
+		return ctx.execute(scope, deploy, script, task.resolver, result)
+	})
+
+	if result.Err == nil && len(script.Register) > 0 {
+		result.Err = register(script, task.resolver, result)
+	}
+}
+
+func (run *execution) resolver(host string) *variables.Resolver {
+	if host == "" {
+		return run.base
+	}
+
+	resolver, ok := run.resolvers[host]
+	if !ok {
+		resolver = run.base.Clone()
+		resolver.LoadHost(host, run.deploy.Remotes.Remotes[host])
+		run.resolvers[host] = resolver
+	}
+
+	return resolver
+}
+
+func (ctx *Context) execute(scope context.Context, deploy *models.Deploy, script *models.Script, resolver *variables.Resolver, result *Result) error {
+	result.quota = newQuota(script)
+	result.directories = deploy.Directories.Merge(script.Directories)
+
+	target, err := ctx.scriptTarget(deploy, script, result.Host)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case script.Move != nil && target != nil:
+		move := script.Move
+		return ctx.upload(scope, deploy, target, move.From, move.To, move.PreservePath, move.IfExists, nil, "", false, false, newAttributes(move.Mode, move.Owner, move.Group), true, result)
+	case script.Copy != nil && target != nil:
+		copy := script.Copy
+		return ctx.upload(scope, deploy, target, copy.From, copy.To, copy.PreservePath, copy.IfExists, copy.Exclude, copy.SHA256, copy.Atomic, copy.Distribute, newAttributes(copy.Mode, copy.Owner, copy.Group), false, result)
+	case script.Move != nil:
+		return ctx.move(scope, deploy, script.Move, result)
+	case script.Copy != nil:
+		return ctx.copy(scope, deploy, script.Copy, result)
+	case script.Download != nil:
+		return ctx.download(scope, deploy, script.Download, result)
+	case script.Run != nil:
+		return ctx.run(scope, script, resolver, target, result)
+	case script.File != nil:
+		return ctx.file(scope, script.File, result)
+	case script.Template != nil:
+		return ctx.template(scope, deploy, script.Template, resolver, result)
+	case script.Symlink != nil:
+		return ctx.symlink(scope, script.Symlink, target, result)
+	case script.Delete != nil:
+		return ctx.delete(scope, script.Delete, result)
+	case script.Archive != nil:
+		return ctx.archive(scope, deploy, script.Archive, result)
+	case script.Extract != nil:
+		return ctx.extract(scope, deploy, script.Extract, result)
+	case script.Manifest != nil:
+		return ctx.manifest(scope, script.Manifest, result)
+	case script.Wait != nil:
+		return ctx.wait(scope, deploy, script.Wait)
+	case script.Compat != nil:
+		return ctx.compat(scope, deploy, script.Compat, target, result)
+	case script.Deploy != nil:
+		return ctx.deploy(scope, deploy, script, resolver, result)
+	}
+
+	return ErrScriptEmpty
+}
+
+func withTimeout(parent context.Context, timeout models.Duration) (context.Context, context.CancelFunc) {
+	if timeout.Duration <= 0 {
+		return context.WithCancel(parent)
+	}
+
+	return context.WithTimeout(parent, timeout.Duration)
+}
+
+func await(scope context.Context, result *Result, operation func() error) error {
+	errC := make(chan error, 1)
+	worker := make(chan struct{})
+	result.worker = worker
+
+	go func() {
+		defer close(worker)
+		errC <- operation()
+	}()
+
+	select {
+	case err := <-errC:
+		return err
+	case <-scope.Done():
+		return scope.Err()
+	}
+}
+
+func (result *Result) settle(scope context.Context) error {
+	if result.worker == nil {
+		return nil
+	}
+
+	select {
+	case <-result.worker:
+		return nil
+	case <-scope.Done():
+		return scope.Err()
+	}
+}
+
+func (reader *scopedReader) Read(p []byte) (int, error) {
+	err := reader.scope.Err()
+	if err != nil {
+		return 0, err
+	}
+
+	return reader.reader.Read(p)
+}
+
+func filterOrder(order, names []string) []string {
+	filtered := make([]string, 0, len(names))
+
+	for _, name := range order {
+		if slices.Contains(names, name) {
+			filtered = append(filtered, name)
+		}
+	}
+
+	return filtered
+}
+
+func (result *Result) Label() string {
+	return models.Label(result.Name, result.Host)
+}
+
+func (result *Result) Outcome() Outcome {
+	switch {
+	case result.Unreachable:
+		return OutcomeUnreachable
+	case result.Err != nil:
+		return OutcomeFailed
+	case result.Skipped:
+		return OutcomeSkipped
+	case result.RolledBack:
+		return OutcomeRolledBack
+	case result.Planned:
+		return OutcomePlanned
+	case result.Changed:
+		return OutcomeChanged
+	}
+
+	return OutcomeUnchanged
+}
+
+func (report *Report) Count(outcome Outcome) int {
+	count := 0
+
+	for _, result := range report.Results {
+		if result.Outcome() == outcome {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (report *Report) Failed() []*Result {
+	failed := []*Result(nil)
+
+	for _, result := range report.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+
+	return failed
+}
@@ -0,0 +1,79 @@
+package deployctl
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+const concurrentConfig = `{
+	"Version": 2,
+	"Parallel": 2,
+	"Variables": {"GREETING": "hello"},
+	"Rollout": {"Check": "health"},
+	"Scripts": {
+		"build": {"Run": {"Path": "echo", "Args": ["${GREETING}"]}},
+		"version": {"Run": {"Path": "echo", "Args": ["{\"version\": \"1.0\"}"]}, "Register": {"VERSION": ".version"}},
+		"install": {"Run": {"Path": "echo", "Args": ["${VERSION}"]}, "Follow": ["build", "version"]},
+		"health": {"Run": {"Path": "true"}, "Follow": ["install"]}
+	}
+}`
+
+func TestProcessContextConcurrent(t *testing.T) {
+	deploys, err := models.Load(strings.NewReader(concurrentConfig), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = models.Resolve(deploys, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deploy := deploys[0]
+	order := deploy.Order()
+
+	resolver, err := variables.Resolve(deploy, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := NewContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Close()
+
+	group := new(sync.WaitGroup)
+	errs := make([]error, 8)
+
+	for i := range errs {
+		group.Add(1)
+
+		go func() {
+			defer group.Done()
+
+			_, errs[i] = ctx.ProcessContext(context.Background(), deploy, resolver)
+		}()
+	}
+
+	group.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	if current := deploy.Order(); strings.Join(current, " ") != strings.Join(order, " ") {
+		t.Errorf("order changed from %q to %q", order, current)
+	}
+
+	if _, ok := resolver.Lookup("VERSION"); ok {
+		t.Error("registered variable leaked into the shared resolver")
+	}
+}
@@ -0,0 +1,27 @@
+package deployctl
+
+type (
+	Starter func(name, host string)
+
+	Finisher func(result *Result)
+)
+
+func (ctx *Context) OnStart(starter Starter) {
+	ctx.starter = starter
+}
+
+func (ctx *Context) start(name, host string) {
+	if ctx.starter != nil {
+		ctx.starter(name, host)
+	}
+}
+
+func (ctx *Context) OnResult(finisher Finisher) {
+	ctx.finisher = finisher
+}
+
+func (ctx *Context) finish(result *Result) {
+	if ctx.finisher != nil {
+		ctx.finisher(result)
+	}
+}
@@ -0,0 +1,50 @@
+package deployctl
+
+import (
+	"errors"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	Confirmer func(deploy *models.Deploy, names []string) error
+)
+
+var ErrProtected = errors.New("environment is protected and the run was not confirmed")
+
+func (ctx *Context) OnConfirm(confirmer Confirmer) {
+	ctx.confirmer = confirmer
+}
+
+func (ctx *Context) SetConfirmed(confirmed bool) {
+	ctx.confirmed = confirmed
+}
+
+func (ctx *Context) Confirm(deploy *models.Deploy, names []string) error {
+	if !deploy.Target.Protected || ctx.confirmed || ctx.dryRun {
+		return nil
+	}
+
+	ctx.confirming.Lock()
+	defer ctx.confirming.Unlock()
+
+	if ctx.accepted[deploy] {
+		return nil
+	}
+
+	if ctx.confirmer == nil {
+		return ErrProtected
+	}
+
+	err := ctx.confirmer(deploy, names)
+	if err != nil {
+		return err
+	}
+
+	if ctx.accepted == nil {
+		ctx.accepted = make(map[*models.Deploy]bool)
+	}
+
+	ctx.accepted[deploy] = true
+	return nil
+}
@@ -0,0 +1,71 @@
+package deployctl
+
+import (
+	"io"
+	"sync"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	QuotaError struct {
+		Limit   int64
+		Written int64
+	}
+
+	quota struct {
+		mutex sync.Mutex
+		limit int64
+		used  int64
+	}
+
+	quotaWriter struct {
+		writer io.Writer
+		quota  *quota
+	}
+)
+
+func (err *QuotaError) Error() string {
+	return "writing " + bytesString(err.Written) + " exceeds the disk quota of " + bytesString(err.Limit)
+}
+
+func newQuota(script *models.Script) *quota {
+	if script.Quota <= 0 {
+		return nil
+	}
+
+	return &quota{limit: int64(script.Quota)}
+}
+
+func (quota *quota) reserve(size int64) error {
+	if quota == nil {
+		return nil
+	}
+
+	quota.mutex.Lock()
+	defer quota.mutex.Unlock()
+
+	quota.used += size
+	if quota.used > quota.limit {
+		return &QuotaError{Limit: quota.limit, Written: quota.used}
+	}
+
+	return nil
+}
+
+func (quota *quota) writer(writer io.Writer) io.Writer {
+	if quota == nil {
+		return writer
+	}
+
+	return &quotaWriter{writer: writer, quota: quota}
+}
+
+func (writer *quotaWriter) Write(data []byte) (int, error) {
+	err := writer.quota.reserve(int64(len(data)))
+	if err != nil {
+		return 0, err
+	}
+
+	return writer.writer.Write(data)
+}
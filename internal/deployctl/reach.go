@@ -0,0 +1,89 @@
+package deployctl
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	UnreachableError struct {
+		Host string
+		Err  error
+	}
+)
+
+const (
+	defaultSSHPort        = 22
+	defaultConnectTimeout = 5 * time.Second
+)
+
+func (err *UnreachableError) Error() string {
+	return "remote " + err.Host + " is unreachable: " + err.Err.Error()
+}
+
+func (err *UnreachableError) Unwrap() error {
+	return err.Err
+}
+
+func sshAddress(remote *models.Remote) string {
+	port := remote.Port
+	if port == 0 {
+		port = defaultSSHPort
+	}
+
+	return net.JoinHostPort(remote.IPv4, strconv.Itoa(port))
+}
+
+func (ctx *Context) reach(scope context.Context, deploy *models.Deploy, host string) error {
+	timeout := deploy.Rollout.ConnectTimeout.Duration
+	if timeout <= 0 {
+		timeout = defaultConnectTimeout
+	}
+
+	err := ctx.dial(scope, sshAddress(deploy.Remotes.Remotes[host]), timeout)
+	if err != nil {
+		return &UnreachableError{Host: host, Err: err}
+	}
+
+	return nil
+}
+
+func (ctx *Context) dial(scope context.Context, address string, timeout time.Duration) error {
+	_, err := ctx.effect("dial", []string{address}, func() ([]byte, error) {
+		dialer := net.Dialer{Timeout: timeout}
+
+		connection, err := dialer.DialContext(scope, "tcp", address)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, connection.Close()
+	})
+
+	return err
+}
+
+func (ctx *Context) unreachable(run *execution, host string, names []string, err error) {
+	for _, name := range names {
+		script := run.deploy.Scripts.Scripts[name]
+		if script.DelegateTo != "" || !run.only.allows(name, host) {
+			continue
+		}
+
+		result := &Result{
+			Name:        name,
+			Host:        host,
+			Type:        script.Type(),
+			Start:       time.Now(),
+			Unreachable: true,
+			Err:         err,
+		}
+
+		run.report.Results = append(run.report.Results, result)
+		ctx.finish(result)
+	}
+}
@@ -0,0 +1,138 @@
+package deployctl
+
+import (
+	"regexp"
+	"strings"
+)
+
+type (
+	redactor []*regexp.Regexp
+
+	RedactedError struct {
+		Message string
+		Err     error
+	}
+)
+
+const redacted = "***"
+
+var defaultRedact = []string{
+	`(?i)\bbearer\s+([A-Za-z0-9._~+/=-]{8,})`,
+	`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`,
+	`(?i)\baws_secret_access_key\s*[=:]\s*"?([A-Za-z0-9/+=]{40})`,
+	`(?i)\b(?:password|passwd|pwd|secret|token|api[_-]?key)\s*[=:]\s*("[^"]*"|'[^']*'|[^\s&;,]+)`,
+	`(?i)[a-z][a-z0-9+.-]*://[^:/@\s]+:([^@\s]+)@`,
+}
+
+func (err *RedactedError) Error() string {
+	return err.Message
+}
+
+func (err *RedactedError) Unwrap() error {
+	return err.Err
+}
+
+func newRedactor(patterns []string) (redactor, error) {
+	compiled := make(redactor, 0, len(defaultRedact)+len(patterns))
+
+	for _, pattern := range append(append([]string(nil), defaultRedact...), patterns...) {
+		expression, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		compiled = append(compiled, expression)
+	}
+
+	return compiled, nil
+}
+
+func (redactor redactor) redact(text string) string {
+	if text == "" {
+		return text
+	}
+
+	for _, expression := range redactor {
+		text = replaceGroups(expression, text)
+	}
+
+	return text
+}
+
+func (redactor redactor) args(args []string) []string {
+	if len(redactor) == 0 {
+		return args
+	}
+
+	clean := make([]string, len(args))
+	for i, arg := range args {
+		clean[i] = redactor.redact(arg)
+	}
+
+	return clean
+}
+
+func replaceGroups(expression *regexp.Regexp, text string) string {
+	matches := expression.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+
+	builder := strings.Builder{}
+	last := 0
+
+	for _, match := range matches {
+		spans := [][2]int{{match[0], match[1]}}
+
+		if len(match) > 2 {
+			spans = spans[:0]
+
+			for group := 2; group < len(match); group += 2 {
+				if match[group] >= 0 {
+					spans = append(spans, [2]int{match[group], match[group+1]})
+				}
+			}
+		}
+
+		for _, span := range spans {
+			if span[0] < last {
+				continue
+			}
+
+			builder.WriteString(text[last:span[0]])
+			builder.WriteString(redacted)
+			last = span[1]
+		}
+	}
+
+	builder.WriteString(text[last:])
+	return builder.String()
+}
+
+func (redactor redactor) error(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	message := err.Error()
+	if clean := redactor.redact(message); clean != message {
+		return &RedactedError{Message: clean, Err: err}
+	}
+
+	return err
+}
+
+func (result *Result) redact() {
+	if result.redactor == nil {
+		return
+	}
+
+	result.Output = result.redactor.redact(result.Output)
+	result.Diff = result.redactor.redact(result.Diff)
+
+	for i, line := range result.Plan {
+		result.Plan[i] = result.redactor.redact(line)
+	}
+
+	result.Err = result.redactor.error(result.Err)
+}
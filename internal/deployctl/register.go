@@ -0,0 +1,135 @@
+package deployctl
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+type (
+	RegisterError struct {
+		Variable string
+		Path     string
+		Err      error
+	}
+)
+
+var (
+	ErrPathFormat   = errors.New("path must start with a dot, like \".build.version\" or \".items[0].id\"")
+	ErrPathNotFound = errors.New("path does not match the output")
+)
+
+func (err *RegisterError) Error() string {
+	return "register " + err.Variable + " from " + err.Path + ": " + err.Err.Error()
+}
+
+func (err *RegisterError) Unwrap() error {
+	return err.Err
+}
+
+func register(script *models.Script, resolver *variables.Resolver, result *Result) error {
+	data := result.stdout
+	if data == nil {
+		data = []byte(result.Output)
+	}
+
+	if result.artifact != "" {
+		content, err := os.ReadFile(result.artifact)
+		if err != nil {
+			return err
+		}
+
+		data = content
+	}
+
+	document := any(nil)
+
+	err := sonic.Unmarshal(data, &document)
+	if err != nil {
+		return &RegisterError{Variable: "output", Path: ".", Err: err}
+	}
+
+	names := make([]string, 0, len(script.Register))
+	for name := range script.Register {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := script.Register[name]
+
+		value, err := extract(document, path)
+		if err != nil {
+			return &RegisterError{Variable: name, Path: path, Err: err}
+		}
+
+		resolver.Set(variables.SourceRegister, name, value)
+	}
+
+	return nil
+}
+
+func extract(document any, path string) (string, error) {
+	rest, ok := strings.CutPrefix(path, ".")
+	if !ok {
+		return "", ErrPathFormat
+	}
+
+	current := document
+
+	for rest != "" {
+		switch {
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return "", ErrPathFormat
+			}
+
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return "", ErrPathFormat
+			}
+
+			list, ok := current.([]any)
+			if !ok || index < 0 || index >= len(list) {
+				return "", ErrPathNotFound
+			}
+
+			current, rest = list[index], rest[end+1:]
+		case rest[0] == '.':
+			rest = rest[1:]
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+
+			object, ok := current.(map[string]any)
+			if !ok {
+				return "", ErrPathNotFound
+			}
+
+			current, ok = object[rest[:end]]
+			if !ok {
+				return "", ErrPathNotFound
+			}
+
+			rest = rest[end:]
+		}
+	}
+
+	if text, ok := current.(string); ok {
+		return text, nil
+	}
+
+	data, err := sonic.Marshal(current)
+	return string(data), err
+}
@@ -0,0 +1,62 @@
+package deployctl
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	RetryError struct {
+		Script  string
+		Host    string
+		Attempt int
+		Retries int
+		Delay   time.Duration
+		Err     error
+	}
+)
+
+func (err *RetryError) Error() string {
+	return "script " + models.Label(err.Script, err.Host) + " failed, attempt " + strconv.Itoa(err.Attempt) + " of " + strconv.Itoa(err.Retries+1) + ", retrying in " + err.Delay.String() + ": " + err.Err.Error()
+}
+
+func (err *RetryError) Unwrap() error {
+	return err.Err
+}
+
+func (ctx *Context) retry(scope context.Context, script *models.Script, result *Result, attempt func() error) error {
+	delay := script.RetryDelay.Duration
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+
+	backoff := max(script.RetryBackoff, 1)
+	retries := max(script.Retries, 0)
+
+	for {
+		result.Attempts++
+		result.Diff = ""
+
+		err := attempt()
+		if err == nil || result.Attempts > retries || scope.Err() != nil || errors.Is(err, ErrAborted) {
+			return err
+		}
+
+		ctx.warn(&RetryError{Script: result.Name, Host: result.Host, Attempt: result.Attempts, Retries: retries, Delay: delay, Err: result.redactor.error(err)})
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-scope.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		delay = time.Duration(float64(delay) * backoff)
+	}
+}
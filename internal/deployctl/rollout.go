@@ -0,0 +1,76 @@
+package deployctl
+
+import (
+	"errors"
+	"time"
+)
+
+type (
+	Approver func(wave, waves int, hosts []string) (bool, error)
+)
+
+var ErrApprovalRequired = errors.New("rollout requires approval between waves but no approver is configured")
+
+func (ctx *Context) OnApprove(approver Approver) {
+	ctx.approver = approver
+}
+
+func waves(hosts []string, size int) [][]string {
+	batches := [][]string(nil)
+
+	for len(hosts) > size {
+		batches = append(batches, hosts[:size])
+		hosts = hosts[size:]
+	}
+
+	return append(batches, hosts)
+}
+
+func (ctx *Context) betweenWaves(run *execution, wave, total int, hosts []string) (failed []error, err error) {
+	rollout := &run.deploy.Rollout
+
+	if rollout.Check != "" {
+		for _, host := range hosts {
+			checkFailed, err := ctx.processHost(run, host, true, []string{rollout.Check})
+			failed = append(failed, checkFailed...)
+
+			if err != nil || len(failed) > 0 {
+				return failed, err
+			}
+		}
+	}
+
+	if ctx.dryRun {
+		return failed, nil
+	}
+
+	if rollout.Pause.Duration > 0 {
+		timer := time.NewTimer(rollout.Pause.Duration)
+
+		select {
+		case <-timer.C:
+		case <-run.scope.Done():
+			timer.Stop()
+			return failed, run.scope.Err()
+		}
+	}
+
+	if !rollout.Approve {
+		return failed, nil
+	}
+
+	if ctx.approver == nil {
+		return failed, ErrApprovalRequired
+	}
+
+	approved, err := ctx.approver(wave, total, hosts)
+	if err != nil {
+		return failed, err
+	}
+
+	if !approved {
+		return failed, ErrAborted
+	}
+
+	return failed, nil
+}
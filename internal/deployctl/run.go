@@ -0,0 +1,198 @@
+package deployctl
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+func (ctx *Context) run(scope context.Context, script *models.Script, resolver *variables.Resolver, target *sshTarget, result *Result) error {
+	run := script.Run
+
+	environment, path, arguments, directory, err := ctx.resolveRun(script, resolver, target)
+	if err != nil {
+		return err
+	}
+
+	throttle := throttleFor(path, &run.Throttle)
+	input := []byte(nil)
+
+	if target != nil {
+		arguments, input = target.command(path, arguments, directory, environment)
+		path, directory, environment = "ssh", "", nil
+	}
+
+	for attempt := 0; ; attempt++ {
+		output, err := ctx.runCommand(scope, script, path, arguments, directory, environment, input, result)
+		result.Output = decode(run.Encoding, output)
+
+		wait, retry := throttle.backoff(attempt, output, err)
+		if !retry {
+			result.Changed = err == nil
+			return err
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-scope.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (ctx *Context) runCommand(scope context.Context, script *models.Script, path string, arguments []string, directory string, environment []string, input []byte, result *Result) ([]byte, error) {
+	run := script.Run
+
+	capture, err := NewCapture(run.OutputLimit, run.SpillOutput)
+	if err != nil {
+		return nil, err
+	}
+
+	stdout := io.Writer(capture)
+
+	var structured *Capture
+	if len(script.Register) > 0 {
+		structured, err = NewCapture(run.OutputLimit, false)
+		if err != nil {
+			return nil, err
+		}
+
+		stdout = io.MultiWriter(capture, structured)
+	}
+
+	stderr := io.Writer(capture)
+
+	if ctx.streams(script) {
+		streamOut, streamErr := ctx.stream(result, run.Encoding), ctx.stream(result, run.Encoding)
+		defer streamOut.Close()
+		defer streamErr.Close()
+
+		stdout, stderr = io.MultiWriter(stdout, streamOut), io.MultiWriter(stderr, streamErr)
+		result.Streamed = true
+	}
+
+	args := append([]string{path}, arguments...)
+
+	return ctx.effect("run", args, func() ([]byte, error) {
+		command := exec.CommandContext(scope, path, arguments...)
+		command.Dir = directory
+		ctx.graceful(command, run.KillGrace)
+		command.Env = append(os.Environ(), environment...)
+		command.Stdout = stdout
+
+		if input != nil {
+			command.Stdin = bytes.NewReader(input)
+		}
+
+		command.Stderr = stderr
+
+		err := command.Run()
+		result.Usage = processUsage(command.ProcessState)
+
+		closeErr := capture.Close()
+		if err == nil {
+			err = closeErr
+		}
+
+		if structured != nil {
+			structured.Close()
+			result.stdout = []byte(decode(run.Encoding, structured.Bytes()))
+		}
+
+		return capture.Bytes(), err
+	})
+}
+
+func (ctx *Context) resolveRun(script *models.Script, resolver *variables.Resolver, target *sshTarget) (environment []string, path string, arguments []string, directory string, err error) {
+	run := script.Run
+
+	environment, err = resolver.Environment(script.Environment)
+	if err != nil {
+		return nil, "", nil, "", err
+	}
+
+	if run.Locale != "" {
+		environment = append(environment, "LANG="+run.Locale, "LC_ALL="+run.Locale)
+	}
+
+	name, arguments, directory := run.Path, run.Args, run.Directory
+
+	if interpreter := strings.Fields(run.Interpreter); len(interpreter) > 0 {
+		name, arguments = interpreter[0], append(append(interpreter[1:], run.Path), run.Args...)
+	}
+
+	if target != nil {
+		if len(run.SearchPath) > 0 && !strings.ContainsRune(name, '/') {
+			name, arguments = "sh", append([]string{"-c", `PATH="$1:$PATH"; shift; exec "$@"`, "sh", strings.Join(run.SearchPath, ":"), name}, arguments...)
+		}
+
+		return environment, name, arguments, directory, nil
+	}
+
+	if ctx.container {
+		name, arguments = containerCommand(name, arguments)
+	}
+
+	path, err = lookPath(name, run.SearchPath)
+	if err != nil {
+		return nil, "", nil, "", err
+	}
+
+	return environment, path, arguments, directory, nil
+}
+
+func lookPath(name string, directories []string) (string, error) {
+	if !strings.ContainsRune(name, '/') {
+		for _, directory := range directories {
+			path, err := exec.LookPath(filepath.Join(directory, name))
+			if err == nil {
+				return path, nil
+			}
+		}
+	}
+
+	return exec.LookPath(name)
+}
+
+func (ctx *Context) planRun(script *models.Script, resolver *variables.Resolver, target *sshTarget) (plan []string, err error) {
+	_, path, arguments, directory, err := ctx.resolveRun(script, resolver, target)
+	if err != nil {
+		return nil, err
+	}
+
+	command := "run " + strings.Join(append([]string{path}, arguments...), " ")
+
+	if target != nil {
+		if directory != "" {
+			command += " in " + directory
+		}
+
+		return []string{command + " on " + target.host}, nil
+	}
+
+	if directory != "" {
+		info, err := os.Stat(directory)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			return nil, &NotDirectoryError{Path: directory}
+		}
+
+		command += " in " + directory
+	}
+
+	return []string{command}, nil
+}
@@ -0,0 +1,250 @@
+package deployctl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+type (
+	secretProvider interface {
+		fetch(scope context.Context, deploy *models.Deploy, secret *models.Secret) ([]byte, error)
+	}
+
+	envSecrets struct{}
+
+	fileSecrets struct {
+		decrypted map[string][]byte
+	}
+
+	vaultSecrets struct {
+		ctx *Context
+	}
+
+	SecretError struct {
+		Name     string
+		Provider string
+		Err      error
+	}
+
+	SecretKeyError struct {
+		Key string
+	}
+)
+
+const (
+	VaultAddress = "VAULT_ADDR"
+	VaultToken   = "VAULT_TOKEN"
+
+	vaultNamespace = "VAULT_NAMESPACE"
+	ageSuffix      = ".age"
+	vaultLimit     = 1 << 20
+)
+
+var (
+	ErrSecretUnset = errors.New("environment variable is not set")
+	ErrVaultUnset  = errors.New(VaultAddress + " and " + VaultToken + " must be set")
+)
+
+func (err *SecretError) Error() string {
+	return "secret " + err.Name + " from " + err.Provider + ": " + err.Err.Error()
+}
+
+func (err *SecretError) Unwrap() error {
+	return err.Err
+}
+
+func (err *SecretKeyError) Error() string {
+	return "key " + err.Key + " is not a string in the decrypted secrets"
+}
+
+func (ctx *Context) LoadSecrets(scope context.Context, deploy *models.Deploy, resolver *variables.Resolver) error {
+	_, err := ctx.loadSecrets(scope, deploy, resolver)
+	return err
+}
+
+func (ctx *Context) loadSecrets(scope context.Context, deploy *models.Deploy, resolver *variables.Resolver) (patterns []string, err error) {
+	if len(deploy.Secrets) == 0 {
+		return nil, nil
+	}
+
+	providers := map[string]secretProvider{
+		models.SecretEnv:   envSecrets{},
+		models.SecretFile:  &fileSecrets{decrypted: make(map[string][]byte)},
+		models.SecretVault: vaultSecrets{ctx: ctx},
+	}
+
+	names := make([]string, 0, len(deploy.Secrets))
+	for name := range deploy.Secrets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		secret := deploy.Secrets[name]
+		provider := secret.Provider()
+
+		document, err := ctx.effect(secretEffect, []string{name, provider}, func() ([]byte, error) {
+			document, err := providers[provider].fetch(scope, deploy, secret)
+			if err == nil && secret.Key != "" {
+				document, err = secretKey(document, secret.Key)
+			}
+
+			return document, err
+		})
+
+		if err != nil {
+			return nil, &SecretError{Name: name, Provider: provider, Err: err}
+		}
+
+		value := strings.TrimRight(string(document), "\r\n")
+		resolver.SetSecret(name, value)
+
+		if value != "" {
+			patterns = append(patterns, "("+regexp.QuoteMeta(value)+")")
+		}
+	}
+
+	return patterns, nil
+}
+
+func secretKey(document []byte, key string) ([]byte, error) {
+	values := map[string]any(nil)
+
+	err := sonic.Unmarshal(document, &values)
+	if err != nil {
+		return nil, err
+	}
+
+	value := any(values)
+
+	for _, part := range strings.Split(key, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, &SecretKeyError{Key: key}
+		}
+
+		value = object[part]
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return nil, &SecretKeyError{Key: key}
+	}
+
+	return []byte(text), nil
+}
+
+func (envSecrets) fetch(scope context.Context, deploy *models.Deploy, secret *models.Secret) ([]byte, error) {
+	value, ok := os.LookupEnv(secret.Env)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", secret.Env, ErrSecretUnset)
+	}
+
+	return []byte(value), nil
+}
+
+func (provider *fileSecrets) fetch(scope context.Context, deploy *models.Deploy, secret *models.Secret) ([]byte, error) {
+	key := secret.File + "\x00" + secret.Identity
+	if document, ok := provider.decrypted[key]; ok {
+		return document, nil
+	}
+
+	command := exec.CommandContext(scope, "sops", "--decrypt", "--output-type", "json", secret.File)
+
+	if strings.HasSuffix(secret.File, ageSuffix) {
+		args := []string{"--decrypt"}
+		if secret.Identity != "" {
+			args = append(args, "--identity", secret.Identity)
+		}
+
+		command = exec.CommandContext(scope, "age", append(args, secret.File)...)
+	}
+
+	stderr := new(bytes.Buffer)
+	command.Stderr = stderr
+
+	document, err := command.Output()
+	if err != nil && stderr.Len() > 0 {
+		return nil, fmt.Errorf("%s: %w: %s", command.Args[0], err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	provider.decrypted[key] = document
+	return document, nil
+}
+
+func (provider vaultSecrets) fetch(scope context.Context, deploy *models.Deploy, secret *models.Secret) ([]byte, error) {
+	address, token := os.Getenv(VaultAddress), os.Getenv(VaultToken)
+	if address == "" || token == "" {
+		return nil, ErrVaultUnset
+	}
+
+	client, err := provider.ctx.httpClient(deploy)
+	if err != nil {
+		return nil, err
+	}
+
+	url := strings.TrimRight(address, "/") + "/v1/" + strings.TrimLeft(secret.Vault, "/")
+
+	request, err := http.NewRequestWithContext(scope, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("X-Vault-Token", token)
+
+	if namespace := os.Getenv(vaultNamespace); namespace != "" {
+		request.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, &StatusError{URL: url, Status: response.Status, Code: response.StatusCode}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, vaultLimit))
+	if err != nil {
+		return nil, err
+	}
+
+	payload := struct {
+		Data map[string]any `json:"data"`
+	}{}
+
+	err = sonic.Unmarshal(body, &payload)
+	if err != nil {
+		return nil, err
+	}
+
+	data := payload.Data
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, versioned := data["metadata"]; versioned {
+			data = nested
+		}
+	}
+
+	return sonic.Marshal(data)
+}
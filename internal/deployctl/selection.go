@@ -0,0 +1,37 @@
+package deployctl
+
+import (
+	"slices"
+	"strings"
+)
+
+type (
+	selection map[string][]string
+)
+
+func parseSelection(labels []string) (names []string, only selection) {
+	only = make(selection, len(labels))
+
+	for _, label := range labels {
+		name, host, _ := strings.Cut(label, "@")
+
+		hosts, ok := only[name]
+		if !ok {
+			names = append(names, name)
+		}
+
+		if host == "" || (ok && hosts == nil) {
+			only[name] = nil
+			continue
+		}
+
+		only[name] = append(hosts, host)
+	}
+
+	return names, only
+}
+
+func (only selection) allows(name, host string) bool {
+	hosts := only[name]
+	return hosts == nil || slices.Contains(hosts, host)
+}
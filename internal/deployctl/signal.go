@@ -0,0 +1,73 @@
+package deployctl
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+const defaultKillGrace = 10 * time.Second
+
+func Interrupted(err error) bool {
+	return errors.Is(err, ErrAborted) || errors.Is(err, context.Canceled)
+}
+
+func (ctx *Context) graceful(command *exec.Cmd, grace models.Duration) {
+	delay := grace.Duration
+	if delay <= 0 {
+		delay = defaultKillGrace
+	}
+
+	command.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	command.WaitDelay = delay
+
+	command.Cancel = func() error {
+		group := command.Process.Pid
+
+		err := syscall.Kill(-group, syscall.SIGTERM)
+		if errors.Is(err, syscall.ESRCH) {
+			return os.ErrProcessDone
+		}
+
+		ctx.terminating(group, true)
+
+		time.AfterFunc(delay, func() {
+			syscall.Kill(-group, syscall.SIGKILL)
+			ctx.terminating(group, false)
+		})
+
+		return err
+	}
+}
+
+func (ctx *Context) terminating(group int, pending bool) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if !pending {
+		delete(ctx.killing, group)
+		return
+	}
+
+	if ctx.killing == nil {
+		ctx.killing = make(map[int]bool)
+	}
+
+	ctx.killing[group] = true
+}
+
+func (ctx *Context) reap() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	for group := range ctx.killing {
+		syscall.Kill(-group, syscall.SIGKILL)
+	}
+
+	ctx.killing = nil
+}
@@ -0,0 +1,457 @@
+package deployctl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	sshTarget struct {
+		host    string
+		remote  *models.Remote
+		options []string
+	}
+)
+
+const sshControlPersist = "60s"
+
+func (ctx *Context) sshTarget(deploy *models.Deploy, host string) (*sshTarget, error) {
+	if deploy.Target.Execution != models.ExecutionSSH || host == "" {
+		return nil, nil
+	}
+
+	return ctx.remoteTarget(deploy, host)
+}
+
+func (ctx *Context) scriptTarget(deploy *models.Deploy, script *models.Script, host string) (*sshTarget, error) {
+	target, err := ctx.sshTarget(deploy, host)
+	if err != nil || target == nil || script.Connection == "" {
+		return target, err
+	}
+
+	connection := deploy.Connections.Connections[script.Connection]
+	if connection == nil {
+		return target, nil
+	}
+
+	remote := *target.remote
+
+	if connection.User != "" {
+		remote.User = connection.User
+	}
+
+	if connection.Port > 0 {
+		remote.Port = connection.Port
+	}
+
+	if connection.Identity != "" {
+		remote.Identity = connection.Identity
+	}
+
+	return ctx.newTarget(deploy, host, &remote)
+}
+
+func (ctx *Context) remoteTarget(deploy *models.Deploy, host string) (*sshTarget, error) {
+	remote := deploy.Remotes.Remotes[host]
+	if remote == nil {
+		return nil, nil
+	}
+
+	return ctx.newTarget(deploy, host, remote)
+}
+
+func (ctx *Context) newTarget(deploy *models.Deploy, host string, remote *models.Remote) (*sshTarget, error) {
+	control, err := ctx.sshControl()
+	if err != nil {
+		return nil, err
+	}
+
+	options := []string{
+		"-o", "BatchMode=yes",
+		"-o", "ControlMaster=auto",
+		"-o", "ControlPath=" + control + "/%C",
+		"-o", "ControlPersist=" + sshControlPersist,
+		"-o", "User=" + remote.User,
+	}
+
+	if remote.Port > 0 {
+		options = append(options, "-o", "Port="+strconv.Itoa(remote.Port))
+	}
+
+	if remote.Identity != "" {
+		options = append(options, "-o", "IdentityFile="+remote.Identity, "-o", "IdentitiesOnly=yes")
+	}
+
+	if timeout := deploy.Rollout.ConnectTimeout.Duration; timeout > 0 {
+		options = append(options, "-o", "ConnectTimeout="+strconv.Itoa(int(max(timeout/time.Second, 1))))
+	}
+
+	return &sshTarget{host: host, remote: remote, options: options}, nil
+}
+
+func (ctx *Context) sshControl() (string, error) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.control != "" {
+		return ctx.control, nil
+	}
+
+	control, err := os.MkdirTemp("", "deployctl-ssh-")
+	if err != nil {
+		return "", err
+	}
+
+	ctx.control = control
+	ctx.redactEffects(redactor{regexp.MustCompile(regexp.QuoteMeta(control))})
+
+	return control, nil
+}
+
+func (target *sshTarget) command(path string, arguments []string, directory string, environment []string) (args []string, input []byte) {
+	words := []string(nil)
+
+	if directory != "" {
+		words = append(words, "cd", shellQuote(directory), "&&")
+	}
+
+	if len(environment) > 0 {
+		words = append(words, `eval "$(cat)"`, "&&")
+
+		for _, variable := range environment {
+			input = append(input, "export "+shellQuote(variable)+"\n"...)
+		}
+	}
+
+	words = append(words, shellQuote(path))
+
+	for _, argument := range arguments {
+		words = append(words, shellQuote(argument))
+	}
+
+	return target.args(strings.Join(words, " ")), input
+}
+
+func (target *sshTarget) args(command string) []string {
+	args := append([]string(nil), target.options...)
+	return append(args, target.remote.IPv4, "--", command)
+}
+
+func (ctx *Context) sshShell(scope context.Context, target *sshTarget, command string) ([]byte, error) {
+	args := target.args(command)
+
+	return ctx.effect("run", append([]string{"ssh"}, args...), func() ([]byte, error) {
+		ssh := exec.CommandContext(scope, "ssh", args...)
+		ctx.graceful(ssh, models.Duration{})
+
+		output, err := ssh.CombinedOutput()
+		if err != nil && len(output) > 0 {
+			return output, fmt.Errorf("%s: %w: %s", target.host, err, bytes.TrimSpace(output))
+		}
+
+		return output, err
+	})
+}
+
+func (ctx *Context) sshInput(scope context.Context, target *sshTarget, command string, input []byte) ([]byte, error) {
+	args := target.args(command)
+
+	return ctx.effect("run", append([]string{"ssh"}, args...), func() ([]byte, error) {
+		ssh := exec.CommandContext(scope, "ssh", args...)
+		ctx.graceful(ssh, models.Duration{})
+		ssh.Stdin = bytes.NewReader(input)
+
+		output, err := ssh.CombinedOutput()
+		if err != nil && len(output) > 0 {
+			return output, fmt.Errorf("%s: %w: %s", target.host, err, bytes.TrimSpace(output))
+		}
+
+		return output, err
+	})
+}
+
+func (ctx *Context) sftp(scope context.Context, target *sshTarget, batch []string) error {
+	args := append(append([]string{"-b", "-"}, target.options...), target.remote.IPv4)
+
+	_, err := ctx.effect("sftp", append([]string{target.host}, batch...), func() ([]byte, error) {
+		sftp := exec.CommandContext(scope, "sftp", args...)
+		ctx.graceful(sftp, models.Duration{})
+		sftp.Stdin = strings.NewReader(strings.Join(batch, "\n") + "\n")
+
+		output, err := sftp.CombinedOutput()
+		if err != nil && len(output) > 0 {
+			return output, fmt.Errorf("%s: %w: %s", target.host, err, bytes.TrimSpace(output))
+		}
+
+		return output, err
+	})
+
+	return err
+}
+
+func (ctx *Context) upload(scope context.Context, deploy *models.Deploy, target *sshTarget, from, to string, preservePath bool, ifExists models.IfExists, exclude []string, sum string, atomic, distribute bool, attributes attributes, remove bool, result *Result) error {
+	list, err := transfers(deploy.Folder, from, to, preservePath)
+	if err != nil {
+		return err
+	}
+
+	release := func(string) {}
+	defer func() { release("") }()
+
+	for _, transfer := range list {
+		info, err := os.Stat(transfer.From)
+		if err != nil {
+			return err
+		}
+
+		err = verifyChecksum(transfer.From, sum)
+		if err != nil {
+			return err
+		}
+
+		destination := path.Clean(transfer.To)
+		partial := destination + ".part"
+
+		output, err := ctx.sshShell(scope, target, "mkdir -p "+shellQuote(path.Dir(destination))+" && rm -rf "+shellQuote(partial)+" && if [ -e "+shellQuote(destination)+" ] || [ -L "+shellQuote(destination)+" ]; then echo exists; fi")
+		if err != nil {
+			return err
+		}
+
+		exists := strings.TrimSpace(string(output)) == "exists"
+
+		switch {
+		case exists && ifExists == models.IfExistsSkip:
+			continue
+		case exists && ifExists == models.IfExistsFail:
+			return &ExistsError{Path: target.host + ":" + destination}
+		}
+
+		if exists && !remove && info.Mode().IsRegular() {
+			mode := info.Mode().Perm()
+			if attributes.mode.Set {
+				mode = attributes.mode.FileMode.Perm()
+			}
+
+			same, err := ctx.sameRemote(scope, target, transfer.From, destination, info, mode)
+			if err != nil {
+				return err
+			}
+
+			if same {
+				if command := attributes.remote(destination); command != "" {
+					_, err = ctx.sshShell(scope, target, command)
+					if err != nil {
+						return err
+					}
+				}
+
+				continue
+			}
+		}
+
+		source, cleanup, err := stage(scope, transfer.From, info, exclude)
+		if err != nil {
+			return err
+		}
+
+		if distribute {
+			release, err = ctx.distribute(scope, deploy, target, swarmKey(transfer.From, info), source, partial)
+		} else {
+			err = ctx.put(scope, target, source, destination, partial, info, exists)
+		}
+
+		cleanup()
+		if err != nil {
+			return err
+		}
+
+		backup := ""
+		promote := "rm -rf " + shellQuote(destination) + " && mv " + shellQuote(partial) + " " + shellQuote(destination)
+		if atomic && !info.IsDir() {
+			promote = "sync " + shellQuote(partial) + " && mv -f " + shellQuote(partial) + " " + shellQuote(destination)
+		}
+
+		if exists && ifExists == models.IfExistsBackup {
+			backup = destination + ".bak." + time.Now().Format("20060102T150405")
+			promote = "mv " + shellQuote(destination) + " " + shellQuote(backup) + " && mv " + shellQuote(partial) + " " + shellQuote(destination)
+		}
+
+		_, err = ctx.sshShell(scope, target, promote)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case !exists:
+			result.onUndo(func() error {
+				_, err := ctx.sshShell(context.Background(), target, "rm -rf "+shellQuote(destination))
+				return err
+			})
+		case backup != "":
+			result.onUndo(func() error {
+				_, err := ctx.sshShell(context.Background(), target, "rm -rf "+shellQuote(destination)+" && mv "+shellQuote(backup)+" "+shellQuote(destination))
+				return err
+			})
+		}
+
+		if sum != "" && info.Mode().IsRegular() {
+			err = ctx.verifyRemote(scope, target, destination, sum)
+			if err != nil {
+				return err
+			}
+		}
+
+		if command := attributes.remote(destination); command != "" {
+			_, err = ctx.sshShell(scope, target, command)
+			if err != nil {
+				return err
+			}
+		}
+
+		release(destination)
+
+		if info.Mode().IsRegular() {
+			result.Usage.Written += info.Size()
+		}
+
+		if remove {
+			source := transfer.From
+
+			_, err = ctx.effect("remove", []string{source}, func() ([]byte, error) {
+				return nil, os.RemoveAll(source)
+			})
+			if err != nil {
+				return err
+			}
+
+			result.onUndo(func() error {
+				return ctx.sftp(context.Background(), target, []string{"get -rp " + sftpQuote(destination) + " " + sftpQuote(source)})
+			})
+		}
+
+		result.Changed = true
+	}
+
+	return nil
+}
+
+func (attributes attributes) remote(destination string) string {
+	commands := []string(nil)
+
+	if attributes.owner != "" {
+		commands = append(commands, "chown -hR "+shellQuote(attributes.owner)+" "+shellQuote(destination))
+	}
+
+	if attributes.mode.Set {
+		commands = append(commands, "find "+shellQuote(destination)+" -type f -exec chmod "+strconv.FormatUint(uint64(attributes.mode.Octal()), 8)+" {} +")
+	}
+
+	return strings.Join(commands, " && ")
+}
+
+func (ctx *Context) verifyRemote(scope context.Context, target *sshTarget, destination, sum string) error {
+	output, err := ctx.sshShell(scope, target, "sha256sum "+shellQuote(destination))
+	if err != nil {
+		return err
+	}
+
+	actual, _, _ := strings.Cut(strings.TrimSpace(string(output)), " ")
+	if !strings.EqualFold(actual, sum) {
+		return &ChecksumError{Source: target.host + ":" + destination, Expected: sum, Actual: actual}
+	}
+
+	return nil
+}
+
+func (ctx *Context) sameRemote(scope context.Context, target *sshTarget, source, destination string, info os.FileInfo, mode fs.FileMode) (bool, error) {
+	quoted := shellQuote(destination)
+
+	output, err := ctx.sshShell(scope, target, "if [ -f "+quoted+" ] && [ ! -L "+quoted+" ]; then stat -c '%s %a' "+quoted+" && sha256sum "+quoted+"; fi")
+	if err != nil {
+		return false, err
+	}
+
+	fields := strings.Fields(string(output))
+	if len(fields) < 3 || fields[0] != strconv.FormatInt(info.Size(), 10) || fields[1] != strconv.FormatUint(uint64(mode), 8) {
+		return false, nil
+	}
+
+	sum, err := checksumFile(source)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.EqualFold(fields[2], sum), nil
+}
+
+func stage(scope context.Context, from string, info os.FileInfo, exclude []string) (source string, cleanup func(), err error) {
+	if !info.IsDir() {
+		return from, func() {}, nil
+	}
+
+	directory, err := os.MkdirTemp("", "deployctl-upload-")
+	if err != nil {
+		return "", nil, err
+	}
+
+	cleanup = func() {
+		os.RemoveAll(directory)
+	}
+
+	source = filepath.Join(directory, filepath.Base(from))
+
+	_, err = copyTree(scope, from, source, exclude, false, attributes{}, new(Result))
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	return source, cleanup, nil
+}
+
+func planUpload(deploy *models.Deploy, target *sshTarget, from, to string, preservePath, distribute bool) (plan []string, err error) {
+	list, err := transfers(deploy.Folder, from, to, preservePath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, transfer := range list {
+		_, err = os.Stat(transfer.From)
+		if err != nil {
+			return nil, err
+		}
+
+		line := "upload " + transfer.From + " to " + target.host + ":" + path.Clean(transfer.To)
+		if distribute {
+			line += " through peers"
+		}
+
+		plan = append(plan, line)
+	}
+
+	return plan, nil
+}
+
+func shellQuote(value string) string {
+	if value != "" && strings.Trim(value, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789@%_-+=:,./") == "" {
+		return value
+	}
+
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+func sftpQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
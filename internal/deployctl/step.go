@@ -0,0 +1,34 @@
+package deployctl
+
+import (
+	"errors"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+type (
+	Step int
+
+	Stepper func(name string, script *models.Script, resolver *variables.Resolver) (Step, error)
+)
+
+const (
+	StepContinue Step = iota
+	StepSkip
+	StepAbort
+)
+
+var ErrAborted = errors.New("deploy aborted")
+
+func (ctx *Context) OnStep(stepper Stepper) {
+	ctx.stepper = stepper
+}
+
+func (ctx *Context) step(name string, script *models.Script, resolver *variables.Resolver) (Step, error) {
+	if ctx.stepper == nil {
+		return StepContinue, nil
+	}
+
+	return ctx.stepper(name, script, resolver)
+}
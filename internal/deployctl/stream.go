@@ -0,0 +1,70 @@
+package deployctl
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	Streamer func(name, host, line string)
+
+	lineWriter struct {
+		mutex  sync.Mutex
+		emit   func(line string)
+		buffer []byte
+	}
+)
+
+const streamMaxLine = 64 << 10
+
+func (ctx *Context) OnOutput(streamer Streamer) {
+	ctx.streamer = streamer
+}
+
+func (ctx *Context) streams(script *models.Script) bool {
+	return ctx.streamer != nil && !script.Run.Capture
+}
+
+func (ctx *Context) stream(result *Result, encoding models.Encoding) *lineWriter {
+	return &lineWriter{emit: func(line string) {
+		ctx.streamer(result.Name, result.Host, result.redactor.redact(decode(encoding, []byte(line))))
+	}}
+}
+
+func (writer *lineWriter) Write(data []byte) (int, error) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	writer.buffer = append(writer.buffer, data...)
+
+	for {
+		end := bytes.IndexByte(writer.buffer, '\n')
+		if end < 0 {
+			break
+		}
+
+		writer.emit(string(bytes.TrimSuffix(writer.buffer[:end], []byte{'\r'})))
+		writer.buffer = writer.buffer[end+1:]
+	}
+
+	if len(writer.buffer) >= streamMaxLine {
+		writer.emit(string(writer.buffer))
+		writer.buffer = nil
+	}
+
+	return len(data), nil
+}
+
+func (writer *lineWriter) Close() error {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	if len(writer.buffer) > 0 {
+		writer.emit(string(writer.buffer))
+		writer.buffer = nil
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package deployctl
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+func (ctx *Context) symlink(scope context.Context, symlink *models.ScriptSymlink, target *sshTarget, result *Result) error {
+	if target != nil {
+		return ctx.remoteSymlink(scope, symlink, target, result)
+	}
+
+	current, exists, err := readLink(symlink.Link)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case exists && current == symlink.Target:
+		return nil
+	case exists && current == "" && !symlink.Force:
+		return &ExistsError{Path: symlink.Link}
+	}
+
+	_, err = ctx.effect("symlink", []string{symlink.Target, symlink.Link}, func() ([]byte, error) {
+		return nil, await(scope, result, func() error {
+			err := makeDirectories(filepath.Dir(symlink.Link), 0o755, result.directories)
+			if err != nil {
+				return err
+			}
+
+			return swapLink(symlink.Target, symlink.Link, exists && current == "")
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !exists:
+		result.onUndo(func() error {
+			return os.Remove(symlink.Link)
+		})
+	case current != "":
+		result.onUndo(func() error {
+			return swapLink(current, symlink.Link, false)
+		})
+	}
+
+	result.Changed = true
+	return nil
+}
+
+func readLink(path string) (current string, exists bool, err error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", false, nil
+		}
+
+		return "", false, err
+	}
+
+	if info.Mode()&fs.ModeSymlink == 0 {
+		return "", true, nil
+	}
+
+	current, err = os.Readlink(path)
+	return current, true, err
+}
+
+func swapLink(target, link string, replace bool) error {
+	file, err := os.CreateTemp(filepath.Dir(link), "."+filepath.Base(link)+".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	temporary := file.Name()
+	file.Close()
+
+	err = os.Remove(temporary)
+	if err == nil {
+		err = os.Symlink(target, temporary)
+	}
+
+	if err == nil && replace {
+		err = os.RemoveAll(link)
+	}
+
+	if err == nil {
+		err = os.Rename(temporary, link)
+	}
+
+	if err != nil {
+		os.Remove(temporary)
+	}
+
+	return err
+}
+
+func (ctx *Context) remoteSymlink(scope context.Context, symlink *models.ScriptSymlink, target *sshTarget, result *Result) error {
+	current, exists, err := ctx.remoteLink(scope, target, symlink.Link)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case exists && current == symlink.Target:
+		return nil
+	case exists && current == "" && !symlink.Force:
+		return &ExistsError{Path: target.host + ":" + symlink.Link}
+	}
+
+	_, err = ctx.sshShell(scope, target, remoteSwap(symlink.Target, symlink.Link, exists && current == ""))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !exists:
+		result.onUndo(func() error {
+			_, err := ctx.sshShell(context.Background(), target, "rm -f "+shellQuote(symlink.Link))
+			return err
+		})
+	case current != "":
+		result.onUndo(func() error {
+			_, err := ctx.sshShell(context.Background(), target, remoteSwap(current, symlink.Link, false))
+			return err
+		})
+	}
+
+	result.Changed = true
+	return nil
+}
+
+func (ctx *Context) remoteLink(scope context.Context, target *sshTarget, link string) (current string, exists bool, err error) {
+	quoted := shellQuote(link)
+
+	output, err := ctx.sshShell(scope, target, "if [ -L "+quoted+" ]; then printf 'link:%s' \"$(readlink "+quoted+")\"; elif [ -e "+quoted+" ]; then echo exists; fi")
+	if err != nil {
+		return "", false, err
+	}
+
+	text := strings.TrimRight(string(output), "\n")
+	if current, ok := strings.CutPrefix(text, "link:"); ok {
+		return current, true, nil
+	}
+
+	return "", text == "exists", nil
+}
+
+func remoteSwap(target, link string, replace bool) string {
+	temporary := shellQuote(link) + ".tmp-$$"
+	command := "mkdir -p " + shellQuote(path.Dir(link)) + " && ln -sfn " + shellQuote(target) + " " + temporary + " && "
+
+	if replace {
+		command += "rm -rf " + shellQuote(link) + " && "
+	}
+
+	return command + "mv -Tf " + temporary + " " + shellQuote(link)
+}
+
+func planSymlink(symlink *models.ScriptSymlink, target *sshTarget) (plan []string, err error) {
+	if target != nil {
+		return []string{"link " + symlink.Link + " to " + symlink.Target + " on " + target.host}, nil
+	}
+
+	current, exists, err := readLink(symlink.Link)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case exists && current == symlink.Target:
+		return []string{symlink.Link + " already links to " + symlink.Target}, nil
+	case exists && current == "" && !symlink.Force:
+		return nil, &ExistsError{Path: symlink.Link}
+	}
+
+	err = writable(filepath.Dir(symlink.Link))
+	if err != nil {
+		return nil, err
+	}
+
+	if exists && current == "" {
+		return []string{"replace " + symlink.Link + " with a link to " + symlink.Target}, nil
+	}
+
+	return []string{"link " + symlink.Link + " to " + symlink.Target}, nil
+}
@@ -0,0 +1,157 @@
+package deployctl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+var templateFunctions = template.FuncMap{
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"trim":    strings.TrimSpace,
+	"replace": strings.ReplaceAll,
+	"split":   strings.Split,
+	"join":    func(separator string, values []string) string { return strings.Join(values, separator) },
+	"quote":   strconv.Quote,
+	"default": func(fallback, value string) string {
+		if value == "" {
+			return fallback
+		}
+
+		return value
+	},
+}
+
+func (ctx *Context) template(scope context.Context, deploy *models.Deploy, tmpl *models.ScriptTemplate, resolver *variables.Resolver, result *Result) error {
+	content, cached, err := ctx.render(deploy, tmpl, resolver, result.Host)
+	if err != nil {
+		return err
+	}
+
+	result.Cached = cached
+
+	undo, err := snapshot(tmpl.To)
+	if err != nil {
+		return err
+	}
+
+	_, err = ctx.effect("template", []string{tmpl.Source, tmpl.To}, func() ([]byte, error) {
+		return nil, await(scope, result, func() error {
+			changed, err := ensureContent(tmpl.To, content, result)
+			if err != nil {
+				return err
+			}
+
+			attributes, err := applyAttributes(tmpl.To, tmpl.Mode, joinOwner(tmpl.Owner, tmpl.Group))
+			result.Changed = changed || attributes
+
+			if result.Changed && undo != nil {
+				result.onUndo(undo)
+			}
+
+			return err
+		})
+	})
+
+	return err
+}
+
+func (ctx *Context) render(deploy *models.Deploy, tmpl *models.ScriptTemplate, resolver *variables.Resolver, host string) (content []byte, cached bool, err error) {
+	source, err := os.ReadFile(tmpl.Source)
+	if err != nil {
+		return nil, false, err
+	}
+
+	data := templateData(deploy, tmpl, resolver, host)
+
+	cache := ""
+	if tmpl.Cache {
+		cache, err = ctx.resultPath("template", string(source), data)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	if cachedResult(cache) {
+		content, err = os.ReadFile(cache)
+		return content, err == nil, err
+	}
+
+	parsed, err := template.New(filepath.Base(tmpl.Source)).Funcs(templateFunctions).Option("missingkey=error").Parse(string(source))
+	if err != nil {
+		return nil, false, err
+	}
+
+	output := bytes.Buffer{}
+
+	err = parsed.Execute(&output, data)
+	if err != nil {
+		return nil, false, err
+	}
+
+	ctx.storeResult(cache, func(path string) error {
+		return writeFileAtomic(path, output.Bytes(), 0o644)
+	})
+
+	return output.Bytes(), false, nil
+}
+
+func templateData(deploy *models.Deploy, tmpl *models.ScriptTemplate, resolver *variables.Resolver, host string) map[string]any {
+	environment := map[string]string{}
+	for _, pair := range os.Environ() {
+		name, value, ok := strings.Cut(pair, "=")
+		if ok {
+			environment[name] = value
+		}
+	}
+
+	data := tmpl.Data
+	if data == nil {
+		data = map[string]string{}
+	}
+
+	return map[string]any{
+		"Vars":        resolver.Values(),
+		"Env":         environment,
+		"Data":        data,
+		"Host":        host,
+		"Remote":      deploy.Remotes.Remotes[host],
+		"Environment": deploy.Target.Environment,
+		"Stage":       deploy.Target.Stage,
+	}
+}
+
+func (ctx *Context) planTemplate(deploy *models.Deploy, tmpl *models.ScriptTemplate, resolver *variables.Resolver, host string, result *Result) (plan []string, err error) {
+	content, _, err := ctx.render(deploy, tmpl, resolver, host)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := os.ReadFile(tmpl.To)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		err = writable(filepath.Dir(tmpl.To))
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{"render " + tmpl.Source + " to " + tmpl.To}, nil
+	case err != nil:
+		return nil, err
+	case bytes.Equal(current, content):
+		return []string{tmpl.To + " is up to date"}, nil
+	}
+
+	result.Diff = textDiff(tmpl.To, current, content)
+
+	return []string{"render " + tmpl.Source + " over " + tmpl.To}, nil
+}
@@ -0,0 +1,113 @@
+package deployctl
+
+import (
+	"math/rand/v2"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	throttle struct {
+		retries  int
+		delay    time.Duration
+		maxDelay time.Duration
+		patterns []string
+	}
+)
+
+const (
+	defaultThrottleRetries  = 5
+	defaultThrottleDelay    = time.Second
+	defaultThrottleMaxDelay = time.Minute
+)
+
+var (
+	throttleRetryAfter = regexp.MustCompile(`(?i)retry[- _]?after\W{0,3}(\d+)`)
+
+	throttleGeneric = []string{"429", "too many requests", "toomanyrequests", "rate limit", "ratelimit"}
+
+	throttleProviders = map[string][]string{
+		"aws":     {"throttling", "requestlimitexceeded", "slowdown", "rate exceeded", "provisionedthroughputexceeded"},
+		"docker":  {"pull rate limit"},
+		"podman":  {"pull rate limit"},
+		"crane":   nil,
+		"skopeo":  nil,
+		"kubectl": {"the server has received too many requests"},
+		"helm":    {"the server has received too many requests"},
+		"gcloud":  {"ratelimitexceeded", "resource_exhausted", "quota exceeded"},
+		"gsutil":  {"ratelimitexceeded", "resource_exhausted"},
+		"az":      {"toomanyrequests", "retry after"},
+	}
+)
+
+func throttleFor(path string, config *models.Throttle) *throttle {
+	patterns := config.Patterns
+	provider, known := throttleProviders[filepath.Base(path)]
+
+	if known {
+		patterns = append(append(patterns, throttleGeneric...), provider...)
+	}
+
+	throttle := &throttle{
+		retries:  config.Retries,
+		delay:    config.Delay.Duration,
+		maxDelay: config.MaxDelay.Duration,
+	}
+
+	for _, pattern := range patterns {
+		throttle.patterns = append(throttle.patterns, strings.ToLower(pattern))
+	}
+
+	if throttle.retries == 0 && len(throttle.patterns) > 0 {
+		throttle.retries = defaultThrottleRetries
+	}
+
+	if throttle.delay <= 0 {
+		throttle.delay = defaultThrottleDelay
+	}
+
+	if throttle.maxDelay <= 0 {
+		throttle.maxDelay = defaultThrottleMaxDelay
+	}
+
+	return throttle
+}
+
+func (throttle *throttle) backoff(attempt int, output []byte, err error) (wait time.Duration, retry bool) {
+	if err == nil || attempt >= throttle.retries || !throttle.throttled(output) {
+		return 0, false
+	}
+
+	ceiling := throttle.delay
+	for i := 0; i < attempt && ceiling < throttle.maxDelay; i++ {
+		ceiling *= 2
+	}
+
+	wait = rand.N(min(ceiling, throttle.maxDelay)) + 1
+
+	if match := throttleRetryAfter.FindSubmatch(output); match != nil {
+		seconds, err := strconv.Atoi(string(match[1]))
+		if err == nil {
+			wait = max(wait, min(time.Duration(seconds)*time.Second, throttle.maxDelay))
+		}
+	}
+
+	return wait, true
+}
+
+func (throttle *throttle) throttled(output []byte) bool {
+	text := strings.ToLower(string(output))
+
+	for _, pattern := range throttle.patterns {
+		if strings.Contains(text, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
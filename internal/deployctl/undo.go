@@ -0,0 +1,183 @@
+package deployctl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	RollbackError struct {
+		Name string
+		Host string
+		Err  error
+	}
+)
+
+func (err *RollbackError) Error() string {
+	return "rollback " + models.Label(err.Name, err.Host) + ": " + err.Err.Error()
+}
+
+func (err *RollbackError) Unwrap() error {
+	return err.Err
+}
+
+func (result *Result) onUndo(undo func() error) {
+	result.undo = append(result.undo, undo)
+}
+
+func snapshot(path string) (func() error, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return func() error {
+				return os.RemoveAll(path)
+			}, nil
+		}
+
+		return nil, err
+	}
+
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		link, err := os.Readlink(path)
+		if err != nil {
+			return nil, err
+		}
+
+		return func() error {
+			err := os.RemoveAll(path)
+			if err != nil {
+				return err
+			}
+
+			return os.Symlink(link, path)
+		}, nil
+	case info.Mode().IsRegular():
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		return func() error {
+			err := os.RemoveAll(path)
+			if err != nil {
+				return err
+			}
+
+			return writeFileAtomic(path, content, info.Mode().Perm())
+		}, nil
+	}
+
+	return nil, nil
+}
+
+func (ctx *Context) rollback(run *execution, tasks []*task) (failed []error) {
+	for i := len(tasks) - 1; i >= 0; i-- {
+		task := tasks[i]
+		result := task.result
+
+		if result.Err != nil || !result.Changed || (len(result.undo) == 0 && !hasRollback(task.script)) {
+			continue
+		}
+
+		_, err := ctx.effect("rollback", []string{result.Label()}, func() ([]byte, error) {
+			return nil, ctx.undo(run, task)
+		})
+		if err != nil {
+			failed = append(failed, &RollbackError{Name: task.name, Host: result.Host, Err: err})
+			continue
+		}
+
+		result.RolledBack = true
+	}
+
+	return failed
+}
+
+func (ctx *Context) undo(run *execution, task *task) error {
+	if hasRollback(task.script) {
+		scope, cancel := withTimeout(run.scope, task.script.Timeout)
+		defer cancel()
+
+		err := ctx.rollbackRun(scope, run.deploy, task)
+		if err != nil {
+			return err
+		}
+	}
+
+	undo := task.result.undo
+
+	for i := len(undo) - 1; i >= 0; i-- {
+		err := undo[i]()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func hasRollback(script *models.Script) bool {
+	return script.Run != nil && len(script.Run.Rollback) > 0
+}
+
+func (ctx *Context) rollbackRun(scope context.Context, deploy *models.Deploy, task *task) error {
+	script, resolver := task.script, task.resolver
+
+	environment, err := resolver.Environment(script.Environment)
+	if err != nil {
+		return err
+	}
+
+	directory := script.Run.Directory
+
+	name, arguments := script.Run.Rollback[0], script.Run.Rollback[1:]
+
+	target, err := ctx.scriptTarget(deploy, script, task.result.Host)
+	if err != nil {
+		return err
+	}
+
+	input := []byte(nil)
+
+	if target != nil {
+		arguments, input = target.command(name, arguments, directory, environment)
+		name, directory, environment = "ssh", "", nil
+	}
+
+	if ctx.container {
+		name, arguments = containerCommand(name, arguments)
+	}
+
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = ctx.effect("run", append([]string{path}, arguments...), func() ([]byte, error) {
+		command := exec.CommandContext(scope, path, arguments...)
+		command.Dir = directory
+		ctx.graceful(command, script.Run.KillGrace)
+		command.Env = append(os.Environ(), environment...)
+
+		if input != nil {
+			command.Stdin = bytes.NewReader(input)
+		}
+
+		output, err := command.CombinedOutput()
+		if err != nil && len(output) > 0 {
+			return output, fmt.Errorf("%w: %s", err, bytes.TrimSpace(output))
+		}
+
+		return output, err
+	})
+
+	return err
+}
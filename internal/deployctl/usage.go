@@ -0,0 +1,49 @@
+package deployctl
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	Usage struct {
+		CPU     time.Duration
+		MaxRSS  int64
+		Read    int64
+		Written int64
+	}
+)
+
+func processUsage(state *os.ProcessState) Usage {
+	if state == nil {
+		return Usage{}
+	}
+
+	usage := Usage{
+		CPU: state.UserTime() + state.SystemTime(),
+	}
+
+	if rusage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		usage.MaxRSS = rusage.Maxrss << 10
+		usage.Read = rusage.Inblock * 512
+		usage.Written = rusage.Oublock * 512
+	}
+
+	return usage
+}
+
+func (usage Usage) String() string {
+	return fmt.Sprintf("cpu %s, max rss %s, read %s, written %s", usage.CPU, bytesString(usage.MaxRSS), bytesString(usage.Read), bytesString(usage.Written))
+}
+
+func (usage Usage) IsZero() bool {
+	return usage == Usage{}
+}
+
+func bytesString(size int64) string {
+	return models.Size(size).String()
+}
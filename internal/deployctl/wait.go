@@ -0,0 +1,167 @@
+package deployctl
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	WaitError struct {
+		Target  string
+		Timeout time.Duration
+		Err     error
+	}
+
+	BodyError struct {
+		URL  string
+		Body string
+	}
+)
+
+const (
+	defaultWaitInterval = time.Second
+	defaultWaitTimeout  = time.Minute
+
+	waitBodyLimit = 1 << 20
+)
+
+func (err *WaitError) Error() string {
+	return err.Target + " not ready after " + err.Timeout.String() + ": " + err.Err.Error()
+}
+
+func (err *WaitError) Unwrap() error {
+	return err.Err
+}
+
+func (err *BodyError) Error() string {
+	return "response of " + err.URL + " does not contain " + strconv.Quote(err.Body)
+}
+
+func waitTarget(wait *models.ScriptWait) string {
+	if wait.URL != "" {
+		return wait.URL
+	}
+
+	return wait.Address
+}
+
+func (ctx *Context) wait(scope context.Context, deploy *models.Deploy, wait *models.ScriptWait) error {
+	client, err := ctx.httpClient(deploy)
+	if err != nil {
+		return err
+	}
+
+	interval := wait.Interval.Duration
+	if interval <= 0 {
+		interval = defaultWaitInterval
+	}
+
+	timeout := wait.Timeout.Duration
+	if timeout <= 0 {
+		timeout = defaultWaitTimeout
+	}
+
+	target := waitTarget(wait)
+
+	_, err = ctx.effect("wait", []string{target}, func() ([]byte, error) {
+		deadline, cancel := context.WithTimeout(scope, timeout)
+		defer cancel()
+
+		expiry, _ := deadline.Deadline()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		last := error(nil)
+
+		for {
+			err := probeReady(deadline, client, wait)
+			if err == nil {
+				return nil, nil
+			}
+
+			if last == nil || time.Now().Before(expiry) {
+				last = err
+			}
+
+			select {
+			case <-ticker.C:
+			case <-deadline.Done():
+				if scope.Err() != nil {
+					return nil, scope.Err()
+				}
+
+				return nil, &WaitError{Target: target, Timeout: timeout, Err: last}
+			}
+		}
+	})
+
+	return err
+}
+
+func probeReady(scope context.Context, client *http.Client, wait *models.ScriptWait) error {
+	if wait.Address != "" {
+		connection, err := new(net.Dialer).DialContext(scope, "tcp", wait.Address)
+		if err != nil {
+			return err
+		}
+
+		return connection.Close()
+	}
+
+	request, err := http.NewRequestWithContext(scope, http.MethodGet, wait.URL, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	switch {
+	case wait.Status != 0 && response.StatusCode != wait.Status,
+		wait.Status == 0 && (response.StatusCode < 200 || response.StatusCode > 299):
+		return &StatusError{URL: wait.URL, Status: response.Status, Code: response.StatusCode}
+	case wait.Body == "":
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, waitBodyLimit))
+	if err != nil {
+		return err
+	}
+
+	if !strings.Contains(string(body), wait.Body) {
+		return &BodyError{URL: wait.URL, Body: wait.Body}
+	}
+
+	return nil
+}
+
+func planWait(wait *models.ScriptWait) (plan []string, err error) {
+	if wait.URL != "" {
+		_, err = url.ParseRequestURI(wait.URL)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{"wait for " + wait.URL + " to respond"}, nil
+	}
+
+	_, _, err = net.SplitHostPort(wait.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{"wait for " + wait.Address + " to accept connections"}, nil
+}
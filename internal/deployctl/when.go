@@ -0,0 +1,107 @@
+package deployctl
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/expr"
+	"github.com/gohryt/dotdeploy/internal/models"
+	"github.com/gohryt/dotdeploy/internal/variables"
+)
+
+type (
+	ConditionError struct {
+		Script string
+		Host   string
+		Err    error
+	}
+)
+
+var ErrExistsArgument = errors.New("exists takes one path")
+
+func (err *ConditionError) Error() string {
+	location := err.Script
+	if err.Host != "" {
+		location += " on " + err.Host
+	}
+
+	return "condition of script " + location + ": " + err.Err.Error()
+}
+
+func (err *ConditionError) Unwrap() error {
+	return err.Err
+}
+
+func (ctx *Context) when(scope context.Context, deploy *models.Deploy, name string, script *models.Script, host string, resolver *variables.Resolver) (bool, error) {
+	if script.When == "" {
+		return true, nil
+	}
+
+	expression, err := expr.Compile(script.When)
+	if err != nil {
+		return false, &ConditionError{Script: name, Host: host, Err: err}
+	}
+
+	target, err := ctx.scriptTarget(deploy, script, host)
+	if err != nil {
+		return false, &ConditionError{Script: name, Host: host, Err: err}
+	}
+
+	environment := map[string]any{}
+	for key, value := range resolver.Values() {
+		environment[key] = value
+	}
+
+	ok, err := expression.Test(map[string]any{
+		"env":         environment,
+		"host":        host,
+		"environment": deploy.Target.Environment,
+		"stage":       deploy.Target.Stage,
+		"exists": expr.Function(func(arguments ...any) (any, error) {
+			path, valid := "", len(arguments) == 1
+			if valid {
+				path, valid = arguments[0].(string)
+			}
+
+			if !valid {
+				return nil, ErrExistsArgument
+			}
+
+			path, err := resolver.Expand(path)
+			if err != nil {
+				return nil, err
+			}
+
+			return ctx.exists(scope, target, path)
+		}),
+	})
+	if err != nil {
+		return false, &ConditionError{Script: name, Host: host, Err: err}
+	}
+
+	return ok, nil
+}
+
+func (ctx *Context) exists(scope context.Context, target *sshTarget, path string) (bool, error) {
+	if target != nil {
+		output, err := ctx.sshShell(scope, target, "if [ -e "+shellQuote(path)+" ] || [ -L "+shellQuote(path)+" ]; then echo exists; fi")
+		if err != nil {
+			return false, err
+		}
+
+		return strings.TrimSpace(string(output)) == "exists", nil
+	}
+
+	_, err := os.Lstat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,59 @@
+package deployctl
+
+import (
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	WindowError struct {
+		Window string
+		Opens  time.Time
+		Closed bool
+	}
+
+	OverrunError struct {
+		Window    string
+		Estimate  time.Duration
+		Remaining time.Duration
+	}
+)
+
+func (err *WindowError) Error() string {
+	if err.Closed {
+		return "maintenance window " + err.Window + " closed during the run"
+	}
+
+	return "outside maintenance window " + err.Window + ", it opens at " + err.Opens.Format(time.RFC3339)
+}
+
+func (err *OverrunError) Error() string {
+	return "run is estimated to take " + err.Estimate.Round(time.Second).String() + " but maintenance window " + err.Window + " closes in " + err.Remaining.Round(time.Second).String()
+}
+
+func CheckOverrun(deploy *models.Deploy, estimate time.Duration, now time.Time) error {
+	if deploy.Window.IsZero() {
+		return nil
+	}
+
+	_, end, open := deploy.Window.Bounds(now)
+	if !open || !now.Add(estimate).After(end) {
+		return nil
+	}
+
+	return &OverrunError{Window: deploy.Window.Text, Estimate: estimate, Remaining: end.Sub(now)}
+}
+
+func openWindow(deploy *models.Deploy, now time.Time) (deadline time.Time, err error) {
+	if deploy.Window.IsZero() {
+		return time.Time{}, nil
+	}
+
+	start, end, open := deploy.Window.Bounds(now)
+	if !open {
+		return time.Time{}, &WindowError{Window: deploy.Window.Text, Opens: start}
+	}
+
+	return end, nil
+}
@@ -0,0 +1,229 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+type (
+	Kind int
+
+	Edit struct {
+		Kind Kind
+		From int
+		To   int
+		Line string
+	}
+)
+
+const (
+	Equal Kind = iota
+	Delete
+	Insert
+)
+
+const (
+	DefaultContext = 3
+	MaxLines       = 20000
+	MaxDistance    = 1000
+	TooLarge       = "changed (diff too large)\n"
+)
+
+func Lines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+func Text(data []byte) bool {
+	return !bytes.ContainsRune(data, 0)
+}
+
+func Edits(from, to []string) ([]Edit, bool) {
+	n, m := len(from), len(to)
+	if n > MaxLines || m > MaxLines {
+		return nil, false
+	}
+
+	limit := min(n+m, MaxDistance)
+	offset := limit + 1
+
+	v := make([]int, 2*limit+2)
+	trace := [][]int(nil)
+
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+
+		for k := -d; k <= d; k += 2 {
+			x := 0
+			if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+				x = v[k+1+offset]
+			} else {
+				x = v[k-1+offset] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && from[x] == to[y] {
+				x++
+				y++
+			}
+
+			v[k+offset] = x
+
+			if x >= n && y >= m {
+				return backtrack(from, to, trace), true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func backtrack(from, to []string, trace [][]int) []Edit {
+	x, y := len(from), len(to)
+	edits := []Edit(nil)
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		at := func(k int) int {
+			return v[k+d]
+		}
+
+		previousK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			previousK = k + 1
+		}
+
+		previousX := 0
+		if d > 0 {
+			previousX = at(previousK)
+		}
+
+		previousY := previousX - previousK
+
+		for x > previousX && y > previousY {
+			x--
+			y--
+			edits = append(edits, Edit{Kind: Equal, From: x, To: y, Line: from[x]})
+		}
+
+		if d > 0 {
+			if x == previousX {
+				edits = append(edits, Edit{Kind: Insert, From: x, To: previousY, Line: to[previousY]})
+			} else {
+				edits = append(edits, Edit{Kind: Delete, From: previousX, To: y, Line: from[previousX]})
+			}
+		}
+
+		x, y = previousX, previousY
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+
+	return edits
+}
+
+func Unified(fromName, toName string, from, to []byte, context int) string {
+	edits, ok := Edits(Lines(from), Lines(to))
+	if !ok {
+		return TooLarge
+	}
+
+	builder := new(strings.Builder)
+
+	for start := 0; start < len(edits); {
+		for start < len(edits) && edits[start].Kind == Equal {
+			start++
+		}
+
+		if start == len(edits) {
+			break
+		}
+
+		first := max(start-context, 0)
+		end := start
+
+		for i := start; i < len(edits); i++ {
+			if edits[i].Kind != Equal {
+				end = i + 1
+				continue
+			}
+
+			if i-end >= 2*context {
+				break
+			}
+		}
+
+		last := min(end+context, len(edits))
+
+		if builder.Len() == 0 {
+			fmt.Fprintf(builder, "--- %s\n+++ %s\n", fromName, toName)
+		}
+
+		writeHunk(builder, edits[first:last])
+		start = last
+	}
+
+	return builder.String()
+}
+
+func writeHunk(builder *strings.Builder, edits []Edit) {
+	fromStart, toStart := edits[0].From, edits[0].To
+	fromCount, toCount := 0, 0
+
+	for _, edit := range edits {
+		switch edit.Kind {
+		case Equal:
+			fromCount++
+			toCount++
+		case Delete:
+			fromCount++
+		case Insert:
+			toCount++
+		}
+	}
+
+	fmt.Fprintf(builder, "@@ -%s +%s @@\n", hunkRange(fromStart, fromCount), hunkRange(toStart, toCount))
+
+	for _, edit := range edits {
+		prefix := " "
+
+		switch edit.Kind {
+		case Delete:
+			prefix = "-"
+		case Insert:
+			prefix = "+"
+		}
+
+		builder.WriteString(prefix)
+		builder.WriteString(edit.Line)
+
+		if !strings.HasSuffix(edit.Line, "\n") {
+			builder.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
@@ -0,0 +1,731 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type (
+	Expression struct {
+		source string
+		root   node
+	}
+
+	ExpressionError struct {
+		Expression string
+		Position   int
+		Reason     string
+	}
+
+	Function func(arguments ...any) (any, error)
+
+	node func(scope map[string]any) (any, error)
+
+	token struct {
+		kind     tokenKind
+		text     string
+		value    any
+		position int
+	}
+
+	tokenKind int
+
+	parser struct {
+		source string
+		tokens []token
+		next   int
+	}
+)
+
+const (
+	tokenEnd tokenKind = iota
+	tokenName
+	tokenLiteral
+	tokenOperator
+)
+
+var ErrNotBoolean = errors.New("expression does not evaluate to true or false")
+
+func (err *ExpressionError) Error() string {
+	return "expression " + strconv.Quote(err.Expression) + " at " + strconv.Itoa(err.Position) + ": " + err.Reason
+}
+
+func Compile(source string) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &parser{source: source, tokens: tokens}
+
+	root, err := parser.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if current := parser.peek(); current.kind != tokenEnd {
+		return nil, parser.fail(current, "unexpected "+strconv.Quote(current.text))
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+func (expression *Expression) Test(scope map[string]any) (bool, error) {
+	value, err := expression.root(scope)
+	if err != nil {
+		return false, err
+	}
+
+	result, ok := value.(bool)
+	if !ok {
+		return false, ErrNotBoolean
+	}
+
+	return result, nil
+}
+
+func tokenize(source string) ([]token, error) {
+	tokens := []token(nil)
+
+	for i := 0; i < len(source); {
+		char := rune(source[i])
+
+		switch {
+		case unicode.IsSpace(char):
+			i++
+		case char == '"' || char == '\'':
+			end := i + 1
+			for end < len(source) && source[end] != byte(char) {
+				if source[end] == '\\' {
+					end++
+				}
+
+				end++
+			}
+
+			if end >= len(source) {
+				return nil, &ExpressionError{Expression: source, Position: i, Reason: "unterminated string"}
+			}
+
+			text := source[i+1 : end]
+			if char == '"' {
+				unquoted, err := strconv.Unquote(source[i : end+1])
+				if err != nil {
+					return nil, &ExpressionError{Expression: source, Position: i, Reason: err.Error()}
+				}
+
+				text = unquoted
+			}
+
+			tokens = append(tokens, token{kind: tokenLiteral, text: source[i : end+1], value: text, position: i})
+			i = end + 1
+		case unicode.IsDigit(char):
+			end := i
+			for end < len(source) && (unicode.IsDigit(rune(source[end])) || source[end] == '.') {
+				end++
+			}
+
+			number, err := strconv.ParseFloat(source[i:end], 64)
+			if err != nil {
+				return nil, &ExpressionError{Expression: source, Position: i, Reason: err.Error()}
+			}
+
+			tokens = append(tokens, token{kind: tokenLiteral, text: source[i:end], value: number, position: i})
+			i = end
+		case unicode.IsLetter(char) || char == '_':
+			end := i
+			for end < len(source) && (unicode.IsLetter(rune(source[end])) || unicode.IsDigit(rune(source[end])) || source[end] == '_') {
+				end++
+			}
+
+			word := source[i:end]
+
+			switch word {
+			case "true", "false":
+				tokens = append(tokens, token{kind: tokenLiteral, text: word, value: word == "true", position: i})
+			case "null":
+				tokens = append(tokens, token{kind: tokenLiteral, text: word, position: i})
+			case "in", "matches", "and", "or", "not":
+				tokens = append(tokens, token{kind: tokenOperator, text: word, position: i})
+			default:
+				tokens = append(tokens, token{kind: tokenName, text: word, position: i})
+			}
+
+			i = end
+		default:
+			operator := ""
+
+			for _, candidate := range []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")", "[", "]", ".", ","} {
+				if strings.HasPrefix(source[i:], candidate) {
+					operator = candidate
+					break
+				}
+			}
+
+			if operator == "" {
+				return nil, &ExpressionError{Expression: source, Position: i, Reason: "unexpected character " + strconv.QuoteRune(char)}
+			}
+
+			tokens = append(tokens, token{kind: tokenOperator, text: operator, position: i})
+			i += len(operator)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEnd, position: len(source)}), nil
+}
+
+func (parser *parser) peek() token {
+	return parser.tokens[parser.next]
+}
+
+func (parser *parser) accept(operators ...string) (string, bool) {
+	current := parser.peek()
+	if current.kind != tokenOperator {
+		return "", false
+	}
+
+	for _, operator := range operators {
+		if current.text == operator {
+			parser.next++
+			return operator, true
+		}
+	}
+
+	return "", false
+}
+
+func (parser *parser) expect(operator string) error {
+	if _, ok := parser.accept(operator); !ok {
+		return parser.fail(parser.peek(), "expected "+strconv.Quote(operator))
+	}
+
+	return nil
+}
+
+func (parser *parser) fail(at token, reason string) error {
+	return &ExpressionError{Expression: parser.source, Position: at.position, Reason: reason}
+}
+
+func (parser *parser) or() (node, error) {
+	left, err := parser.and()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := parser.accept("||", "or"); !ok {
+			return left, nil
+		}
+
+		right, err := parser.and()
+		if err != nil {
+			return nil, err
+		}
+
+		left = logical(left, right, true)
+	}
+}
+
+func (parser *parser) and() (node, error) {
+	left, err := parser.not()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := parser.accept("&&", "and"); !ok {
+			return left, nil
+		}
+
+		right, err := parser.not()
+		if err != nil {
+			return nil, err
+		}
+
+		left = logical(left, right, false)
+	}
+}
+
+func logical(left, right node, or bool) node {
+	return func(scope map[string]any) (any, error) {
+		value, err := left(scope)
+		if err != nil {
+			return nil, err
+		}
+
+		if truthy(value) == or {
+			return or, nil
+		}
+
+		value, err = right(scope)
+		if err != nil {
+			return nil, err
+		}
+
+		return truthy(value), nil
+	}
+}
+
+func (parser *parser) not() (node, error) {
+	if _, ok := parser.accept("!", "not"); ok {
+		operand, err := parser.not()
+		if err != nil {
+			return nil, err
+		}
+
+		return func(scope map[string]any) (any, error) {
+			value, err := operand(scope)
+			if err != nil {
+				return nil, err
+			}
+
+			return !truthy(value), nil
+		}, nil
+	}
+
+	return parser.comparison()
+}
+
+func (parser *parser) comparison() (node, error) {
+	left, err := parser.postfix()
+	if err != nil {
+		return nil, err
+	}
+
+	at := parser.peek()
+
+	operator, ok := parser.accept("==", "!=", "<", "<=", ">", ">=", "in", "matches")
+	if !ok {
+		return left, nil
+	}
+
+	right, err := parser.postfix()
+	if err != nil {
+		return nil, err
+	}
+
+	if operator == "matches" {
+		return matcher(parser, at, left, right)
+	}
+
+	return func(scope map[string]any) (any, error) {
+		a, err := left(scope)
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := right(scope)
+		if err != nil {
+			return nil, err
+		}
+
+		return compare(operator, a, b)
+	}, nil
+}
+
+func matcher(parser *parser, at token, left, right node) (node, error) {
+	cache := map[string]*regexp.Regexp{}
+
+	return func(scope map[string]any) (any, error) {
+		value, err := left(scope)
+		if err != nil {
+			return nil, err
+		}
+
+		pattern, err := right(scope)
+		if err != nil {
+			return nil, err
+		}
+
+		text, ok := pattern.(string)
+		if !ok {
+			return nil, parser.fail(at, "matches needs a string pattern")
+		}
+
+		compiled, ok := cache[text]
+		if !ok {
+			compiled, err = regexp.Compile(text)
+			if err != nil {
+				return nil, parser.fail(at, err.Error())
+			}
+
+			cache[text] = compiled
+		}
+
+		return compiled.MatchString(fmt.Sprint(value)), nil
+	}, nil
+}
+
+func compare(operator string, a, b any) (any, error) {
+	switch operator {
+	case "==":
+		return equal(a, b), nil
+	case "!=":
+		return !equal(a, b), nil
+	case "in":
+		switch container := b.(type) {
+		case []any:
+			for _, item := range container {
+				if equal(a, item) {
+					return true, nil
+				}
+			}
+
+			return false, nil
+		case string:
+			return strings.Contains(container, fmt.Sprint(a)), nil
+		case map[string]any:
+			_, ok := container[fmt.Sprint(a)]
+			return ok, nil
+		case nil:
+			return false, nil
+		}
+
+		return nil, fmt.Errorf("in needs a list, string or object, got %T", b)
+	}
+
+	x, xok := a.(float64)
+	y, yok := b.(float64)
+
+	if !xok || !yok {
+		s, sok := a.(string)
+		t, tok := b.(string)
+
+		if !sok || !tok {
+			return nil, fmt.Errorf("%s needs two numbers or two strings", operator)
+		}
+
+		x, y = float64(strings.Compare(s, t)), 0
+	}
+
+	switch operator {
+	case "<":
+		return x < y, nil
+	case "<=":
+		return x <= y, nil
+	case ">":
+		return x > y, nil
+	}
+
+	return x >= y, nil
+}
+
+func equal(a, b any) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+func truthy(value any) bool {
+	switch value := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return value
+	case float64:
+		return value != 0
+	case string:
+		return value != ""
+	case []any:
+		return len(value) > 0
+	case map[string]any:
+		return len(value) > 0
+	}
+
+	return true
+}
+
+func (parser *parser) postfix() (node, error) {
+	current, err := parser.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		at := parser.peek()
+
+		switch operator, _ := parser.accept(".", "["); operator {
+		case ".":
+			name := parser.peek()
+			if name.kind != tokenName {
+				return nil, parser.fail(name, "expected a field name")
+			}
+
+			parser.next++
+			current = field(current, name.text)
+		case "[":
+			index, err := parser.or()
+			if err != nil {
+				return nil, err
+			}
+
+			err = parser.expect("]")
+			if err != nil {
+				return nil, err
+			}
+
+			current = element(parser, at, current, index)
+		default:
+			return current, nil
+		}
+	}
+}
+
+func field(object node, name string) node {
+	return func(scope map[string]any) (any, error) {
+		value, err := object(scope)
+		if err != nil {
+			return nil, err
+		}
+
+		fields, _ := value.(map[string]any)
+		return fields[name], nil
+	}
+}
+
+func element(parser *parser, at token, list, index node) node {
+	return func(scope map[string]any) (any, error) {
+		value, err := list(scope)
+		if err != nil {
+			return nil, err
+		}
+
+		position, err := index(scope)
+		if err != nil {
+			return nil, err
+		}
+
+		switch container := value.(type) {
+		case []any:
+			number, ok := position.(float64)
+			if !ok {
+				return nil, parser.fail(at, "list index must be a number")
+			}
+
+			i := int(number)
+			if i < 0 {
+				i += len(container)
+			}
+
+			if i < 0 || i >= len(container) {
+				return nil, nil
+			}
+
+			return container[i], nil
+		case map[string]any:
+			return container[fmt.Sprint(position)], nil
+		}
+
+		return nil, nil
+	}
+}
+
+func (parser *parser) primary() (node, error) {
+	current := parser.peek()
+
+	switch current.kind {
+	case tokenLiteral:
+		parser.next++
+		value := current.value
+
+		return func(map[string]any) (any, error) {
+			return value, nil
+		}, nil
+	case tokenName:
+		parser.next++
+
+		if _, ok := parser.accept("("); ok {
+			return parser.call(current)
+		}
+
+		name := current.text
+
+		return func(scope map[string]any) (any, error) {
+			return scope[name], nil
+		}, nil
+	case tokenOperator:
+		if current.text == "(" {
+			parser.next++
+
+			inner, err := parser.or()
+			if err != nil {
+				return nil, err
+			}
+
+			return inner, parser.expect(")")
+		}
+
+		if current.text == "[" {
+			parser.next++
+			return parser.list()
+		}
+	}
+
+	return nil, parser.fail(current, "expected a value")
+}
+
+func (parser *parser) list() (node, error) {
+	items := []node(nil)
+
+	for {
+		if _, ok := parser.accept("]"); ok {
+			break
+		}
+
+		if len(items) > 0 {
+			err := parser.expect(",")
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		item, err := parser.or()
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return func(scope map[string]any) (any, error) {
+		values := make([]any, 0, len(items))
+
+		for _, item := range items {
+			value, err := item(scope)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+		}
+
+		return values, nil
+	}, nil
+}
+
+func (parser *parser) call(name token) (node, error) {
+	arguments := []node(nil)
+
+	for {
+		if _, ok := parser.accept(")"); ok {
+			break
+		}
+
+		if len(arguments) > 0 {
+			err := parser.expect(",")
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		argument, err := parser.or()
+		if err != nil {
+			return nil, err
+		}
+
+		arguments = append(arguments, argument)
+	}
+
+	switch name.text {
+	case "len":
+		if len(arguments) != 1 {
+			return nil, parser.fail(name, "len takes one argument")
+		}
+
+		return func(scope map[string]any) (any, error) {
+			value, err := arguments[0](scope)
+			if err != nil {
+				return nil, err
+			}
+
+			switch value := value.(type) {
+			case []any:
+				return float64(len(value)), nil
+			case string:
+				return float64(len(value)), nil
+			case map[string]any:
+				return float64(len(value)), nil
+			}
+
+			return float64(0), nil
+		}, nil
+	case "any", "all", "count":
+		if len(arguments) != 2 {
+			return nil, parser.fail(name, name.text+" takes a list and a condition on it")
+		}
+
+		return quantifier(name.text, arguments[0], arguments[1]), nil
+	}
+
+	return function(parser.source, name, arguments), nil
+}
+
+func function(source string, name token, arguments []node) node {
+	return func(scope map[string]any) (any, error) {
+		call, ok := scope[name.text].(Function)
+		if !ok {
+			return nil, &ExpressionError{Expression: source, Position: name.position, Reason: "unknown function " + name.text}
+		}
+
+		values := make([]any, len(arguments))
+
+		for i, argument := range arguments {
+			value, err := argument(scope)
+			if err != nil {
+				return nil, err
+			}
+
+			values[i] = value
+		}
+
+		return call(values...)
+	}
+}
+
+func quantifier(kind string, list, condition node) node {
+	return func(scope map[string]any) (any, error) {
+		value, err := list(scope)
+		if err != nil {
+			return nil, err
+		}
+
+		items, _ := value.([]any)
+		matched := 0
+
+		inner := make(map[string]any, len(scope)+1)
+		for key, value := range scope {
+			inner[key] = value
+		}
+
+		for _, item := range items {
+			inner["it"] = item
+
+			result, err := condition(inner)
+			if err != nil {
+				return nil, err
+			}
+
+			if truthy(result) {
+				matched++
+			} else if kind == "all" {
+				return false, nil
+			}
+
+			if kind == "any" && matched > 0 {
+				return true, nil
+			}
+		}
+
+		switch kind {
+		case "any":
+			return false, nil
+		case "all":
+			return true, nil
+		}
+
+		return float64(matched), nil
+	}
+}
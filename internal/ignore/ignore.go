@@ -0,0 +1,257 @@
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type (
+	Rule struct {
+		base      string
+		pattern   *regexp.Regexp
+		negate    bool
+		directory bool
+	}
+
+	Matcher struct {
+		rules []*Rule
+	}
+)
+
+const FileName = ".deployignore"
+
+func New() *Matcher {
+	return new(Matcher)
+}
+
+func Load(root string) (*Matcher, error) {
+	matcher := New()
+
+	err := matcher.load(root, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return matcher, nil
+}
+
+func (matcher *Matcher) load(root, relative string) error {
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(relative), FileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	matcher.Add(relative, data)
+	return nil
+}
+
+func (matcher *Matcher) AddPatterns(patterns []string) {
+	for _, pattern := range patterns {
+		rule := parse("", pattern)
+		if rule != nil {
+			matcher.rules = append(matcher.rules, rule)
+		}
+	}
+}
+
+func (matcher *Matcher) Add(base string, data []byte) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for scanner.Scan() {
+		rule := parse(base, scanner.Text())
+		if rule != nil {
+			matcher.rules = append(matcher.rules, rule)
+		}
+	}
+}
+
+func (matcher *Matcher) Match(name string, directory bool) bool {
+	name = strings.Trim(path.Clean(filepath.ToSlash(name)), "/")
+	if name == "." || name == "" {
+		return false
+	}
+
+	parts := strings.Split(name, "/")
+
+	for i := 1; i < len(parts); i++ {
+		if matcher.match(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+
+	return matcher.match(name, directory)
+}
+
+func (matcher *Matcher) match(name string, directory bool) bool {
+	ignored := false
+
+	for _, rule := range matcher.rules {
+		if rule.directory && !directory {
+			continue
+		}
+
+		relative := name
+		if rule.base != "" {
+			var ok bool
+
+			relative, ok = strings.CutPrefix(name, rule.base+"/")
+			if !ok {
+				continue
+			}
+		}
+
+		if rule.pattern.MatchString(relative) {
+			ignored = !rule.negate
+		}
+	}
+
+	return ignored
+}
+
+func Walk(root string, matcher *Matcher, walk func(path string, entry fs.DirEntry) error) error {
+	if matcher == nil {
+		matcher = New()
+	}
+
+	return filepath.WalkDir(root, func(current string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(root, current)
+		if err != nil {
+			return err
+		}
+
+		relative = filepath.ToSlash(relative)
+
+		if relative != "." {
+			if entry.Name() == FileName && !entry.IsDir() {
+				return nil
+			}
+
+			if matcher.Match(relative, entry.IsDir()) {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+		}
+
+		if entry.IsDir() {
+			base := relative
+			if base == "." {
+				base = ""
+			}
+
+			err = matcher.load(root, base)
+			if err != nil {
+				return err
+			}
+		}
+
+		return walk(current, entry)
+	})
+}
+
+func parse(base, line string) *Rule {
+	line = trimTrailingSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	rule := &Rule{base: base}
+
+	switch {
+	case strings.HasPrefix(line, "!"):
+		rule.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.directory = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if line == "" {
+		return nil
+	}
+
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expression := translate(line)
+	if !anchored {
+		expression = "(?:.*/)?" + expression
+	}
+
+	pattern, err := regexp.Compile("^" + expression + "$")
+	if err != nil {
+		return nil
+	}
+
+	rule.pattern = pattern
+	return rule
+}
+
+func translate(pattern string) string {
+	builder := new(strings.Builder)
+
+	for i := 0; i < len(pattern); i++ {
+		switch character := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/") && (i == 0 || pattern[i-1] == '/'):
+			builder.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**") && i+2 == len(pattern) && (i == 0 || pattern[i-1] == '/'):
+			builder.WriteString(".*")
+			i++
+		case character == '*':
+			builder.WriteString("[^/]*")
+		case character == '?':
+			builder.WriteString("[^/]")
+		case character == '\\' && i+1 < len(pattern):
+			i++
+			builder.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		case character == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				builder.WriteString(`\[`)
+				continue
+			}
+
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+
+			builder.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			builder.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+
+	return builder.String()
+}
+
+func trimTrailingSpace(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+
+	return strings.ReplaceAll(line, `\ `, " ")
+}
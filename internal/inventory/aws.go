@@ -0,0 +1,248 @@
+package inventory
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	ec2Instances struct {
+		Reservations []struct {
+			Instances []struct {
+				ID      string `xml:"instanceId"`
+				Private string `xml:"privateIpAddress"`
+				Public  string `xml:"ipAddress"`
+				Tags    []struct {
+					Key   string `xml:"key"`
+					Value string `xml:"value"`
+				} `xml:"tagSet>item"`
+			} `xml:"instancesSet>item"`
+		} `xml:"reservationSet>item"`
+		NextToken string `xml:"nextToken"`
+	}
+
+	awsCredentials struct {
+		accessKey string
+		secretKey string
+		session   string
+	}
+)
+
+const (
+	ec2Version = "2016-11-15"
+	ec2Service = "ec2"
+)
+
+var (
+	ErrAWSCredentials = errors.New("aws: AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required")
+	ErrAWSRegion      = errors.New("aws: Region or AWS_REGION is required")
+)
+
+func aws(scope context.Context, client *http.Client, source *models.InventorySource) ([]*Host, error) {
+	credentials := awsCredentials{
+		accessKey: os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		session:   os.Getenv("AWS_SESSION_TOKEN"),
+	}
+
+	if credentials.accessKey == "" || credentials.secretKey == "" {
+		return nil, ErrAWSCredentials
+	}
+
+	region := source.Region
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+
+	if region == "" {
+		return nil, ErrAWSRegion
+	}
+
+	endpoint := source.Endpoint
+	if endpoint == "" {
+		endpoint = "https://ec2." + region + ".amazonaws.com/"
+	}
+
+	query := url.Values{}
+	query.Set("Action", "DescribeInstances")
+	query.Set("Version", ec2Version)
+	query.Set("Filter.1.Name", "instance-state-name")
+	query.Set("Filter.1.Value.1", "running")
+
+	names := make([]string, 0, len(source.Selector))
+	for name := range source.Selector {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for i, name := range names {
+		prefix := "Filter." + strconv.Itoa(i+2)
+		query.Set(prefix+".Name", "tag:"+name)
+		query.Set(prefix+".Value.1", source.Selector[name])
+	}
+
+	hosts := []*Host(nil)
+
+	for {
+		instances := new(ec2Instances)
+
+		err := describe(scope, client, endpoint, region, credentials, query, instances)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, reservation := range instances.Reservations {
+			for _, instance := range reservation.Instances {
+				host := &Host{
+					ID:      instance.ID,
+					Name:    instance.ID,
+					Public:  instance.Public,
+					Private: instance.Private,
+				}
+
+				for _, tag := range instance.Tags {
+					if tag.Key == "Name" && tag.Value != "" {
+						host.Name = tag.Value
+					}
+				}
+
+				hosts = append(hosts, host)
+			}
+		}
+
+		if instances.NextToken == "" {
+			return hosts, nil
+		}
+
+		query.Set("NextToken", instances.NextToken)
+	}
+}
+
+func describe(scope context.Context, client *http.Client, endpoint, region string, credentials awsCredentials, query url.Values, target any) error {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+
+	parsed.RawQuery = canonicalQuery(query)
+
+	request, err := http.NewRequestWithContext(scope, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	sign(request, region, ec2Service, credentials, time.Now().UTC())
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return &StatusError{Provider: "aws", Status: response.Status}
+	}
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	return xml.Unmarshal(data, target)
+}
+
+func sign(request *http.Request, region, service string, credentials awsCredentials, now time.Time) {
+	date := now.Format("20060102")
+	stamp := now.Format("20060102T150405Z")
+
+	request.Header.Set("X-Amz-Date", stamp)
+	if credentials.session != "" {
+		request.Header.Set("X-Amz-Security-Token", credentials.session)
+	}
+
+	headers := []string{"host", "x-amz-date"}
+	values := map[string]string{"host": request.URL.Host, "x-amz-date": stamp}
+
+	if credentials.session != "" {
+		headers = append(headers, "x-amz-security-token")
+		values["x-amz-security-token"] = credentials.session
+	}
+
+	canonicalHeaders := new(strings.Builder)
+	for _, name := range headers {
+		canonicalHeaders.WriteString(name + ":" + values[name] + "\n")
+	}
+
+	signedHeaders := strings.Join(headers, ";")
+	emptyHash := sha256.Sum256(nil)
+
+	path := request.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	canonical := strings.Join([]string{
+		request.Method,
+		path,
+		request.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(emptyHash[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+credentials.secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	request.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+credentials.accessKey+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		for _, value := range query[name] {
+			pairs = append(pairs, awsEscape(name)+"="+awsEscape(value))
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+func awsEscape(text string) string {
+	return strings.ReplaceAll(url.QueryEscape(text), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
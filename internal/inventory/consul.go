@@ -0,0 +1,87 @@
+package inventory
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	consulEntry struct {
+		Node           string
+		Address        string
+		ServiceAddress string
+		ServiceID      string
+	}
+)
+
+const consulEndpoint = "http://127.0.0.1:8500"
+
+func consul(scope context.Context, client *http.Client, source *models.InventorySource) ([]*Host, error) {
+	endpoint := source.Endpoint
+	if endpoint == "" {
+		endpoint = os.Getenv("CONSUL_HTTP_ADDR")
+	}
+
+	if endpoint == "" {
+		endpoint = consulEndpoint
+	}
+
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+
+	token := source.Token
+	if token == "" {
+		token = os.Getenv("CONSUL_HTTP_TOKEN")
+	}
+
+	headers := map[string]string{}
+	if token != "" {
+		headers["X-Consul-Token"] = token
+	}
+
+	query := url.Values{}
+	for name, value := range source.Selector {
+		query.Set(name, value)
+	}
+
+	path := "/v1/catalog/nodes"
+	if source.Service != "" {
+		path = "/v1/catalog/service/" + url.PathEscape(source.Service)
+	}
+
+	target := strings.TrimSuffix(endpoint, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	entries := []*consulEntry(nil)
+
+	err := getJSON(scope, client, "consul", target, headers, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make([]*Host, 0, len(entries))
+
+	for _, entry := range entries {
+		address := entry.ServiceAddress
+		if address == "" {
+			address = entry.Address
+		}
+
+		name := entry.Node
+		if source.Service != "" && entry.ServiceID != "" {
+			name = entry.Node + "-" + entry.ServiceID
+		}
+
+		hosts = append(hosts, &Host{Name: name, Private: address})
+	}
+
+	return hosts, nil
+}
@@ -0,0 +1,113 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	hetznerServers struct {
+		Servers []struct {
+			ID        int64  `json:"id"`
+			Name      string `json:"name"`
+			PublicNet struct {
+				IPv4 struct {
+					IP string `json:"ip"`
+				} `json:"ipv4"`
+			} `json:"public_net"`
+			PrivateNet []struct {
+				IP string `json:"ip"`
+			} `json:"private_net"`
+		} `json:"servers"`
+		Meta struct {
+			Pagination struct {
+				NextPage *int `json:"next_page"`
+			} `json:"pagination"`
+		} `json:"meta"`
+	}
+)
+
+const hetznerEndpoint = "https://api.hetzner.cloud/v1"
+
+var ErrHetznerToken = errors.New("hetzner: Token or HCLOUD_TOKEN is required")
+
+func hetzner(scope context.Context, client *http.Client, source *models.InventorySource) ([]*Host, error) {
+	token := source.Token
+	if token == "" {
+		token = os.Getenv("HCLOUD_TOKEN")
+	}
+
+	if token == "" {
+		return nil, ErrHetznerToken
+	}
+
+	endpoint := source.Endpoint
+	if endpoint == "" {
+		endpoint = hetznerEndpoint
+	}
+
+	query := url.Values{}
+	query.Set("per_page", "50")
+
+	if selector := labelSelector(source.Selector); selector != "" {
+		query.Set("label_selector", selector)
+	}
+
+	headers := map[string]string{"Authorization": "Bearer " + token}
+	hosts := []*Host(nil)
+
+	for page := 1; ; {
+		query.Set("page", strconv.Itoa(page))
+
+		servers := new(hetznerServers)
+
+		err := getJSON(scope, client, "hetzner", strings.TrimSuffix(endpoint, "/")+"/servers?"+query.Encode(), headers, servers)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, server := range servers.Servers {
+			host := &Host{
+				ID:     strconv.FormatInt(server.ID, 10),
+				Name:   server.Name,
+				Public: server.PublicNet.IPv4.IP,
+			}
+
+			if len(server.PrivateNet) > 0 {
+				host.Private = server.PrivateNet[0].IP
+			}
+
+			hosts = append(hosts, host)
+		}
+
+		next := servers.Meta.Pagination.NextPage
+		if next == nil || *next <= page {
+			return hosts, nil
+		}
+
+		page = *next
+	}
+}
+
+func labelSelector(selector map[string]string) string {
+	terms := make([]string, 0, len(selector))
+
+	for name, value := range selector {
+		if value == "" {
+			terms = append(terms, name)
+		} else {
+			terms = append(terms, name+"="+value)
+		}
+	}
+
+	sort.Strings(terms)
+	return strings.Join(terms, ",")
+}
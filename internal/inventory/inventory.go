@@ -0,0 +1,124 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	Provider func(scope context.Context, client *http.Client, source *models.InventorySource) ([]*Host, error)
+
+	Host struct {
+		ID      string
+		Name    string
+		Public  string
+		Private string
+	}
+
+	StatusError struct {
+		Provider string
+		Status   string
+	}
+
+	DuplicateError struct {
+		Provider string
+		Name     string
+	}
+)
+
+var providers = map[string]Provider{
+	"aws":     aws,
+	"hetzner": hetzner,
+	"consul":  consul,
+}
+
+var ErrProviderUnknown = errors.New("inventory provider must be \"aws\", \"hetzner\" or \"consul\"")
+
+func (err *StatusError) Error() string {
+	return err.Provider + ": unexpected status " + err.Status
+}
+
+func (err *DuplicateError) Error() string {
+	return err.Provider + ": several hosts are named " + err.Name
+}
+
+func Load(scope context.Context, client *http.Client, source *models.InventorySource) (remotes map[string]*models.Remote, err error) {
+	provider, ok := providers[source.Provider]
+	if !ok {
+		return nil, ErrProviderUnknown
+	}
+
+	hosts, err := provider(scope, client, source)
+	if err != nil {
+		return nil, err
+	}
+
+	remotes = make(map[string]*models.Remote, len(hosts))
+	named := make(map[string]int, len(hosts))
+
+	for _, host := range hosts {
+		named[host.Name]++
+	}
+
+	for _, host := range hosts {
+		address := host.Public
+		if source.Private || address == "" {
+			address = host.Private
+		}
+
+		if address == "" {
+			continue
+		}
+
+		name := source.Prefix + host.Name
+		if named[host.Name] > 1 && host.ID != "" && host.ID != host.Name {
+			name += "-" + host.ID
+		}
+
+		if _, ok := remotes[name]; ok {
+			return nil, &DuplicateError{Provider: source.Provider, Name: name}
+		}
+
+		remotes[name] = &models.Remote{
+			IPv4: address,
+			User: source.User,
+			Port: source.Port,
+		}
+	}
+
+	return remotes, nil
+}
+
+func getJSON(scope context.Context, client *http.Client, provider, url string, headers map[string]string, target any) error {
+	request, err := http.NewRequestWithContext(scope, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return &StatusError{Provider: provider, Status: response.Status}
+	}
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	return sonic.Unmarshal(data, target)
+}
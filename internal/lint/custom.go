@@ -0,0 +1,186 @@
+package lint
+
+import (
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/expr"
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+func withCustom(deploy *models.Deploy) ([]*Rule, error) {
+	all := append([]*Rule(nil), rules...)
+
+	names := make(map[string]bool, len(rules)+len(deploy.LintRules))
+	for _, rule := range rules {
+		names[rule.Name] = true
+	}
+
+	for _, custom := range deploy.LintRules {
+		if custom == nil {
+			continue
+		}
+
+		if names[custom.Name] {
+			return nil, &RuleError{Rule: custom.Name, Err: ErrRuleDuplicate}
+		}
+
+		names[custom.Name] = true
+
+		expression, err := expr.Compile(custom.Assert)
+		if err != nil {
+			return nil, &RuleError{Rule: custom.Name, Err: err}
+		}
+
+		severity := custom.Severity
+		if severity == "" {
+			severity = models.SeverityError
+		}
+
+		all = append(all, &Rule{Name: custom.Name, Severity: severity, Check: customCheck(custom, expression)})
+	}
+
+	return all, nil
+}
+
+func customCheck(custom *models.LintRule, expression *expr.Expression) func(deploy *models.Deploy) ([]*Finding, error) {
+	return func(deploy *models.Deploy) ([]*Finding, error) {
+		document := Scope(deploy)
+
+		if custom.Each != models.LintEachScript {
+			ok, err := expression.Test(document)
+			if err != nil || ok {
+				return nil, err
+			}
+
+			return []*Finding{{Message: custom.Message}}, nil
+		}
+
+		findings := []*Finding(nil)
+
+		for _, script := range document["Scripts"].([]any) {
+			scope := make(map[string]any, len(document)+1)
+			for key, value := range document {
+				scope[key] = value
+			}
+
+			scope["Script"] = script
+
+			ok, err := expression.Test(scope)
+			if err != nil {
+				return nil, err
+			}
+
+			if !ok {
+				findings = append(findings, &Finding{Script: script.(map[string]any)["Name"].(string), Message: custom.Message})
+			}
+		}
+
+		return findings, nil
+	}
+}
+
+func Scope(deploy *models.Deploy) map[string]any {
+	followers := map[string][]any{}
+
+	for _, name := range deploy.Order() {
+		for _, follow := range deploy.Scripts.Scripts[name].Follow {
+			followers[follow] = append(followers[follow], name)
+		}
+	}
+
+	scripts := []any{}
+
+	for _, name := range deploy.Order() {
+		scripts = append(scripts, scriptScope(name, deploy.Scripts.Scripts[name], followers[name]))
+	}
+
+	hosts, _ := deploy.Hosts()
+
+	remotes := []any{}
+	for _, name := range sortedKeys(deploy.Remotes.Remotes) {
+		remotes = append(remotes, name)
+	}
+
+	variables := map[string]any{}
+	for name, value := range deploy.Variables.Variables {
+		variables[name] = value
+	}
+
+	document := map[string]any{
+		"Target": map[string]any{
+			"Environment": deploy.Target.Environment,
+			"Stage":       deploy.Target.Stage,
+			"Hosts":       anyList(deploy.Target.Hosts),
+			"Protected":   deploy.Target.Protected,
+			"Execution":   string(deploy.Target.Execution),
+		},
+		"Folder":    deploy.Folder,
+		"Parallel":  float64(deploy.Parallel),
+		"Strategy":  string(deploy.Strategy),
+		"OnFailure": string(deploy.OnFailure),
+		"Rollout": map[string]any{
+			"Check":   deploy.Rollout.Check,
+			"Approve": deploy.Rollout.Approve,
+		},
+		"Hosts":     anyList(hosts),
+		"Remotes":   remotes,
+		"Variables": variables,
+		"Scripts":   scripts,
+		"First":     nil,
+		"Last":      nil,
+	}
+
+	if len(scripts) > 0 {
+		document["First"] = scripts[0]
+		document["Last"] = scripts[len(scripts)-1]
+	}
+
+	return document
+}
+
+func scriptScope(name string, script *models.Script, followers []any) map[string]any {
+	command, path, args := "", "", []any{}
+
+	if script.Run != nil {
+		path = script.Run.Path
+		command = strings.Join(append([]string{script.Run.Path}, script.Run.Args...), " ")
+		args = anyList(script.Run.Args)
+	}
+
+	environment := map[string]any{}
+	for key, value := range script.Environment {
+		environment[key] = value
+	}
+
+	if followers == nil {
+		followers = []any{}
+	}
+
+	return map[string]any{
+		"Name":        name,
+		"Type":        script.Type(),
+		"Follow":      anyList(script.Follow),
+		"Followers":   followers,
+		"Timeout":     script.Timeout.Seconds(),
+		"RunOnce":     script.RunOnce,
+		"Shared":      script.Shared,
+		"ReadOnly":    script.ReadOnly,
+		"Phase":       string(script.Phase),
+		"DelegateTo":  script.DelegateTo,
+		"Connection":  script.Connection,
+		"Destructive": script.Destructive(),
+		"Path":        path,
+		"Args":        args,
+		"Command":     command,
+		"Environment": environment,
+	}
+}
+
+func anyList(values []string) []any {
+	list := make([]any, 0, len(values))
+	for _, value := range values {
+		list = append(list, value)
+	}
+
+	return list
+}
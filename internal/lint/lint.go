@@ -0,0 +1,323 @@
+package lint
+
+import (
+	"errors"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	Finding struct {
+		Rule     string
+		Severity models.Severity
+		Script   string
+		Message  string
+	}
+
+	Rule struct {
+		Name     string
+		Severity models.Severity
+		Check    func(deploy *models.Deploy) ([]*Finding, error)
+	}
+
+	UnknownRuleError struct {
+		Rule string
+	}
+
+	RuleError struct {
+		Rule string
+		Err  error
+	}
+)
+
+var ErrRuleDuplicate = errors.New("rule is already defined")
+
+var (
+	secretName    = regexp.MustCompile(`(?i)(password|passwd|secret|token|api[_-]?key|private[_-]?key|credential)`)
+	secretValue   = regexp.MustCompile(`AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36}|glpat-[A-Za-z0-9_-]{20}|xox[abpr]-[A-Za-z0-9-]{10,}|-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+	dsnPassword   = regexp.MustCompile(`^[a-z][a-z0-9+.-]*://[^:/@]+:([^@]+)@`)
+	restartMarker = regexp.MustCompile(`\b(restart|reload)\b`)
+	sharedMarker  = regexp.MustCompile(`\b(migrate|migrations?|s3|gsutil|rclone|azcopy)\b`)
+
+	rules = []*Rule{
+		{Name: "unreachable", Severity: models.SeverityError, Check: builtin(unreachable)},
+		{Name: "run-timeout", Severity: models.SeverityWarning, Check: builtin(runTimeout)},
+		{Name: "plaintext-secret", Severity: models.SeverityError, Check: builtin(plaintextSecret)},
+		{Name: "delete-outside-folder", Severity: models.SeverityError, Check: builtin(deleteOutsideFolder)},
+		{Name: "restart-healthcheck", Severity: models.SeverityWarning, Check: builtin(restartHealthcheck)},
+		{Name: "shared-storage", Severity: models.SeverityWarning, Check: builtin(sharedStorage)},
+	}
+)
+
+func (err *UnknownRuleError) Error() string {
+	return "unknown lint rule " + err.Rule
+}
+
+func (err *RuleError) Error() string {
+	return "lint rule " + err.Rule + ": " + err.Err.Error()
+}
+
+func (err *RuleError) Unwrap() error {
+	return err.Err
+}
+
+func Rules() []*Rule {
+	return rules
+}
+
+func Check(deploy *models.Deploy) ([]*Finding, error) {
+	all, err := withCustom(deploy)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(all))
+	for _, rule := range all {
+		known[rule.Name] = true
+	}
+
+	for name := range deploy.Lint {
+		if !known[name] {
+			return nil, &UnknownRuleError{Rule: name}
+		}
+	}
+
+	findings := []*Finding(nil)
+
+	for _, rule := range all {
+		severity := rule.Severity
+		if configured := deploy.Lint[rule.Name]; configured != "" {
+			severity = configured
+		}
+
+		if severity == models.SeverityOff {
+			continue
+		}
+
+		found, err := rule.Check(deploy)
+		if err != nil {
+			return nil, &RuleError{Rule: rule.Name, Err: err}
+		}
+
+		for _, finding := range found {
+			finding.Rule = rule.Name
+			finding.Severity = severity
+			findings = append(findings, finding)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Script < findings[j].Script
+	})
+
+	return findings, nil
+}
+
+func builtin(check func(deploy *models.Deploy) []*Finding) func(deploy *models.Deploy) ([]*Finding, error) {
+	return func(deploy *models.Deploy) ([]*Finding, error) {
+		return check(deploy), nil
+	}
+}
+
+func Errors(findings []*Finding) int {
+	count := 0
+
+	for _, finding := range findings {
+		if finding.Severity == models.SeverityError {
+			count++
+		}
+	}
+
+	return count
+}
+
+func unreachable(deploy *models.Deploy) (findings []*Finding) {
+	if len(deploy.Target.Hosts) > 0 && len(deploy.Inventory) == 0 {
+		hosts, err := deploy.Hosts()
+		if err == nil && len(hosts) == 0 {
+			findings = append(findings, &Finding{Message: "Target.Hosts " + strings.Join(deploy.Target.Hosts, ", ") + " match no remote, no script will run"})
+		}
+	}
+
+	for _, name := range deploy.Order() {
+		script := deploy.Scripts.Scripts[name]
+
+		if script.DelegateTo != "" && deploy.Remotes.Remotes[script.DelegateTo] == nil && len(deploy.Inventory) == 0 {
+			findings = append(findings, &Finding{Script: name, Message: "delegated to undefined remote " + script.DelegateTo})
+		}
+	}
+
+	if check := deploy.Rollout.Check; check != "" && deploy.Scripts.Scripts[check] == nil {
+		findings = append(findings, &Finding{Message: "Rollout.Check names undefined script " + check})
+	}
+
+	return findings
+}
+
+func runTimeout(deploy *models.Deploy) (findings []*Finding) {
+	for _, name := range deploy.Order() {
+		script := deploy.Scripts.Scripts[name]
+
+		if script.Run != nil && script.Timeout.Duration <= 0 {
+			findings = append(findings, &Finding{Script: name, Message: "Run has no Timeout and can hang the deploy"})
+		}
+	}
+
+	return findings
+}
+
+func plaintextSecret(deploy *models.Deploy) (findings []*Finding) {
+	check := func(script, where, key, value string) {
+		if value == "" || strings.Contains(value, "${") {
+			return
+		}
+
+		if secretValue.MatchString(value) || secretName.MatchString(key) {
+			findings = append(findings, &Finding{Script: script, Message: where + " " + key + " holds a plaintext secret, pass it as a variable from the environment or a var file"})
+		}
+	}
+
+	for _, key := range sortedKeys(deploy.Variables.Variables) {
+		check("", "variable", key, deploy.Variables.Variables[key])
+	}
+
+	for _, name := range sortedKeys(deploy.Connections.Connections) {
+		connection := deploy.Connections.Connections[name]
+		if connection == nil {
+			continue
+		}
+
+		if match := dsnPassword.FindStringSubmatch(connection.DSN); match != nil && !strings.Contains(match[1], "${") {
+			findings = append(findings, &Finding{Message: "connection " + name + " DSN holds a plaintext password"})
+		}
+
+		for _, key := range sortedKeys(connection.Variables) {
+			check("", "connection "+name+" variable", key, connection.Variables[key])
+		}
+	}
+
+	for _, name := range deploy.Order() {
+		script := deploy.Scripts.Scripts[name]
+
+		for _, key := range sortedKeys(script.Environment) {
+			check(name, "environment", key, script.Environment[key])
+		}
+
+		if script.Download != nil {
+			for _, key := range sortedKeys(script.Download.Headers) {
+				check(name, "header", key, script.Download.Headers[key])
+			}
+		}
+
+		if script.Run != nil {
+			for _, argument := range script.Run.Args {
+				if !strings.Contains(argument, "${") && secretValue.MatchString(argument) {
+					findings = append(findings, &Finding{Script: name, Message: "Run argument holds a plaintext secret"})
+				}
+			}
+		}
+	}
+
+	return findings
+}
+
+func deleteOutsideFolder(deploy *models.Deploy) (findings []*Finding) {
+	folder := ""
+	if deploy.Folder != "" {
+		folder = path.Clean(filepath.ToSlash(deploy.Folder))
+	}
+
+	for _, name := range deploy.Order() {
+		script := deploy.Scripts.Scripts[name]
+		if script.Delete == nil {
+			continue
+		}
+
+		target := filepath.ToSlash(script.Delete.Path)
+
+		inside := false
+		switch {
+		case folder != "" && (target == folder || strings.HasPrefix(path.Clean(target), folder+"/")):
+			inside = folder != "/"
+		case strings.Contains(target, "${"):
+			continue
+		case !path.IsAbs(target):
+			clean := path.Clean(target)
+			inside = clean != ".." && !strings.HasPrefix(clean, "../")
+		}
+
+		if !inside {
+			findings = append(findings, &Finding{Script: name, Message: "Delete " + script.Delete.Path + " is outside Folder"})
+		}
+	}
+
+	return findings
+}
+
+func restartHealthcheck(deploy *models.Deploy) (findings []*Finding) {
+	if deploy.Rollout.Check != "" {
+		return nil
+	}
+
+	followed := map[string]bool{}
+
+	for _, script := range deploy.Scripts.Scripts {
+		if script == nil {
+			continue
+		}
+
+		for _, follow := range script.Follow {
+			followed[follow] = true
+		}
+	}
+
+	for _, name := range deploy.Order() {
+		script := deploy.Scripts.Scripts[name]
+		if script.Run == nil || followed[name] {
+			continue
+		}
+
+		command := strings.Join(append([]string{script.Run.Path}, script.Run.Args...), " ")
+		if restartMarker.MatchString(command) {
+			findings = append(findings, &Finding{Script: name, Message: "restarts a service but no script follows it to check health and Rollout.Check is not set"})
+		}
+	}
+
+	return findings
+}
+
+func sharedStorage(deploy *models.Deploy) (findings []*Finding) {
+	hosts, err := deploy.Hosts()
+	if err != nil || len(hosts) < 2 {
+		return nil
+	}
+
+	for _, name := range deploy.Order() {
+		script := deploy.Scripts.Scripts[name]
+		if script.Run == nil || script.RunOnce || script.Shared != "" || script.DelegateTo != "" {
+			continue
+		}
+
+		command := strings.Join(append([]string{script.Run.Path}, script.Run.Args...), " ")
+		if sharedMarker.MatchString(command) {
+			findings = append(findings, &Finding{Script: name, Message: "looks like it acts on shared storage but runs on every host, set RunOnce or Shared"})
+		}
+	}
+
+	return findings
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
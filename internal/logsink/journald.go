@@ -0,0 +1,80 @@
+package logsink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type (
+	Journald struct {
+		connection *net.UnixConn
+	}
+)
+
+const (
+	journaldSocket = "/run/systemd/journal/socket"
+	journaldLimit  = 64 << 10
+)
+
+func OpenJournald() (*Journald, error) {
+	connection, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journaldSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Journald{connection: connection}, nil
+}
+
+func (sink *Journald) Send(priority Priority, message string, fields map[string]string) error {
+	if len(message) > journaldLimit {
+		message = message[:journaldLimit]
+	}
+
+	buffer := new(bytes.Buffer)
+
+	writeJournalField(buffer, "MESSAGE", message)
+	writeJournalField(buffer, "PRIORITY", strconv.Itoa(int(priority)))
+	writeJournalField(buffer, "SYSLOG_IDENTIFIER", Identifier)
+	writeJournalField(buffer, "SYSLOG_PID", strconv.Itoa(os.Getpid()))
+
+	for _, name := range sortedFields(fields) {
+		writeJournalField(buffer, journalName(name), fields[name])
+	}
+
+	_, err := sink.connection.Write(buffer.Bytes())
+	return err
+}
+
+func (sink *Journald) Close() error {
+	return sink.connection.Close()
+}
+
+func writeJournalField(buffer *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		buffer.WriteString(name + "=" + value + "\n")
+		return
+	}
+
+	buffer.WriteString(name + "\n")
+	binary.Write(buffer, binary.LittleEndian, uint64(len(value)))
+	buffer.WriteString(value + "\n")
+}
+
+func journalName(name string) string {
+	mapped := strings.Map(func(character rune) rune {
+		switch {
+		case character >= 'a' && character <= 'z':
+			return character - 'a' + 'A'
+		case character >= 'A' && character <= 'Z', character >= '0' && character <= '9':
+			return character
+		}
+
+		return '_'
+	}, name)
+
+	return strings.TrimLeft(mapped, "_")
+}
@@ -0,0 +1,102 @@
+package logsink
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	Priority int
+
+	Sink interface {
+		Send(priority Priority, message string, fields map[string]string) error
+		Close() error
+	}
+
+	Sinks []Sink
+
+	Writer struct {
+		Sinks    Sinks
+		Priority Priority
+	}
+)
+
+const (
+	PriorityError   Priority = 3
+	PriorityWarning Priority = 4
+	PriorityInfo    Priority = 6
+	PriorityDebug   Priority = 7
+)
+
+const (
+	Identifier      = "deployctl"
+	timestampLayout = "2006/01/02 15:04:05 "
+)
+
+var ErrSinkUnknown = errors.New("log sink must be \"syslog\" or \"journald\"")
+
+func Open(name string) (Sink, error) {
+	switch name {
+	case "syslog":
+		return OpenSyslog()
+	case "journald":
+		return OpenJournald()
+	}
+
+	return nil, ErrSinkUnknown
+}
+
+func (sinks Sinks) Send(priority Priority, message string, fields map[string]string) error {
+	errs := []error(nil)
+
+	for _, sink := range sinks {
+		err := sink.Send(priority, message, fields)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (sinks Sinks) Close() error {
+	errs := []error(nil)
+
+	for _, sink := range sinks {
+		err := sink.Close()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (writer *Writer) Write(data []byte) (int, error) {
+	message := strings.TrimRight(string(data), "\n")
+
+	if len(message) > len(timestampLayout) {
+		_, err := time.Parse(timestampLayout, message[:len(timestampLayout)])
+		if err == nil {
+			message = message[len(timestampLayout):]
+		}
+	}
+
+	if message != "" {
+		writer.Sinks.Send(writer.Priority, message, nil)
+	}
+
+	return len(data), nil
+}
+
+func sortedFields(fields map[string]string) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
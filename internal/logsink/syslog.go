@@ -0,0 +1,48 @@
+package logsink
+
+import (
+	"log/syslog"
+	"strconv"
+	"strings"
+)
+
+type (
+	Syslog struct {
+		writer *syslog.Writer
+	}
+)
+
+func OpenSyslog() (*Syslog, error) {
+	writer, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, Identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Syslog{writer: writer}, nil
+}
+
+func (sink *Syslog) Send(priority Priority, message string, fields map[string]string) error {
+	builder := new(strings.Builder)
+	builder.WriteString(message)
+
+	for _, name := range sortedFields(fields) {
+		builder.WriteString(" " + strings.ToLower(name) + "=" + strconv.Quote(fields[name]))
+	}
+
+	text := builder.String()
+
+	switch {
+	case priority <= PriorityError:
+		return sink.writer.Err(text)
+	case priority == PriorityWarning:
+		return sink.writer.Warning(text)
+	case priority >= PriorityDebug:
+		return sink.writer.Debug(text)
+	}
+
+	return sink.writer.Info(text)
+}
+
+func (sink *Syslog) Close() error {
+	return sink.writer.Close()
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+type (
+	ArchiveFormat string
+)
+
+const (
+	ArchiveTar     ArchiveFormat = "tar"
+	ArchiveTarGzip ArchiveFormat = "tar.gz"
+	ArchiveTarZstd ArchiveFormat = "tar.zst"
+	ArchiveZip     ArchiveFormat = "zip"
+)
+
+var ErrArchiveFormatUnknown = errors.New("archive format must be \"tar\", \"tar.gz\", \"tar.zst\" or \"zip\"")
+
+func (format *ArchiveFormat) UnmarshalJSON(data []byte) error {
+	text, err := strconv.Unquote(string(data))
+	if err != nil {
+		return ErrArchiveFormatUnknown
+	}
+
+	switch ArchiveFormat(text) {
+	case "", ArchiveTar, ArchiveTarGzip, ArchiveTarZstd, ArchiveZip:
+		*format = ArchiveFormat(text)
+	case "tgz":
+		*format = ArchiveTarGzip
+	case "tzst":
+		*format = ArchiveTarZstd
+	default:
+		return ErrArchiveFormatUnknown
+	}
+
+	return nil
+}
+
+func ArchiveFormatOf(path string) (ArchiveFormat, bool) {
+	lower := strings.ToLower(path)
+
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return ArchiveTarGzip, true
+	case strings.HasSuffix(lower, ".tar.zst"), strings.HasSuffix(lower, ".tzst"):
+		return ArchiveTarZstd, true
+	case strings.HasSuffix(lower, ".tar"):
+		return ArchiveTar, true
+	case strings.HasSuffix(lower, ".zip"):
+		return ArchiveZip, true
+	}
+
+	return "", false
+}
@@ -0,0 +1,7 @@
+package models
+
+type (
+	Artifacts struct {
+		Peers []string
+	}
+)
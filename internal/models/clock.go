@@ -0,0 +1,9 @@
+package models
+
+type (
+	Clock struct {
+		MaxSkew      Duration
+		Synchronized bool
+		Severity     Severity
+	}
+)
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/version"
+)
+
+func (deploy *Deploy) CheckCompat() error {
+	for name, script := range deploy.Scripts.Scripts {
+		if script == nil || script.Compat == nil {
+			continue
+		}
+
+		for i, dependency := range script.Compat.Services {
+			path := rootPath + ".Scripts." + name + ".Compat.Services[" + strconv.Itoa(i) + "]"
+
+			switch {
+			case dependency == nil:
+				return &ValidationError{Path: path, Reason: "service is empty"}
+			case dependency.URL == "" && dependency.Command == "":
+				return &ValidationError{Path: path, Reason: "one of URL or Command is required"}
+			case dependency.URL != "" && dependency.Command != "":
+				return &ValidationError{Path: path, Reason: "URL conflicts with Command"}
+			case dependency.Command != "" && len(dependency.Headers) > 0:
+				return &ValidationError{Path: path + ".Headers", Reason: "Headers only apply to URL"}
+			}
+
+			if strings.Contains(dependency.Constraint, "$") {
+				continue
+			}
+
+			_, err := version.ParseConstraint(dependency.Constraint)
+			if err != nil {
+				return &ValidationError{Path: path + ".Constraint", Reason: err.Error()}
+			}
+		}
+	}
+
+	return nil
+}
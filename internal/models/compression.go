@@ -0,0 +1,36 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+)
+
+type (
+	Compression string
+)
+
+const (
+	CompressionNone Compression = "none"
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+)
+
+var ErrCompressionUnknown = errors.New("compression must be \"none\", \"gzip\" or \"zstd\"")
+
+func (compression *Compression) UnmarshalJSON(data []byte) error {
+	text, err := strconv.Unquote(string(data))
+	if err != nil {
+		return ErrCompressionUnknown
+	}
+
+	switch Compression(text) {
+	case "", CompressionNone:
+		*compression = CompressionNone
+	case CompressionGzip, CompressionZstd:
+		*compression = Compression(text)
+	default:
+		return ErrCompressionUnknown
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"strconv"
+)
+
+func (deploy *Deploy) ApplyConnections() error {
+	for name, script := range deploy.Scripts.Scripts {
+		if script == nil || script.Connection == "" {
+			continue
+		}
+
+		connection, ok := deploy.Connections.Connections[script.Connection]
+		if !ok || connection == nil {
+			return &ValidationError{Path: rootPath + ".Scripts." + name + ".Connection", Reason: "undefined connection " + script.Connection}
+		}
+
+		if script.DelegateTo == "" {
+			script.DelegateTo = connection.Remote
+		}
+
+		if script.Environment == nil {
+			script.Environment = make(Environment)
+		}
+
+		for key, value := range connection.Environment() {
+			if _, ok := script.Environment[key]; !ok {
+				script.Environment[key] = value
+			}
+		}
+	}
+
+	return nil
+}
+
+func (connection *Connection) Environment() Environment {
+	environment := make(Environment, len(connection.Variables)+8)
+
+	set := func(name, value string) {
+		if value != "" {
+			environment[name] = value
+		}
+	}
+
+	set("DEPLOY_SSH_USER", connection.User)
+	set("DEPLOY_SSH_IDENTITY", connection.Identity)
+
+	if connection.Port > 0 {
+		set("DEPLOY_SSH_PORT", strconv.Itoa(connection.Port))
+	}
+
+	set("DOCKER_CONTEXT", connection.DockerContext)
+	set("KUBECONFIG", connection.Kubeconfig)
+	set("DEPLOY_KUBE_CONTEXT", connection.KubeContext)
+	set("DEPLOY_KUBE_NAMESPACE", connection.Namespace)
+	set("DATABASE_URL", connection.DSN)
+
+	for name, value := range connection.Variables {
+		environment[name] = value
+	}
+
+	return environment
+}
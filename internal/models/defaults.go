@@ -0,0 +1,221 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+)
+
+type (
+	Defaults struct {
+		Defaults map[string]*Script `validate:"-"`
+	}
+)
+
+func (script *Script) Type() string {
+	value := reflect.ValueOf(script).Elem()
+	valueType := value.Type()
+
+	for i := 0; i < valueType.NumField(); i++ {
+		field := value.Field(i)
+		if field.Kind() == reflect.Pointer && !field.IsNil() {
+			return valueType.Field(i).Name
+		}
+	}
+
+	return ""
+}
+
+func ScriptTypes() []string {
+	valueType := reflect.TypeOf(Script{})
+	types := []string(nil)
+
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		if field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct {
+			types = append(types, field.Name)
+		}
+	}
+
+	return types
+}
+
+func (deploy *Deploy) ApplyDefaults() error {
+	known := ScriptTypes()
+
+	for scriptType := range deploy.Defaults.Defaults {
+		if !contains(known, scriptType) {
+			return &ValidationError{Path: rootPath + ".Defaults." + scriptType, Reason: "unknown script type"}
+		}
+	}
+
+	scripts, _ := field(deploy.document, "Scripts").(map[string]any)
+
+	for name, script := range deploy.Scripts.Scripts {
+		if script == nil {
+			continue
+		}
+
+		defaults, ok := deploy.Defaults.Defaults[script.Type()]
+		if !ok || defaults == nil {
+			continue
+		}
+
+		set, _ := field(scripts, name).(map[string]any)
+		merge(reflect.ValueOf(script).Elem(), reflect.ValueOf(defaults).Elem(), set)
+	}
+
+	return nil
+}
+
+func merge(destination, source reflect.Value, set map[string]any) {
+	switch destination.Kind() {
+	case reflect.Struct:
+		if _, ok := destination.Interface().(Duration); ok {
+			if destination.IsZero() {
+				destination.Set(source)
+			}
+
+			return
+		}
+
+		for i := 0; i < destination.NumField(); i++ {
+			structField := destination.Type().Field(i)
+			if !structField.IsExported() {
+				continue
+			}
+
+			if set == nil {
+				merge(destination.Field(i), source.Field(i), nil)
+				continue
+			}
+
+			value, ok := set[key(set, structField.Name)]
+			if !ok {
+				merge(destination.Field(i), source.Field(i), nil)
+				continue
+			}
+
+			nested, object := value.(map[string]any)
+
+			switch kind := structField.Type.Kind(); {
+			case kind == reflect.Map:
+				merge(destination.Field(i), source.Field(i), nested)
+			case object && (kind == reflect.Struct || kind == reflect.Pointer && structField.Type.Elem().Kind() == reflect.Struct):
+				merge(destination.Field(i), source.Field(i), nested)
+			}
+		}
+	case reflect.Pointer:
+		if source.IsNil() {
+			return
+		}
+
+		if destination.IsNil() {
+			destination.Set(reflect.New(source.Type().Elem()))
+		}
+
+		merge(destination.Elem(), source.Elem(), set)
+	case reflect.Map:
+		if source.IsNil() {
+			return
+		}
+
+		if destination.IsNil() {
+			destination.Set(reflect.MakeMapWithSize(source.Type(), source.Len()))
+		}
+
+		iterator := source.MapRange()
+		for iterator.Next() {
+			if !destination.MapIndex(iterator.Key()).IsValid() {
+				destination.SetMapIndex(iterator.Key(), clone(iterator.Value()))
+			}
+		}
+	default:
+		if destination.IsZero() {
+			destination.Set(clone(source))
+		}
+	}
+}
+
+func clone(value reflect.Value) reflect.Value {
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			return value
+		}
+
+		copied := reflect.New(value.Type().Elem())
+		copied.Elem().Set(clone(value.Elem()))
+
+		return copied
+	case reflect.Slice:
+		if value.IsNil() {
+			return value
+		}
+
+		copied := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for i := 0; i < value.Len(); i++ {
+			copied.Index(i).Set(clone(value.Index(i)))
+		}
+
+		return copied
+	case reflect.Map:
+		if value.IsNil() {
+			return value
+		}
+
+		copied := reflect.MakeMapWithSize(value.Type(), value.Len())
+
+		iterator := value.MapRange()
+		for iterator.Next() {
+			copied.SetMapIndex(iterator.Key(), clone(iterator.Value()))
+		}
+
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(value.Type()).Elem()
+		copied.Set(value)
+
+		for i := 0; i < value.NumField(); i++ {
+			if value.Type().Field(i).IsExported() {
+				copied.Field(i).Set(clone(value.Field(i)))
+			}
+		}
+
+		return copied
+	}
+
+	return value
+}
+
+func field(document any, name string) any {
+	object, ok := document.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	return object[key(object, name)]
+}
+
+func key(object map[string]any, name string) string {
+	if _, ok := object[name]; ok {
+		return name
+	}
+
+	for candidate := range object {
+		if strings.EqualFold(candidate, name) {
+			return candidate
+		}
+	}
+
+	return name
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyDefaults(t *testing.T) {
+	tests := []struct {
+		name    string
+		scripts string
+		check   func(t *testing.T, scripts map[string]*Script)
+	}{
+		{
+			name:    "unset fields",
+			scripts: `{"first": {"Run": {"Path": "first"}}}`,
+			check: func(t *testing.T, scripts map[string]*Script) {
+				run := scripts["first"].Run
+				if !run.Capture || run.Directory != "/srv" || !slices.Equal(run.SearchPath, []string{"/opt/bin"}) || run.Throttle.Retries != 3 || run.Throttle.Delay.Duration != time.Second {
+					t.Errorf("defaults were not applied: %+v", run)
+				}
+			},
+		},
+		{
+			name:    "explicit zero values",
+			scripts: `{"first": {"Run": {"Path": "first", "Capture": false, "Directory": "", "SearchPath": [], "Throttle": {"Retries": 0}}}}`,
+			check: func(t *testing.T, scripts map[string]*Script) {
+				run := scripts["first"].Run
+				if run.Capture || run.Directory != "" || len(run.SearchPath) != 0 || run.Throttle.Retries != 0 {
+					t.Errorf("explicit values were overridden: %+v", run)
+				}
+
+				if run.Throttle.Delay.Duration != time.Second {
+					t.Errorf("throttle delay %s, expected the default", run.Throttle.Delay)
+				}
+			},
+		},
+		{
+			name:    "case insensitive fields",
+			scripts: `{"first": {"run": {"path": "first", "capture": false}}}`,
+			check: func(t *testing.T, scripts map[string]*Script) {
+				if scripts["first"].Run.Capture {
+					t.Error("explicit capture was overridden")
+				}
+			},
+		},
+		{
+			name:    "unshared slices",
+			scripts: `{"first": {"Run": {"Path": "first"}}, "second": {"Run": {"Path": "second"}}}`,
+			check: func(t *testing.T, scripts map[string]*Script) {
+				scripts["first"].Run.SearchPath[0] = "/changed"
+				scripts["first"].Run.Throttle.Patterns[0] = "changed"
+
+				second := scripts["second"].Run
+				if second.SearchPath[0] != "/opt/bin" || second.Throttle.Patterns[0] != "busy" {
+					t.Errorf("defaults are shared between scripts: %+v", second)
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := `{
+				"Version": 2,
+				"Defaults": {"Run": {"Run": {"Capture": true, "Directory": "/srv", "SearchPath": ["/opt/bin"], "Throttle": {"Retries": 3, "Delay": "1s", "Patterns": ["busy"]}}}},
+				"Scripts": ` + test.scripts + `
+			}`
+
+			deploys, err := Load(strings.NewReader(config), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			test.check(t, deploys[0].Scripts.Scripts)
+		})
+	}
+}
@@ -2,30 +2,311 @@ package models
 
 type (
 	Remote struct {
-		IPv4 string
-		User string
-		Port int
+		IPv4        string `validate:"required"`
+		User        string `validate:"required"`
+		Port        int
+		Identity    string
+		Compression Compression
+		Delta       bool
+		Variables   map[string]string
 	}
 
 	Remotes struct {
 		Remotes map[string]*Remote
 	}
 
+	Connection struct {
+		Remote        string
+		User          string
+		Port          int
+		Identity      string
+		DockerContext string
+		KubeContext   string
+		Kubeconfig    string
+		Namespace     string
+		DSN           string
+		Variables     map[string]string
+	}
+
+	Connections struct {
+		Connections map[string]*Connection
+	}
+
 	ScriptMove struct {
-		From string
+		From         string `validate:"required"`
+		To           string
+		PreservePath bool
+		IfExists     IfExists
+		Mode         Mode
+		Owner        string
+		Group        string
+	}
+
+	ScriptCopy struct {
+		From         string `validate:"required"`
+		To           string
+		PreservePath bool
+		IfExists     IfExists
+		Exclude      []string
+		SHA256       string
+		Atomic       bool
+		Distribute   bool
+		Mode         Mode
+		Owner        string
+		Group        string
+	}
+
+	ScriptDownload struct {
+		URL         string `validate:"required"`
+		To          string
+		Connections int
+		IfExists    IfExists
+		Headers     map[string]string
+		Retries     int
+		Timeout     Duration
+		SHA256      string
+	}
+
+	ScriptRun struct {
+		Path        string `validate:"required"`
+		Args        []string
+		Interpreter string
+		SearchPath  []string
+		Directory   string
+		OutputLimit int
+		SpillOutput bool
+		Capture     bool
+		Encoding    Encoding
+		Locale      string
+		KillGrace   Duration
+		Rollback    []string
+		Throttle    Throttle
+	}
+
+	Throttle struct {
+		Retries  int
+		Delay    Duration
+		MaxDelay Duration
+		Patterns []string
+	}
+
+	ScriptFile struct {
+		Path    string `validate:"required"`
+		State   FileState
+		Content string
+		Source  string
+		Mode    Mode
+		Owner   string
+		Group   string
+	}
+
+	ScriptTemplate struct {
+		Source string `validate:"required"`
+		To     string `validate:"required"`
+		Data   map[string]string
+		Mode   Mode
+		Owner  string
+		Group  string
+		Cache  bool
+	}
+
+	ScriptSymlink struct {
+		Target string `validate:"required"`
+		Link   string `validate:"required"`
+		Force  bool
+	}
+
+	ScriptDelete struct {
+		Path      string `validate:"required"`
+		Recursive bool
+	}
+
+	ScriptArchive struct {
+		From     string `validate:"required"`
+		To       string
+		Format   ArchiveFormat
+		Level    int
+		Threads  int
+		IfExists IfExists
+		Cache    bool
+	}
+
+	ScriptExtract struct {
+		From            string `validate:"required"`
+		To              string
+		Format          ArchiveFormat
+		StripComponents int
+		IfExists        IfExists
+	}
+
+	ScriptManifest struct {
+		Path string `validate:"required"`
 		To   string
 	}
 
+	ScriptWait struct {
+		URL      string
+		Address  string
+		Status   int
+		Body     string
+		Interval Duration
+		Timeout  Duration
+	}
+
+	ScriptCompat struct {
+		Services []*Dependency `validate:"required"`
+	}
+
+	Dependency struct {
+		Name       string `validate:"required"`
+		URL        string
+		Headers    map[string]string
+		Command    string
+		Path       string
+		Constraint string `validate:"required"`
+	}
+
+	ScriptDeploy struct {
+		Config        string `validate:"required"`
+		Scripts       []string
+		Environment   string
+		Stage         string
+		Variables     map[string]string
+		VariableFiles []string
+		Remote        string
+	}
+
 	Script struct {
-		Move *ScriptMove
+		Follow       []string
+		When         string
+		Timeout      Duration
+		Retries      int
+		RetryDelay   Duration
+		RetryBackoff float64
+		Quota        Size
+		Directories  Directories
+		Environment  Environment
+		RunOnce      bool
+		Shared       string
+		ReadOnly     bool
+		Phase        Phase
+		DelegateTo   string
+		Connection   string
+		Register     map[string]string
+		Move         *ScriptMove
+		Copy         *ScriptCopy
+		Download     *ScriptDownload
+		Run          *ScriptRun
+		File         *ScriptFile
+		Template     *ScriptTemplate
+		Symlink      *ScriptSymlink
+		Delete       *ScriptDelete
+		Archive      *ScriptArchive
+		Extract      *ScriptExtract
+		Manifest     *ScriptManifest
+		Wait         *ScriptWait
+		Compat       *ScriptCompat
+		Deploy       *ScriptDeploy
 	}
 
 	Scripts struct {
 		Scripts map[string]*Script
 	}
 
+	HTTP struct {
+		Timeout           Duration
+		Retries           int
+		RetryDelay        Duration
+		Proxy             string
+		CABundle          string
+		ClientCertificate string
+		ClientKey         string
+		Insecure          bool
+		RateLimit         float64
+		Burst             int
+		BreakerThreshold  int
+		BreakerCooldown   Duration
+	}
+
+	InventorySource struct {
+		Provider string `validate:"required"`
+		Endpoint string
+		Token    string
+		Region   string
+		Service  string
+		Selector map[string]string
+		Private  bool
+		Prefix   string
+		User     string
+		Port     int
+	}
+
+	EventSink struct {
+		URL     string `validate:"required"`
+		Headers map[string]string
+		Events  []EventType
+	}
+
+	Rollout struct {
+		Serial          Serial
+		Pause           Duration
+		Check           string
+		Approve         bool
+		SkipUnreachable bool
+		ConnectTimeout  Duration
+	}
+
+	Facts struct {
+		Packages []string
+		Services []string
+	}
+
+	Target struct {
+		Remote      string
+		Hosts       []string
+		Execution   Execution
+		Environment string
+		Stage       string
+		Order       int
+		Protected   bool
+	}
+
+	Variables struct {
+		Variables map[string]string
+	}
+
 	Deploy struct {
+		Version     int
+		Target      Target
+		Strategy    Strategy
+		OnFailure   OnFailure
+		Parallel    int
+		Folder      string
+		BaseDir     string
+		Directories Directories
+		Prefetch    bool
+		Retention   Retention
+		Artifacts   Artifacts
+		HTTP        HTTP
+		Rollout     Rollout
+		Window      Window
+		Overrun     Overrun
+		Clock       Clock
+		Preflight   Preflight
+		Facts       *Facts
+		Inventory   []*InventorySource
+		Events      []*EventSink
+		Lint        map[string]Severity
+		LintRules   []*LintRule
+		Redact      []string
+		Secrets     map[string]*Secret
+		Variables
+		Defaults
 		Remotes
+		Connections
 		Scripts
+
+		order    []string
+		document map[string]any
 	}
 )
@@ -0,0 +1,42 @@
+package models
+
+func (script *Script) Destructive() bool {
+	switch {
+	case script.Move != nil:
+		return overwrites(script.Move.IfExists)
+	case script.Copy != nil:
+		return overwrites(script.Copy.IfExists)
+	case script.Download != nil:
+		return overwrites(script.Download.IfExists)
+	case script.Delete != nil:
+		return true
+	case script.Archive != nil:
+		return overwrites(script.Archive.IfExists)
+	case script.Extract != nil:
+		return overwrites(script.Extract.IfExists)
+	case script.Run != nil:
+		return true
+	case script.File != nil:
+		return script.File.State != FileStateDirectory
+	case script.Template != nil:
+		return true
+	case script.Symlink != nil:
+		return script.Symlink.Force
+	case script.Deploy != nil:
+		return true
+	}
+
+	return false
+}
+
+func overwrites(ifExists IfExists) bool {
+	return ifExists == "" || ifExists == IfExistsOverwrite
+}
+
+func (deploy *Deploy) CheckProtected() error {
+	if deploy.Target.Protected && deploy.Target.Environment == "" {
+		return &ValidationError{Path: rootPath + ".Target.Protected", Reason: "a protected target needs an Environment to confirm"}
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestDestructive(t *testing.T) {
+	tests := []struct {
+		name        string
+		script      *Script
+		destructive bool
+	}{
+		{name: "run", script: &Script{Run: &ScriptRun{Path: "true"}}, destructive: true},
+		{name: "delete", script: &Script{Delete: &ScriptDelete{Path: "/srv/app"}}, destructive: true},
+		{name: "copy overwriting", script: &Script{Copy: &ScriptCopy{From: "app", To: "/srv/app"}}, destructive: true},
+		{name: "copy skipping", script: &Script{Copy: &ScriptCopy{From: "app", To: "/srv/app", IfExists: IfExistsSkip}}, destructive: false},
+		{name: "copy with backup", script: &Script{Copy: &ScriptCopy{From: "app", To: "/srv/app", IfExists: IfExistsBackup}}, destructive: false},
+		{name: "template", script: &Script{Template: &ScriptTemplate{Source: "app.conf.tmpl", To: "/etc/app.conf"}}, destructive: true},
+		{name: "file content", script: &Script{File: &ScriptFile{Path: "/etc/app.conf", State: FileStateFile, Content: "port=80"}}, destructive: true},
+		{name: "file link", script: &Script{File: &ScriptFile{Path: "/srv/current", State: FileStateLink, Source: "/srv/releases/2"}}, destructive: true},
+		{name: "file absent", script: &Script{File: &ScriptFile{Path: "/etc/app.conf", State: FileStateAbsent}}, destructive: true},
+		{name: "file directory", script: &Script{File: &ScriptFile{Path: "/srv/app", State: FileStateDirectory}}, destructive: false},
+		{name: "symlink", script: &Script{Symlink: &ScriptSymlink{Target: "/srv/releases/2", Link: "/srv/current"}}, destructive: false},
+		{name: "forced symlink", script: &Script{Symlink: &ScriptSymlink{Target: "/srv/releases/2", Link: "/srv/current", Force: true}}, destructive: true},
+		{name: "wait", script: &Script{Wait: &ScriptWait{URL: "http://localhost/health"}}, destructive: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if destructive := test.script.Destructive(); destructive != test.destructive {
+				t.Errorf("Destructive() = %t, expected %t", destructive, test.destructive)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+type (
+	Directories struct {
+		Mode  Mode
+		Owner string
+	}
+)
+
+func (directories Directories) Merge(override Directories) Directories {
+	if override.Mode.Set {
+		directories.Mode = override.Mode
+	}
+
+	if override.Owner != "" {
+		directories.Owner = override.Owner
+	}
+
+	return directories
+}
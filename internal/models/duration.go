@@ -0,0 +1,51 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+type (
+	Duration struct {
+		time.Duration
+		Bare bool
+	}
+)
+
+var ErrDurationFormat = errors.New("duration must be a string like \"90s\", \"5m\" or \"1h30m\"")
+
+func (duration *Duration) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		text, err := strconv.Unquote(string(data))
+		if err != nil {
+			return ErrDurationFormat
+		}
+
+		parsed, err := time.ParseDuration(text)
+		if err != nil {
+			return err
+		}
+
+		duration.Duration = parsed
+		duration.Bare = false
+		return nil
+	}
+
+	seconds, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return ErrDurationFormat
+	}
+
+	duration.Duration = time.Duration(seconds) * time.Second
+	duration.Bare = true
+	return nil
+}
+
+func (duration Duration) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(duration.Duration.String())), nil
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+type (
+	Encoding string
+)
+
+const (
+	EncodingUTF8   Encoding = "utf-8"
+	EncodingLatin1 Encoding = "latin1"
+	EncodingCP1251 Encoding = "cp1251"
+)
+
+var ErrEncodingUnknown = errors.New("encoding must be \"utf-8\", \"latin1\" or \"cp1251\"")
+
+func (encoding *Encoding) UnmarshalJSON(data []byte) error {
+	text, err := strconv.Unquote(string(data))
+	if err != nil {
+		return ErrEncodingUnknown
+	}
+
+	switch strings.ToLower(text) {
+	case "", "utf-8", "utf8":
+		*encoding = EncodingUTF8
+	case "latin1", "latin-1", "iso-8859-1":
+		*encoding = EncodingLatin1
+	case "cp1251", "windows-1251":
+		*encoding = EncodingCP1251
+	default:
+		return ErrEncodingUnknown
+	}
+
+	return nil
+}
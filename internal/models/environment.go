@@ -0,0 +1,60 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/bytedance/sonic"
+)
+
+type (
+	Environment map[string]string
+)
+
+var ErrEnvironmentFormat = errors.New("environment must be an object or a list of KEY=VALUE strings")
+
+func (environment *Environment) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	values := make(map[string]string)
+
+	if len(data) > 0 && data[0] == '[' {
+		pairs := []string(nil)
+
+		err := sonic.Unmarshal(data, &pairs)
+		if err != nil {
+			return ErrEnvironmentFormat
+		}
+
+		for _, pair := range pairs {
+			name, value, ok := strings.Cut(pair, "=")
+			if !ok || name == "" {
+				return ErrEnvironmentFormat
+			}
+
+			values[name] = value
+		}
+	} else {
+		err := sonic.Unmarshal(data, &values)
+		if err != nil {
+			return ErrEnvironmentFormat
+		}
+	}
+
+	*environment = values
+	return nil
+}
+
+func (environment Environment) Names() []string {
+	names := make([]string, 0, len(environment))
+
+	for name := range environment {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
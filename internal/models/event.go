@@ -0,0 +1,39 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+)
+
+type (
+	EventType string
+)
+
+const (
+	EventRunStarted   EventType = "run.started"
+	EventRunFinished  EventType = "run.finished"
+	EventScriptFailed EventType = "script.failed"
+)
+
+var ErrEventTypeUnknown = errors.New("event must be \"run.started\", \"run.finished\" or \"script.failed\"")
+
+func (event *EventType) UnmarshalJSON(data []byte) error {
+	text, err := strconv.Unquote(string(data))
+	if err != nil {
+		return ErrEventTypeUnknown
+	}
+
+	switch EventType(text) {
+	case EventRunStarted, EventRunFinished, EventScriptFailed:
+		*event = EventType(text)
+	default:
+		return ErrEventTypeUnknown
+	}
+
+	return nil
+}
+
+func (sink *EventSink) Wants(event EventType) bool {
+	return len(sink.Events) == 0 || slices.Contains(sink.Events, event)
+}
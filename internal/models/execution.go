@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+)
+
+type (
+	Execution string
+)
+
+const (
+	ExecutionLocal Execution = "local"
+	ExecutionSSH   Execution = "ssh"
+)
+
+var ErrExecutionUnknown = errors.New("execution must be \"local\" or \"ssh\"")
+
+func (execution *Execution) UnmarshalJSON(data []byte) error {
+	text, err := strconv.Unquote(string(data))
+	if err != nil {
+		return ErrExecutionUnknown
+	}
+
+	switch Execution(text) {
+	case "", ExecutionLocal, ExecutionSSH:
+		*execution = Execution(text)
+	default:
+		return ErrExecutionUnknown
+	}
+
+	return nil
+}
+
+func (deploy *Deploy) CheckExecution() error {
+	if deploy.Target.Execution != ExecutionSSH {
+		return nil
+	}
+
+	for name, script := range deploy.Scripts.Scripts {
+		if script == nil {
+			continue
+		}
+
+		switch script.Type() {
+		case "Download", "File", "Template", "Delete", "Archive", "Extract", "Manifest":
+			return &ValidationError{Path: rootPath + ".Scripts." + name + "." + script.Type(), Reason: script.Type() + " scripts act on the local machine and cannot run with Execution ssh"}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+)
+
+type (
+	IfExists string
+)
+
+const (
+	IfExistsOverwrite IfExists = "overwrite"
+	IfExistsSkip      IfExists = "skip"
+	IfExistsFail      IfExists = "fail"
+	IfExistsBackup    IfExists = "backup"
+)
+
+var ErrIfExistsUnknown = errors.New("if exists must be \"overwrite\", \"skip\", \"fail\" or \"backup\"")
+
+func (ifExists *IfExists) UnmarshalJSON(data []byte) error {
+	text, err := strconv.Unquote(string(data))
+	if err != nil {
+		return ErrIfExistsUnknown
+	}
+
+	switch IfExists(text) {
+	case "", IfExistsOverwrite:
+		*ifExists = IfExistsOverwrite
+	case IfExistsSkip, IfExistsFail, IfExistsBackup:
+		*ifExists = IfExists(text)
+	default:
+		return ErrIfExistsUnknown
+	}
+
+	return nil
+}
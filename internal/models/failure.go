@@ -0,0 +1,55 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+)
+
+type (
+	OnFailure string
+)
+
+const (
+	OnFailureStop     OnFailure = "stop"
+	OnFailureContinue OnFailure = "continue"
+	OnFailureRollback OnFailure = "rollback"
+)
+
+var ErrOnFailureUnknown = errors.New("on failure must be \"stop\", \"continue\" or \"rollback\"")
+
+func (onFailure *OnFailure) UnmarshalJSON(data []byte) error {
+	text, err := strconv.Unquote(string(data))
+	if err != nil {
+		return ErrOnFailureUnknown
+	}
+
+	switch OnFailure(text) {
+	case "", OnFailureStop, OnFailureContinue, OnFailureRollback:
+		*onFailure = OnFailure(text)
+	default:
+		return ErrOnFailureUnknown
+	}
+
+	return nil
+}
+
+func (deploy *Deploy) ApplyOnFailure() error {
+	switch deploy.OnFailure {
+	case "":
+		return nil
+	case OnFailureContinue:
+		if deploy.Strategy == StrategyFailFast {
+			return &ValidationError{Path: rootPath + ".OnFailure", Reason: "continue conflicts with Strategy fail_fast"}
+		}
+
+		deploy.Strategy = StrategyContinue
+	default:
+		if deploy.Strategy == StrategyContinue {
+			return &ValidationError{Path: rootPath + ".OnFailure", Reason: string(deploy.OnFailure) + " conflicts with Strategy continue"}
+		}
+
+		deploy.Strategy = StrategyFailFast
+	}
+
+	return nil
+}
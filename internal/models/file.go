@@ -0,0 +1,110 @@
+package models
+
+import (
+	"errors"
+	"io/fs"
+	"strconv"
+)
+
+type (
+	FileState string
+
+	Mode struct {
+		fs.FileMode
+		Set bool
+	}
+)
+
+const (
+	FileStateFile      FileState = "file"
+	FileStateDirectory FileState = "directory"
+	FileStateLink      FileState = "link"
+	FileStateAbsent    FileState = "absent"
+)
+
+var (
+	ErrFileStateUnknown = errors.New("file state must be \"file\", \"directory\", \"link\" or \"absent\"")
+	ErrModeFormat       = errors.New("mode must be an octal string like \"0644\"")
+)
+
+func (state *FileState) UnmarshalJSON(data []byte) error {
+	text, err := strconv.Unquote(string(data))
+	if err != nil {
+		return ErrFileStateUnknown
+	}
+
+	switch FileState(text) {
+	case "", FileStateFile:
+		*state = FileStateFile
+	case FileStateDirectory, FileStateLink, FileStateAbsent:
+		*state = FileState(text)
+	default:
+		return ErrFileStateUnknown
+	}
+
+	return nil
+}
+
+func (mode *Mode) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	text, err := strconv.Unquote(string(data))
+	if err != nil {
+		return ErrModeFormat
+	}
+
+	value, err := strconv.ParseUint(text, 8, 32)
+	if err != nil || value > 0o7777 {
+		return ErrModeFormat
+	}
+
+	mode.FileMode = fs.FileMode(value&0o777) | specialBits(value)
+	mode.Set = true
+	return nil
+}
+
+func (mode Mode) MarshalJSON() ([]byte, error) {
+	if !mode.Set {
+		return []byte("null"), nil
+	}
+
+	return []byte(strconv.Quote("0" + strconv.FormatUint(uint64(mode.Octal()), 8))), nil
+}
+
+func (mode Mode) Octal() uint32 {
+	value := uint32(mode.Perm())
+
+	if mode.FileMode&fs.ModeSetuid != 0 {
+		value |= 0o4000
+	}
+
+	if mode.FileMode&fs.ModeSetgid != 0 {
+		value |= 0o2000
+	}
+
+	if mode.FileMode&fs.ModeSticky != 0 {
+		value |= 0o1000
+	}
+
+	return value
+}
+
+func specialBits(value uint64) fs.FileMode {
+	mode := fs.FileMode(0)
+
+	if value&0o4000 != 0 {
+		mode |= fs.ModeSetuid
+	}
+
+	if value&0o2000 != 0 {
+		mode |= fs.ModeSetgid
+	}
+
+	if value&0o1000 != 0 {
+		mode |= fs.ModeSticky
+	}
+
+	return mode
+}
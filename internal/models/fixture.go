@@ -0,0 +1,14 @@
+package models
+
+type (
+	Fixture struct {
+		Effect string
+		Args   []string
+		Output []byte
+		Error  string
+	}
+
+	Fixtures struct {
+		Fixtures []*Fixture
+	}
+)
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+type (
+	RunResult struct {
+		Name     string
+		Host     string
+		Type     string
+		Start    time.Time
+		Duration time.Duration
+		Skipped  bool
+		Outcome  string
+		CPU      time.Duration
+		MaxRSS   int64
+		Read     int64
+		Written  int64
+		Error    string
+	}
+
+	Run struct {
+		ID       int
+		Start    time.Time
+		Duration time.Duration
+		Config   string
+		Failed   bool
+		Aborted  bool
+		Error    string
+		Results  []*RunResult
+	}
+)
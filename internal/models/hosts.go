@@ -0,0 +1,45 @@
+package models
+
+import (
+	"path"
+	"sort"
+)
+
+func (deploy *Deploy) Hosts() (hosts []string, err error) {
+	if len(deploy.Target.Hosts) == 0 {
+		return nil, nil
+	}
+
+	for name, remote := range deploy.Remotes.Remotes {
+		if remote == nil {
+			continue
+		}
+
+		for _, pattern := range deploy.Target.Hosts {
+			ok, err := path.Match(pattern, name)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				hosts = append(hosts, name)
+				break
+			}
+		}
+	}
+
+	sort.Strings(hosts)
+	return hosts, nil
+}
+
+func Label(name, host string) string {
+	if host == "" {
+		return name
+	}
+
+	return name + "@" + host
+}
+
+func (result *RunResult) Label() string {
+	return Label(result.Name, result.Host)
+}
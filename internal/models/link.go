@@ -0,0 +1,120 @@
+package models
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type (
+	LinkError struct {
+		Script string
+		Follow string
+		Line   int
+	}
+
+	CycleError struct {
+		Scripts []string
+	}
+)
+
+func (err *LinkError) Error() string {
+	location := ""
+	if err.Line > 0 {
+		location = "line " + strconv.Itoa(err.Line) + ": "
+	}
+
+	return location + "script " + err.Script + " follows undefined script " + err.Follow
+}
+
+func (err *CycleError) Error() string {
+	return "scripts form a follow cycle: " + strings.Join(err.Scripts, ", ")
+}
+
+func (deploy *Deploy) Link(source []byte) error {
+	names := make([]string, 0, len(deploy.Scripts.Scripts))
+	pending := make(map[string]int, len(deploy.Scripts.Scripts))
+	followers := make(map[string][]string, len(deploy.Scripts.Scripts))
+
+	for name, script := range deploy.Scripts.Scripts {
+		if script == nil {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		script := deploy.Scripts.Scripts[name]
+
+		for _, follow := range script.Follow {
+			if deploy.Scripts.Scripts[follow] == nil {
+				return &LinkError{Script: name, Follow: follow, Line: Line(source, name)}
+			}
+
+			if !script.Commits() && deploy.Scripts.Scripts[follow].Commits() {
+				return &PhaseError{Script: name, Follow: follow, Line: Line(source, name)}
+			}
+
+			pending[name]++
+			followers[follow] = append(followers[follow], name)
+		}
+	}
+
+	ready := []string(nil)
+	for _, name := range names {
+		if pending[name] == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	order := make([]string, 0, len(names))
+
+	for len(ready) > 0 {
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+
+		for _, follower := range followers[name] {
+			pending[follower]--
+			if pending[follower] == 0 {
+				ready = append(ready, follower)
+			}
+		}
+
+		sort.Strings(ready)
+	}
+
+	if len(order) != len(names) {
+		cycle := []string(nil)
+
+		for _, name := range names {
+			if pending[name] > 0 {
+				cycle = append(cycle, name)
+			}
+		}
+
+		return &CycleError{Scripts: cycle}
+	}
+
+	deploy.order = order
+	return nil
+}
+
+func (deploy *Deploy) Order() []string {
+	return slices.Clone(deploy.order)
+}
+
+func Line(source []byte, key string) int {
+	location := regexp.MustCompile(regexp.QuoteMeta(strconv.Quote(key)) + `\s*:`).FindIndex(source)
+	if location == nil {
+		return 0
+	}
+
+	return bytes.Count(source[:location[0]], []byte{'\n'}) + 1
+}
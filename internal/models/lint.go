@@ -0,0 +1,67 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+)
+
+type (
+	Severity string
+
+	LintRule struct {
+		Name     string `validate:"required"`
+		Severity Severity
+		Each     LintEach
+		Assert   string `validate:"required"`
+		Message  string `validate:"required"`
+	}
+
+	LintEach string
+)
+
+const (
+	SeverityOff     Severity = "off"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+const (
+	LintEachDocument LintEach = "document"
+	LintEachScript   LintEach = "script"
+)
+
+var ErrLintEachUnknown = errors.New("lint rule each must be \"document\" or \"script\"")
+
+var ErrSeverityUnknown = errors.New("severity must be \"off\", \"warning\" or \"error\"")
+
+func (severity *Severity) UnmarshalJSON(data []byte) error {
+	text, err := strconv.Unquote(string(data))
+	if err != nil {
+		return ErrSeverityUnknown
+	}
+
+	switch Severity(text) {
+	case "", SeverityOff, SeverityWarning, SeverityError:
+		*severity = Severity(text)
+	default:
+		return ErrSeverityUnknown
+	}
+
+	return nil
+}
+
+func (each *LintEach) UnmarshalJSON(data []byte) error {
+	text, err := strconv.Unquote(string(data))
+	if err != nil {
+		return ErrLintEachUnknown
+	}
+
+	switch LintEach(text) {
+	case "", LintEachDocument, LintEachScript:
+		*each = LintEach(text)
+	default:
+		return ErrLintEachUnknown
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/bytedance/sonic"
+)
+
+var strictAPI = sonic.Config{DisallowUnknownFields: true}.Froze()
+
+func Load(reader io.Reader, strict bool) (deploys []*Deploy, err error) {
+	api := sonic.ConfigDefault
+	if strict {
+		api = strictAPI
+	}
+
+	source, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := api.NewDecoder(bytes.NewReader(source))
+
+	for decoder.More() {
+		raw := json.RawMessage(nil)
+
+		err = decoder.Decode(&raw)
+		if err != nil {
+			return nil, err
+		}
+
+		deploy := new(Deploy)
+
+		err = api.Unmarshal(raw, deploy)
+		if err != nil {
+			return nil, err
+		}
+
+		err = api.Unmarshal(raw, &deploy.document)
+		if err != nil {
+			return nil, err
+		}
+
+		version := max(deploy.Version, 1)
+		if version > SchemaVersion || (strict && version < SchemaVersion) {
+			return nil, &VersionError{Version: version}
+		}
+
+		err = deploy.ApplyDefaults()
+		if err != nil {
+			return nil, err
+		}
+
+		err = deploy.ApplyConnections()
+		if err != nil {
+			return nil, err
+		}
+
+		err = deploy.ApplyOnFailure()
+		if err != nil {
+			return nil, err
+		}
+
+		err = deploy.CheckExecution()
+		if err != nil {
+			return nil, err
+		}
+
+		err = deploy.CheckProtected()
+		if err != nil {
+			return nil, err
+		}
+
+		err = deploy.CheckReadOnly()
+		if err != nil {
+			return nil, err
+		}
+
+		err = deploy.CheckWhen()
+		if err != nil {
+			return nil, err
+		}
+
+		err = deploy.CheckRedact()
+		if err != nil {
+			return nil, err
+		}
+
+		err = deploy.CheckWait()
+		if err != nil {
+			return nil, err
+		}
+
+		err = deploy.CheckSecrets()
+		if err != nil {
+			return nil, err
+		}
+
+		err = deploy.CheckCompat()
+		if err != nil {
+			return nil, err
+		}
+
+		err = Validate(deploy, strict)
+		if err != nil {
+			return nil, err
+		}
+
+		err = deploy.Link(source)
+		if err != nil {
+			return nil, err
+		}
+
+		deploys = append(deploys, deploy)
+	}
+
+	sort.SliceStable(deploys, func(i, j int) bool {
+		return deploys[i].Target.Order < deploys[j].Target.Order
+	})
+
+	return deploys, nil
+}
+
+func Select(deploys []*Deploy, environment, stage string) []*Deploy {
+	selected := []*Deploy(nil)
+
+	for _, deploy := range deploys {
+		if deploy.Target.Matches(environment, stage) {
+			selected = append(selected, deploy)
+		}
+	}
+
+	return selected
+}
+
+func (target *Target) Matches(environment, stage string) bool {
+	return (environment == "" || target.Environment == "" || target.Environment == environment) &&
+		(stage == "" || target.Stage == "" || target.Stage == stage)
+}
@@ -0,0 +1,8 @@
+package models
+
+type (
+	Lock struct {
+		Config    string
+		Artifacts map[string]string
+	}
+)
@@ -0,0 +1,14 @@
+package models
+
+type (
+	ManifestEntry struct {
+		SHA256 string
+		Size   int64
+		Mode   Mode
+		Link   string
+	}
+
+	Manifest struct {
+		Files map[string]*ManifestEntry
+	}
+)
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/bytedance/sonic"
+)
+
+type (
+	Migration func(document map[string]any) error
+
+	VersionError struct {
+		Version int
+	}
+)
+
+const SchemaVersion = 2
+
+var migrations = map[int]Migration{
+	1: migrateTimeouts,
+}
+
+var ErrDocumentFormat = errors.New("document must be a JSON object")
+
+func (err *VersionError) Error() string {
+	if err.Version > SchemaVersion {
+		return "config version " + strconv.Itoa(err.Version) + " is newer than supported version " + strconv.Itoa(SchemaVersion)
+	}
+
+	return "config version " + strconv.Itoa(err.Version) + " is outdated, run migrate-config"
+}
+
+func MigrateConfig(reader io.Reader) (config []byte, err error) {
+	decoder := sonic.ConfigDefault.NewDecoder(reader)
+	buffer := new(bytes.Buffer)
+
+	for decoder.More() {
+		document := map[string]any(nil)
+
+		err = decoder.Decode(&document)
+		if err != nil {
+			return nil, err
+		}
+
+		if document == nil {
+			return nil, ErrDocumentFormat
+		}
+
+		err = Migrate(document)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := sonic.ConfigStd.MarshalIndent(document, "", "\t")
+		if err != nil {
+			return nil, err
+		}
+
+		buffer.Write(data)
+		buffer.WriteByte('\n')
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func Migrate(document map[string]any) error {
+	version := documentVersion(document)
+	if version > SchemaVersion {
+		return &VersionError{Version: version}
+	}
+
+	for ; version < SchemaVersion; version++ {
+		migration, ok := migrations[version]
+		if ok {
+			err := migration(document)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	document["Version"] = SchemaVersion
+	return nil
+}
+
+func documentVersion(document map[string]any) int {
+	value, ok := document["Version"].(float64)
+	if !ok || value < 1 {
+		return 1
+	}
+
+	return int(value)
+}
+
+func migrateTimeouts(document map[string]any) error {
+	for _, section := range []string{"Scripts", "Defaults"} {
+		scripts, _ := document[section].(map[string]any)
+
+		for _, value := range scripts {
+			script, _ := value.(map[string]any)
+			if script == nil {
+				continue
+			}
+
+			if seconds, ok := script["Timeout"].(float64); ok {
+				script["Timeout"] = fmt.Sprintf("%ds", int64(seconds))
+			}
+		}
+	}
+
+	return nil
+}
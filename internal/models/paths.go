@@ -0,0 +1,141 @@
+package models
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+func Resolve(deploys []*Deploy, directory string) error {
+	for _, deploy := range deploys {
+		err := deploy.ResolvePaths(directory)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (deploy *Deploy) ResolvePaths(directory string) error {
+	base := deploy.BaseDir
+	if base == "" {
+		base = directory
+	} else if relative(base) {
+		base = filepath.Join(directory, base)
+	}
+
+	base, err := filepath.Abs(base)
+	if err != nil {
+		return err
+	}
+
+	deploy.BaseDir = base
+
+	resolve := func(paths ...*string) {
+		for _, path := range paths {
+			if relative(*path) {
+				*path = filepath.Join(base, *path)
+			}
+		}
+	}
+
+	local := deploy.Target.Execution != ExecutionSSH
+
+	if local {
+		if deploy.Folder == "" {
+			deploy.Folder = base
+		}
+
+		resolve(&deploy.Folder)
+	}
+
+	resolve(&deploy.HTTP.CABundle, &deploy.HTTP.ClientCertificate, &deploy.HTTP.ClientKey)
+
+	for _, remote := range deploy.Remotes.Remotes {
+		if remote != nil && remote.Identity != "" {
+			resolve(&remote.Identity)
+		}
+	}
+
+	for _, connection := range deploy.Connections.Connections {
+		if connection != nil && connection.Identity != "" {
+			resolve(&connection.Identity)
+		}
+	}
+
+	for _, secret := range deploy.Secrets {
+		if secret != nil && secret.File != "" {
+			resolve(&secret.File)
+
+			if secret.Identity != "" {
+				resolve(&secret.Identity)
+			}
+		}
+	}
+
+	for _, script := range deploy.Scripts.Scripts {
+		if script == nil {
+			continue
+		}
+
+		switch {
+		case script.Move != nil:
+			resolve(&script.Move.From)
+			if local {
+				resolve(&script.Move.To)
+			}
+		case script.Copy != nil:
+			resolve(&script.Copy.From)
+			if local {
+				resolve(&script.Copy.To)
+			}
+		case script.Download != nil:
+			resolve(&script.Download.To)
+		case script.Run != nil:
+			if local {
+				resolve(&script.Run.Directory)
+
+				if script.Run.Interpreter != "" || strings.ContainsRune(script.Run.Path, '/') {
+					resolve(&script.Run.Path)
+				}
+
+				for i := range script.Run.SearchPath {
+					resolve(&script.Run.SearchPath[i])
+				}
+			}
+		case script.File != nil:
+			resolve(&script.File.Path)
+			if script.File.State != FileStateLink {
+				resolve(&script.File.Source)
+			}
+		case script.Template != nil:
+			resolve(&script.Template.Source, &script.Template.To)
+		case script.Symlink != nil:
+			if local {
+				resolve(&script.Symlink.Link)
+			}
+		case script.Delete != nil:
+			resolve(&script.Delete.Path)
+		case script.Archive != nil:
+			resolve(&script.Archive.From, &script.Archive.To)
+		case script.Extract != nil:
+			resolve(&script.Extract.From, &script.Extract.To)
+		case script.Manifest != nil:
+			resolve(&script.Manifest.Path, &script.Manifest.To)
+		case script.Deploy != nil:
+			if script.Deploy.Remote == "" {
+				resolve(&script.Deploy.Config)
+			}
+
+			for i := range script.Deploy.VariableFiles {
+				resolve(&script.Deploy.VariableFiles[i])
+			}
+		}
+	}
+
+	return nil
+}
+
+func relative(path string) bool {
+	return path != "" && !filepath.IsAbs(path) && !strings.HasPrefix(path, "$")
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+)
+
+type (
+	Phase string
+
+	PhaseError struct {
+		Script string
+		Follow string
+		Line   int
+	}
+)
+
+const (
+	PhasePrepare Phase = "prepare"
+	PhaseCommit  Phase = "commit"
+)
+
+var ErrPhaseUnknown = errors.New("phase must be \"prepare\" or \"commit\"")
+
+func (phase *Phase) UnmarshalJSON(data []byte) error {
+	text, err := strconv.Unquote(string(data))
+	if err != nil {
+		return ErrPhaseUnknown
+	}
+
+	switch Phase(text) {
+	case "", PhasePrepare, PhaseCommit:
+		*phase = Phase(text)
+	default:
+		return ErrPhaseUnknown
+	}
+
+	return nil
+}
+
+func (err *PhaseError) Error() string {
+	location := ""
+	if err.Line > 0 {
+		location = "line " + strconv.Itoa(err.Line) + ": "
+	}
+
+	return location + "prepare script " + err.Script + " follows commit script " + err.Follow
+}
+
+func (script *Script) Commits() bool {
+	return script.Phase == PhaseCommit
+}
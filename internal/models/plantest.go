@@ -0,0 +1,19 @@
+package models
+
+type (
+	PlanTest struct {
+		Name        string `validate:"required"`
+		Environment string
+		Stage       string
+		Container   bool
+		Variables   map[string]string
+		Env         map[string]string
+		Scripts     []string
+		Forbid      []string
+		Resolved    map[string]Environment
+	}
+
+	PlanTests struct {
+		Tests []*PlanTest
+	}
+)
@@ -0,0 +1,15 @@
+package models
+
+type (
+	Preflight struct {
+		Plan      bool
+		Timeout   Duration
+		Endpoints []*Endpoint
+	}
+
+	Endpoint struct {
+		Name    string
+		Address string `validate:"required"`
+		Remote  bool
+	}
+)
@@ -0,0 +1,11 @@
+package models
+
+func (deploy *Deploy) CheckReadOnly() error {
+	for name, script := range deploy.Scripts.Scripts {
+		if script != nil && script.ReadOnly && script.Run == nil {
+			return &ValidationError{Path: rootPath + ".Scripts." + name + ".ReadOnly", Reason: "only Run scripts can be marked read-only"}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"regexp"
+	"strconv"
+)
+
+func (deploy *Deploy) CheckRedact() error {
+	for i, pattern := range deploy.Redact {
+		_, err := regexp.Compile(pattern)
+		if err != nil {
+			return &ValidationError{Path: rootPath + ".Redact[" + strconv.Itoa(i) + "]", Reason: err.Error()}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package models
+
+type (
+	Retention struct {
+		Cache      Duration
+		Workspaces Duration
+		Runs       int
+	}
+)
+
+func Retain(deploys []*Deploy) (retention Retention) {
+	for _, deploy := range deploys {
+		retention.Cache.Duration = max(retention.Cache.Duration, deploy.Retention.Cache.Duration)
+		retention.Workspaces.Duration = max(retention.Workspaces.Duration, deploy.Retention.Workspaces.Duration)
+		retention.Runs = max(retention.Runs, deploy.Retention.Runs)
+	}
+
+	return retention
+}
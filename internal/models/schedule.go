@@ -0,0 +1,98 @@
+package models
+
+import (
+	"slices"
+	"sort"
+)
+
+type (
+	Schedule struct {
+		rank      map[string]int
+		pending   map[string]int
+		followers map[string][]string
+		ready     []string
+	}
+)
+
+func (deploy *Deploy) Schedule(names []string) *Schedule {
+	schedule := &Schedule{
+		rank:      make(map[string]int, len(names)),
+		pending:   make(map[string]int, len(names)),
+		followers: make(map[string][]string, len(names)),
+	}
+
+	for i, name := range deploy.order {
+		if slices.Contains(names, name) {
+			schedule.rank[name] = i
+		}
+	}
+
+	for name := range schedule.rank {
+		for _, follow := range deploy.Scripts.Scripts[name].Follow {
+			if _, ok := schedule.rank[follow]; ok {
+				schedule.pending[name]++
+				schedule.followers[follow] = append(schedule.followers[follow], name)
+			}
+		}
+	}
+
+	for name := range schedule.rank {
+		if schedule.pending[name] == 0 {
+			schedule.ready = append(schedule.ready, name)
+		}
+	}
+
+	schedule.sort()
+	return schedule
+}
+
+func (schedule *Schedule) Next() (name string, ok bool) {
+	if len(schedule.ready) == 0 {
+		return "", false
+	}
+
+	name = schedule.ready[0]
+	schedule.ready = schedule.ready[1:]
+
+	return name, true
+}
+
+func (schedule *Schedule) Done(name string) {
+	for _, follower := range schedule.followers[name] {
+		schedule.pending[follower]--
+		if schedule.pending[follower] == 0 {
+			schedule.ready = append(schedule.ready, follower)
+		}
+	}
+
+	schedule.sort()
+}
+
+func (schedule *Schedule) Fail(name string) (blocked []string) {
+	seen := map[string]bool{name: true}
+	queue := []string{name}
+
+	for len(queue) > 0 {
+		for _, follower := range schedule.followers[queue[0]] {
+			if !seen[follower] {
+				seen[follower] = true
+				blocked = append(blocked, follower)
+				queue = append(queue, follower)
+			}
+		}
+
+		queue = queue[1:]
+	}
+
+	sort.Slice(blocked, func(i, j int) bool {
+		return schedule.rank[blocked[i]] < schedule.rank[blocked[j]]
+	})
+
+	return blocked
+}
+
+func (schedule *Schedule) sort() {
+	sort.Slice(schedule.ready, func(i, j int) bool {
+		return schedule.rank[schedule.ready[i]] < schedule.rank[schedule.ready[j]]
+	})
+}
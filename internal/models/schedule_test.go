@@ -0,0 +1,85 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const scheduleConfig = `{
+	"Version": 2,
+	"Scripts": {
+		"build": {"Run": {"Path": "true"}},
+		"version": {"Run": {"Path": "true"}},
+		"install": {"Run": {"Path": "true"}, "Follow": ["build", "version"]},
+		"migrate": {"Run": {"Path": "true"}, "Follow": ["install"]},
+		"health": {"Run": {"Path": "true"}, "Follow": ["migrate"]},
+		"notify": {"Run": {"Path": "true"}, "Follow": ["version"]}
+	}
+}`
+
+func TestScheduleConcurrent(t *testing.T) {
+	deploys, err := Load(strings.NewReader(scheduleConfig), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deploy := deploys[0]
+
+	tests := []struct {
+		name     string
+		names    []string
+		fail     string
+		expected []string
+		blocked  []string
+	}{
+		{name: "all", names: deploy.Order(), expected: []string{"build", "version", "install", "migrate", "health", "notify"}},
+		{name: "subset", names: []string{"health", "install", "build"}, expected: []string{"build", "install", "health"}},
+		{name: "failed", names: deploy.Order(), fail: "install", expected: []string{"build", "version", "install", "notify"}, blocked: []string{"migrate", "health"}},
+		{name: "failed root", names: deploy.Order(), fail: "version", expected: []string{"build", "version"}, blocked: []string{"install", "migrate", "health", "notify"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			group := sync.WaitGroup{}
+
+			for range 16 {
+				group.Add(1)
+
+				go func() {
+					defer group.Done()
+
+					order, blocked := drain(deploy.Schedule(test.names), test.fail)
+
+					if !slices.Equal(order, test.expected) {
+						t.Errorf("scheduled %q, expected %q", order, test.expected)
+					}
+
+					if !slices.Equal(blocked, test.blocked) {
+						t.Errorf("blocked %q, expected %q", blocked, test.blocked)
+					}
+				}()
+			}
+
+			group.Wait()
+		})
+	}
+}
+
+func drain(schedule *Schedule, fail string) (order, blocked []string) {
+	for {
+		name, ok := schedule.Next()
+		if !ok {
+			return order, blocked
+		}
+
+		order = append(order, name)
+
+		if name == fail {
+			blocked = append(blocked, schedule.Fail(name)...)
+		} else {
+			schedule.Done(name)
+		}
+	}
+}
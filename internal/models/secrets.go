@@ -0,0 +1,58 @@
+package models
+
+type (
+	Secret struct {
+		Env      string
+		File     string
+		Vault    string
+		Key      string
+		Identity string
+	}
+)
+
+const (
+	SecretEnv   = "Env"
+	SecretFile  = "File"
+	SecretVault = "Vault"
+)
+
+func (secret *Secret) Provider() string {
+	switch {
+	case secret.Env != "":
+		return SecretEnv
+	case secret.File != "":
+		return SecretFile
+	case secret.Vault != "":
+		return SecretVault
+	}
+
+	return ""
+}
+
+func (deploy *Deploy) CheckSecrets() error {
+	for name, secret := range deploy.Secrets {
+		path := rootPath + ".Secrets." + name
+
+		if secret == nil {
+			return &ValidationError{Path: path, Reason: "one of Env, File or Vault is required"}
+		}
+
+		providers := 0
+		for _, value := range []string{secret.Env, secret.File, secret.Vault} {
+			if value != "" {
+				providers++
+			}
+		}
+
+		switch {
+		case providers == 0:
+			return &ValidationError{Path: path, Reason: "one of Env, File or Vault is required"}
+		case providers > 1:
+			return &ValidationError{Path: path, Reason: "Env, File and Vault are exclusive"}
+		case secret.Vault != "" && secret.Key == "":
+			return &ValidationError{Path: path, Reason: "Vault requires Key"}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type (
+	Serial struct {
+		Count   int
+		Percent float64
+	}
+)
+
+var ErrSerialFormat = errors.New("serial must be a positive host count or a percentage like \"25%\"")
+
+func (serial *Serial) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	text := string(data)
+
+	if len(data) > 0 && data[0] == '"' {
+		unquoted, err := strconv.Unquote(text)
+		if err != nil {
+			return ErrSerialFormat
+		}
+
+		text = unquoted
+	}
+
+	if percent, ok := strings.CutSuffix(text, "%"); ok {
+		value, err := strconv.ParseFloat(percent, 64)
+		if err != nil || value <= 0 || value > 100 {
+			return ErrSerialFormat
+		}
+
+		*serial = Serial{Percent: value}
+		return nil
+	}
+
+	count, err := strconv.Atoi(text)
+	if err != nil || count <= 0 {
+		return ErrSerialFormat
+	}
+
+	*serial = Serial{Count: count}
+	return nil
+}
+
+func (serial Serial) MarshalJSON() ([]byte, error) {
+	switch {
+	case serial.Percent > 0:
+		return []byte(strconv.Quote(strconv.FormatFloat(serial.Percent, 'f', -1, 64) + "%")), nil
+	case serial.Count > 0:
+		return []byte(strconv.Itoa(serial.Count)), nil
+	}
+
+	return []byte("null"), nil
+}
+
+func (serial Serial) Size(hosts int) int {
+	size := hosts
+
+	switch {
+	case serial.Percent > 0:
+		size = int(math.Ceil(float64(hosts) * serial.Percent / 100))
+	case serial.Count > 0:
+		size = serial.Count
+	}
+
+	return max(min(size, hosts), 1)
+}
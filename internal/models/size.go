@@ -0,0 +1,82 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+type (
+	Size int64
+)
+
+var ErrSizeFormat = errors.New("size must be a number of bytes or a string like \"512MiB\" or \"2GB\"")
+
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30}, {"TiB", 1 << 40},
+	{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"TB", 1e12},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}, {"T", 1 << 40},
+	{"B", 1},
+}
+
+func (size *Size) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	text := string(data)
+
+	if len(data) > 0 && data[0] == '"' {
+		unquoted, err := strconv.Unquote(text)
+		if err != nil {
+			return ErrSizeFormat
+		}
+
+		text = strings.TrimSpace(unquoted)
+	}
+
+	parsed, err := ParseSize(text)
+	if err != nil {
+		return err
+	}
+
+	*size = parsed
+	return nil
+}
+
+func ParseSize(text string) (Size, error) {
+	multiplier := int64(1)
+
+	for _, unit := range sizeUnits {
+		if number, ok := strings.CutSuffix(text, unit.suffix); ok {
+			text, multiplier = strings.TrimSpace(number), unit.multiplier
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil || value < 0 {
+		return 0, ErrSizeFormat
+	}
+
+	return Size(value * float64(multiplier)), nil
+}
+
+func (size Size) String() string {
+	const unit = 1024
+
+	if size < unit {
+		return strconv.FormatInt(int64(size), 10) + "B"
+	}
+
+	divisor, exponent := Size(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		divisor *= unit
+		exponent++
+	}
+
+	return strconv.FormatFloat(float64(size)/float64(divisor), 'f', 1, 64) + string("KMGTPE"[exponent]) + "iB"
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+)
+
+type (
+	Strategy string
+)
+
+const (
+	StrategyFailFast Strategy = "fail_fast"
+	StrategyContinue Strategy = "continue"
+)
+
+var ErrStrategyUnknown = errors.New("strategy must be \"fail_fast\" or \"continue\"")
+
+func (strategy *Strategy) UnmarshalJSON(data []byte) error {
+	text, err := strconv.Unquote(string(data))
+	if err != nil {
+		return ErrStrategyUnknown
+	}
+
+	switch Strategy(text) {
+	case "", StrategyFailFast:
+		*strategy = StrategyFailFast
+	case StrategyContinue:
+		*strategy = StrategyContinue
+	default:
+		return ErrStrategyUnknown
+	}
+
+	return nil
+}
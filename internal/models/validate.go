@@ -0,0 +1,105 @@
+package models
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type (
+	ValidationError struct {
+		Path   string
+		Reason string
+	}
+)
+
+const rootPath = "deploy"
+
+func (err *ValidationError) Error() string {
+	return err.Path + ": " + err.Reason
+}
+
+func Validate(value any, strict bool) error {
+	return validate(rootPath, reflect.ValueOf(value), strict)
+}
+
+func validate(path string, value reflect.Value, strict bool) error {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+
+		return validate(path, value.Elem(), strict)
+	case reflect.Map:
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+
+		for _, key := range keys {
+			err := validate(path+"."+key.String(), value.MapIndex(key), strict)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			err := validate(path+"["+strconv.Itoa(i)+"]", value.Index(i), strict)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		if duration, ok := value.Interface().(Duration); ok {
+			if strict && duration.Bare {
+				return &ValidationError{Path: path, Reason: "bare integer duration is ambiguous, use a unit like \"90s\""}
+			}
+
+			return nil
+		}
+
+		valueType := value.Type()
+
+		for i := 0; i < valueType.NumField(); i++ {
+			field := valueType.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			rules := field.Tag.Get("validate")
+			if rules == "-" {
+				continue
+			}
+
+			fieldPath := path
+			if !field.Anonymous {
+				fieldPath = path + "." + field.Name
+			}
+
+			fieldValue := value.Field(i)
+
+			if strict && hasRule(rules, "required") && fieldValue.IsZero() {
+				return &ValidationError{Path: fieldPath, Reason: "required field is missing"}
+			}
+
+			err := validate(fieldPath, fieldValue, strict)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func hasRule(tag, rule string) bool {
+	for _, candidate := range strings.Split(tag, ",") {
+		if candidate == rule {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,22 @@
+package models
+
+func (deploy *Deploy) CheckWait() error {
+	for name, script := range deploy.Scripts.Scripts {
+		if script == nil || script.Wait == nil {
+			continue
+		}
+
+		path := rootPath + ".Scripts." + name + ".Wait"
+
+		switch wait := script.Wait; {
+		case wait.URL == "" && wait.Address == "":
+			return &ValidationError{Path: path, Reason: "one of URL or Address is required"}
+		case wait.URL != "" && wait.Address != "":
+			return &ValidationError{Path: path, Reason: "URL conflicts with Address"}
+		case wait.Address != "" && (wait.Status != 0 || wait.Body != ""):
+			return &ValidationError{Path: path, Reason: "Status and Body only apply to URL"}
+		}
+	}
+
+	return nil
+}
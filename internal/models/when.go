@@ -0,0 +1,18 @@
+package models
+
+import "github.com/gohryt/dotdeploy/internal/expr"
+
+func (deploy *Deploy) CheckWhen() error {
+	for name, script := range deploy.Scripts.Scripts {
+		if script == nil || script.When == "" {
+			continue
+		}
+
+		_, err := expr.Compile(script.When)
+		if err != nil {
+			return &ValidationError{Path: rootPath + ".Scripts." + name + ".When", Reason: err.Error()}
+		}
+	}
+
+	return nil
+}
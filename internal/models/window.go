@@ -0,0 +1,144 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	Window struct {
+		Start    time.Duration
+		End      time.Duration
+		Location *time.Location
+		Text     string
+	}
+
+	Overrun string
+)
+
+const (
+	OverrunWarn  Overrun = "warn"
+	OverrunAbort Overrun = "abort"
+)
+
+var (
+	ErrWindowFormat   = errors.New("window must be like \"02:00-04:00\" or \"02:00-04:00 Europe/Kyiv\"")
+	ErrOverrunUnknown = errors.New("overrun must be \"warn\" or \"abort\"")
+)
+
+func (window *Window) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	text, err := strconv.Unquote(string(data))
+	if err != nil {
+		return ErrWindowFormat
+	}
+
+	if strings.TrimSpace(text) == "" {
+		*window = Window{}
+		return nil
+	}
+
+	span, zone, _ := strings.Cut(strings.TrimSpace(text), " ")
+
+	from, to, ok := strings.Cut(span, "-")
+	if !ok {
+		return ErrWindowFormat
+	}
+
+	start, err := clock(from)
+	if err != nil {
+		return err
+	}
+
+	end, err := clock(to)
+	if err != nil {
+		return err
+	}
+
+	location := time.Local
+
+	if zone = strings.TrimSpace(zone); zone != "" {
+		location, err = time.LoadLocation(zone)
+		if err != nil {
+			return err
+		}
+	}
+
+	*window = Window{Start: start, End: end, Location: location, Text: text}
+	return nil
+}
+
+func (window Window) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(window.Text)), nil
+}
+
+func (window *Window) IsZero() bool {
+	return window.Location == nil
+}
+
+func (window *Window) Bounds(now time.Time) (start, end time.Time, open bool) {
+	length := window.End - window.Start
+	if length <= 0 {
+		length += 24 * time.Hour
+	}
+
+	local := now.In(window.Location)
+
+	for day := -1; day <= 1; day++ {
+		date := local.AddDate(0, 0, day)
+		start = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, window.Location).Add(window.Start)
+		end = start.Add(length)
+
+		if now.Before(start) {
+			return start, end, false
+		}
+
+		if now.Before(end) {
+			return start, end, true
+		}
+	}
+
+	return start, end, false
+}
+
+func clock(text string) (time.Duration, error) {
+	hours, minutes, ok := strings.Cut(strings.TrimSpace(text), ":")
+	if !ok {
+		return 0, ErrWindowFormat
+	}
+
+	hour, err := strconv.Atoi(hours)
+	if err != nil || hour < 0 || hour > 24 {
+		return 0, ErrWindowFormat
+	}
+
+	minute, err := strconv.Atoi(minutes)
+	if err != nil || minute < 0 || minute > 59 || (hour == 24 && minute > 0) {
+		return 0, ErrWindowFormat
+	}
+
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, nil
+}
+
+func (overrun *Overrun) UnmarshalJSON(data []byte) error {
+	text, err := strconv.Unquote(string(data))
+	if err != nil {
+		return ErrOverrunUnknown
+	}
+
+	switch Overrun(text) {
+	case "", OverrunWarn:
+		*overrun = OverrunWarn
+	case OverrunAbort:
+		*overrun = OverrunAbort
+	default:
+		return ErrOverrunUnknown
+	}
+
+	return nil
+}
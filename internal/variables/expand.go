@@ -0,0 +1,94 @@
+package variables
+
+import (
+	"os"
+	"strings"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	UndefinedError struct {
+		Name string
+	}
+)
+
+const (
+	secretPrefix = "secret:"
+	concealed    = "***"
+)
+
+func (err *UndefinedError) Error() string {
+	return "variable " + err.Name + " is not defined"
+}
+
+func (resolver *Resolver) SetSecret(name, value string) {
+	resolver.mutex.Lock()
+	defer resolver.mutex.Unlock()
+
+	resolver.secrets[name] = value
+}
+
+func (resolver *Resolver) Secret(name string) (value string, ok bool) {
+	resolver.mutex.RLock()
+	defer resolver.mutex.RUnlock()
+
+	value, ok = resolver.secrets[name]
+	return value, ok
+}
+
+func (resolver *Resolver) Conceal(text string) string {
+	resolver.mutex.RLock()
+	defer resolver.mutex.RUnlock()
+
+	for _, value := range resolver.secrets {
+		if value != "" {
+			text = strings.ReplaceAll(text, value, concealed)
+		}
+	}
+
+	return text
+}
+
+func (resolver *Resolver) Expand(text string) (string, error) {
+	err := error(nil)
+
+	expanded := os.Expand(text, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+
+		if secret, ok := strings.CutPrefix(name, secretPrefix); ok {
+			value, ok := resolver.Secret(secret)
+			if !ok && err == nil {
+				err = &UndefinedError{Name: name}
+			}
+
+			return value
+		}
+
+		value, ok := resolver.Lookup(name)
+		if !ok && err == nil {
+			err = &UndefinedError{Name: name}
+		}
+
+		return value
+	})
+
+	return expanded, err
+}
+
+func (resolver *Resolver) Environment(environment models.Environment) (list []string, err error) {
+	list = make([]string, 0, len(environment))
+
+	for _, name := range environment.Names() {
+		value, err := resolver.Expand(environment[name])
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, name+"="+value)
+	}
+
+	return list, nil
+}
@@ -0,0 +1,51 @@
+package variables
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	resolver := NewResolver()
+	resolver.Set(SourceConfig, "VERSION", "1.4.2")
+	resolver.SetSecret("TOKEN", "hunter2")
+
+	tests := []struct {
+		text      string
+		expected  string
+		undefined string
+	}{
+		{text: "app-${VERSION}.tar.gz", expected: "app-1.4.2.tar.gz"},
+		{text: "app-$VERSION", expected: "app-1.4.2"},
+		{text: "echo arg=$$1", expected: "echo arg=$1"},
+		{text: "cd $$HOME && echo $$$$", expected: "cd $HOME && echo $$"},
+		{text: "price: $$5 for ${VERSION}", expected: "price: $5 for 1.4.2"},
+		{text: "Bearer ${secret:TOKEN}", expected: "Bearer hunter2"},
+		{text: "echo $1", undefined: "1"},
+		{text: "${MISSING}", undefined: "MISSING"},
+		{text: "${secret:MISSING}", undefined: "secret:MISSING"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			expanded, err := resolver.Expand(test.text)
+
+			if test.undefined != "" {
+				undefined := new(UndefinedError)
+				if !errors.As(err, &undefined) || undefined.Name != test.undefined {
+					t.Fatalf("expected variable %s to be undefined, got %v", test.undefined, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if expanded != test.expected {
+				t.Errorf("expanded to %q, expected %q", expanded, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package variables
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bytedance/sonic"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+type (
+	Source int
+
+	Resolver struct {
+		mutex   sync.RWMutex
+		values  map[string]string
+		sources map[string]Source
+		secrets map[string]string
+	}
+)
+
+const (
+	SourceBuiltin Source = iota
+	SourceFile
+	SourceConfig
+	SourceHost
+	SourceRegister
+	SourceEnvironment
+	SourceFlag
+)
+
+var ErrFlagFormat = errors.New("variable must be in key=value format")
+
+func (source Source) String() string {
+	switch source {
+	case SourceBuiltin:
+		return "builtin"
+	case SourceFile:
+		return "file"
+	case SourceConfig:
+		return "config"
+	case SourceHost:
+		return "host"
+	case SourceRegister:
+		return "register"
+	case SourceEnvironment:
+		return "environment"
+	case SourceFlag:
+		return "flag"
+	}
+
+	return "unknown"
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{
+		values:  make(map[string]string),
+		sources: make(map[string]Source),
+		secrets: make(map[string]string),
+	}
+}
+
+func Resolve(deploy *models.Deploy, files []string, flags []string) (resolver *Resolver, err error) {
+	resolver = NewResolver()
+	resolver.LoadBuiltins(&deploy.Target)
+
+	for _, file := range files {
+		err = resolver.LoadFile(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resolver.LoadMap(SourceConfig, deploy.Variables.Variables)
+	resolver.LoadEnvironment(os.Environ())
+
+	err = resolver.LoadFlags(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolver, nil
+}
+
+func (resolver *Resolver) Set(source Source, name, value string) {
+	resolver.mutex.Lock()
+	defer resolver.mutex.Unlock()
+
+	current, ok := resolver.sources[name]
+	if ok && current > source {
+		return
+	}
+
+	resolver.values[name] = value
+	resolver.sources[name] = source
+}
+
+func (resolver *Resolver) Lookup(name string) (value string, ok bool) {
+	resolver.mutex.RLock()
+	defer resolver.mutex.RUnlock()
+
+	value, ok = resolver.values[name]
+	return value, ok
+}
+
+func (resolver *Resolver) Values() map[string]string {
+	resolver.mutex.RLock()
+	defer resolver.mutex.RUnlock()
+
+	values := make(map[string]string, len(resolver.values))
+	for name, value := range resolver.values {
+		values[name] = value
+	}
+
+	return values
+}
+
+func (resolver *Resolver) Source(name string) (source Source, ok bool) {
+	resolver.mutex.RLock()
+	defer resolver.mutex.RUnlock()
+
+	source, ok = resolver.sources[name]
+	return source, ok
+}
+
+func (resolver *Resolver) LoadBuiltins(target *models.Target) {
+	resolver.Set(SourceBuiltin, "DEPLOY_REMOTE", target.Remote)
+	resolver.Set(SourceBuiltin, "DEPLOY_ENVIRONMENT", target.Environment)
+	resolver.Set(SourceBuiltin, "DEPLOY_STAGE", target.Stage)
+
+	wd, err := os.Getwd()
+	if err == nil {
+		resolver.Set(SourceBuiltin, "DEPLOY_DIRECTORY", wd)
+	}
+}
+
+func (resolver *Resolver) Clone() *Resolver {
+	resolver.mutex.RLock()
+	defer resolver.mutex.RUnlock()
+
+	clone := NewResolver()
+
+	for name, value := range resolver.values {
+		clone.values[name] = value
+		clone.sources[name] = resolver.sources[name]
+	}
+
+	for name, value := range resolver.secrets {
+		clone.secrets[name] = value
+	}
+
+	return clone
+}
+
+func (resolver *Resolver) LoadHost(name string, remote *models.Remote) {
+	resolver.Set(SourceBuiltin, "DEPLOY_REMOTE", name)
+	resolver.Set(SourceBuiltin, "DEPLOY_REMOTE_ADDRESS", remote.IPv4)
+	resolver.Set(SourceBuiltin, "DEPLOY_REMOTE_USER", remote.User)
+
+	if remote.Port > 0 {
+		resolver.Set(SourceBuiltin, "DEPLOY_REMOTE_PORT", strconv.Itoa(remote.Port))
+	}
+
+	resolver.LoadMap(SourceHost, remote.Variables)
+}
+
+func (resolver *Resolver) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]string)
+
+	err = sonic.Unmarshal(data, &values)
+	if err != nil {
+		return err
+	}
+
+	resolver.LoadMap(SourceFile, values)
+	return nil
+}
+
+func (resolver *Resolver) LoadMap(source Source, values map[string]string) {
+	for name, value := range values {
+		resolver.Set(source, name, value)
+	}
+}
+
+func (resolver *Resolver) LoadEnvironment(environ []string) {
+	for _, pair := range environ {
+		name, value, ok := strings.Cut(pair, "=")
+		if ok {
+			resolver.Set(SourceEnvironment, name, value)
+		}
+	}
+}
+
+func (resolver *Resolver) LoadFlags(flags []string) error {
+	for _, pair := range flags {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || name == "" {
+			return ErrFlagFormat
+		}
+
+		resolver.Set(SourceFlag, name, value)
+	}
+
+	return nil
+}
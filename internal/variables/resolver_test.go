@@ -0,0 +1,80 @@
+package variables
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/gohryt/dotdeploy/internal/models"
+)
+
+func TestResolverConcurrent(t *testing.T) {
+	tests := []struct {
+		name    string
+		operate func(resolver *Resolver, i int) error
+	}{
+		{name: "set", operate: func(resolver *Resolver, i int) error {
+			resolver.Set(SourceRegister, "REGISTERED_"+strconv.Itoa(i), "value")
+			return nil
+		}},
+		{name: "lookup", operate: func(resolver *Resolver, i int) error {
+			resolver.Lookup("VERSION")
+			return nil
+		}},
+		{name: "values", operate: func(resolver *Resolver, i int) error {
+			resolver.Values()
+			return nil
+		}},
+		{name: "secret", operate: func(resolver *Resolver, i int) error {
+			resolver.SetSecret("TOKEN_"+strconv.Itoa(i), "hunter2")
+			return nil
+		}},
+		{name: "expand", operate: func(resolver *Resolver, i int) error {
+			_, err := resolver.Expand("app-${VERSION} ${secret:TOKEN}")
+			return err
+		}},
+		{name: "environment", operate: func(resolver *Resolver, i int) error {
+			_, err := resolver.Environment(models.Environment{"VERSION": "${VERSION}"})
+			return err
+		}},
+		{name: "clone", operate: func(resolver *Resolver, i int) error {
+			resolver.Clone().LoadHost("web-"+strconv.Itoa(i), &models.Remote{IPv4: "10.0.0.1", User: "deploy", Port: 22})
+			return nil
+		}},
+		{name: "conceal", operate: func(resolver *Resolver, i int) error {
+			resolver.Conceal("Bearer hunter2")
+			return nil
+		}},
+	}
+
+	resolver := NewResolver()
+	resolver.Set(SourceConfig, "VERSION", "1.4.2")
+	resolver.SetSecret("TOKEN", "hunter2")
+
+	group := sync.WaitGroup{}
+
+	for i := range 8 {
+		for _, test := range tests {
+			group.Add(1)
+
+			go func() {
+				defer group.Done()
+
+				err := test.operate(resolver, i)
+				if err != nil {
+					t.Errorf("%s: %v", test.name, err)
+				}
+			}()
+		}
+	}
+
+	group.Wait()
+
+	for i := range 8 {
+		name := "REGISTERED_" + strconv.Itoa(i)
+
+		if source, ok := resolver.Source(name); !ok || source != SourceRegister {
+			t.Errorf("%s has source %s, expected %s", name, source, SourceRegister)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package version
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type (
+	Version struct {
+		Numbers    []int
+		Prerelease []string
+		text       string
+	}
+
+	Constraint struct {
+		text         string
+		alternatives [][]comparison
+	}
+
+	comparison struct {
+		operator string
+		version  *Version
+	}
+
+	VersionError struct {
+		Version string
+	}
+
+	ConstraintError struct {
+		Constraint string
+		Reason     string
+	}
+)
+
+var operators = []string{">=", "<=", "!=", "==", ">", "<", "=", "^", "~"}
+
+func (err *VersionError) Error() string {
+	return strconv.Quote(err.Version) + " is not a version"
+}
+
+func (err *ConstraintError) Error() string {
+	return "constraint " + strconv.Quote(err.Constraint) + ": " + err.Reason
+}
+
+func Parse(text string) (*Version, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(text), "v")
+	trimmed, _, _ = strings.Cut(trimmed, "+")
+	core, prerelease, hasPrerelease := strings.Cut(trimmed, "-")
+
+	version := &Version{text: strings.TrimSpace(text)}
+
+	for _, part := range strings.Split(core, ".") {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return nil, &VersionError{Version: text}
+		}
+
+		version.Numbers = append(version.Numbers, number)
+	}
+
+	if hasPrerelease {
+		if prerelease == "" {
+			return nil, &VersionError{Version: text}
+		}
+
+		version.Prerelease = strings.Split(prerelease, ".")
+	}
+
+	return version, nil
+}
+
+func (version *Version) String() string {
+	return version.text
+}
+
+func (version *Version) number(i int) int {
+	if i < len(version.Numbers) {
+		return version.Numbers[i]
+	}
+
+	return 0
+}
+
+func (version *Version) Compare(other *Version) int {
+	for i := 0; i < max(len(version.Numbers), len(other.Numbers)); i++ {
+		if a, b := version.number(i), other.number(i); a != b {
+			return compareInts(a, b)
+		}
+	}
+
+	switch {
+	case len(version.Prerelease) == 0 && len(other.Prerelease) == 0:
+		return 0
+	case len(version.Prerelease) == 0:
+		return 1
+	case len(other.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < min(len(version.Prerelease), len(other.Prerelease)); i++ {
+		if result := compareIdentifiers(version.Prerelease[i], other.Prerelease[i]); result != 0 {
+			return result
+		}
+	}
+
+	return compareInts(len(version.Prerelease), len(other.Prerelease))
+}
+
+func compareIdentifiers(a, b string) int {
+	first, firstErr := strconv.Atoi(a)
+	second, secondErr := strconv.Atoi(b)
+
+	switch {
+	case firstErr == nil && secondErr == nil:
+		return compareInts(first, second)
+	case firstErr == nil:
+		return -1
+	case secondErr == nil:
+		return 1
+	}
+
+	return strings.Compare(a, b)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+func ParseConstraint(text string) (*Constraint, error) {
+	constraint := &Constraint{text: strings.TrimSpace(text)}
+
+	for _, alternative := range strings.Split(text, "||") {
+		comparisons := []comparison(nil)
+
+		terms := strings.FieldsFunc(alternative, func(r rune) bool { return r == ',' || r == ' ' })
+
+		for i := 0; i < len(terms); i++ {
+			term := terms[i]
+			if slices.Contains(operators, term) && i+1 < len(terms) {
+				i++
+				term += terms[i]
+			}
+
+			operator := "="
+
+			for _, candidate := range operators {
+				if rest, ok := strings.CutPrefix(term, candidate); ok {
+					operator, term = candidate, rest
+					break
+				}
+			}
+
+			if operator == "==" {
+				operator = "="
+			}
+
+			version, err := Parse(term)
+			if err != nil {
+				return nil, &ConstraintError{Constraint: text, Reason: err.Error()}
+			}
+
+			comparisons = append(comparisons, comparison{operator: operator, version: version})
+		}
+
+		if len(comparisons) == 0 {
+			return nil, &ConstraintError{Constraint: text, Reason: "empty alternative"}
+		}
+
+		constraint.alternatives = append(constraint.alternatives, comparisons)
+	}
+
+	return constraint, nil
+}
+
+func (constraint *Constraint) String() string {
+	return constraint.text
+}
+
+func (constraint *Constraint) Allows(version *Version) bool {
+	for _, comparisons := range constraint.alternatives {
+		allowed := true
+
+		for _, comparison := range comparisons {
+			if !comparison.allows(version) {
+				allowed = false
+				break
+			}
+		}
+
+		if allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (comparison comparison) allows(version *Version) bool {
+	result := version.Compare(comparison.version)
+
+	switch comparison.operator {
+	case ">=":
+		return result >= 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	case "<":
+		return result < 0
+	case "!=":
+		return result != 0
+	case "^", "~":
+		return result >= 0 && version.Compare(comparison.upper()) < 0
+	}
+
+	return result == 0
+}
+
+func (comparison comparison) upper() *Version {
+	numbers := comparison.version.Numbers
+	position := 0
+
+	if comparison.operator == "~" {
+		position = min(1, len(numbers)-1)
+	} else {
+		for position < len(numbers)-1 && numbers[position] == 0 {
+			position++
+		}
+	}
+
+	upper := &Version{Numbers: append([]int(nil), numbers[:position+1]...), Prerelease: []string{"0"}}
+	upper.Numbers[position]++
+
+	return upper
+}